@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -12,25 +13,46 @@ import (
 	"renew-guard/internal/routes"
 	"renew-guard/internal/scheduler"
 	"renew-guard/internal/services"
+	"renew-guard/pkg/clock"
 	"renew-guard/pkg/email"
 	"renew-guard/pkg/jwt"
+	"renew-guard/pkg/notifier"
+	"renew-guard/pkg/ratelimit"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	fakeNow := flag.String("fake-now", "", "Simulate the current time, e.g. 2024-03-01 or 2024-03-01T09:00:00Z (not allowed in production)")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// Initialize clock
+	clk := clock.New()
+	if *fakeNow != "" {
+		if cfg.Server.Env == "production" {
+			log.Fatalf("--fake-now is not allowed in production")
+		}
+		start, err := parseFakeNow(*fakeNow)
+		if err != nil {
+			log.Fatalf("Invalid --fake-now value: %v", err)
+		}
+		clk = clock.NewOffset(start)
+		log.Printf("Using simulated clock starting at %s", start.Format(time.RFC3339))
+	}
+
 	// Set Gin mode
 	gin.SetMode(cfg.Server.GinMode)
 
 	// Initialize database
-	db, err := database.Initialize(cfg)
+	db, err := database.Initialize(cfg, clk)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
@@ -40,9 +62,35 @@ func main() {
 	userRepo := repositories.NewUserRepository(db)
 	subscriptionRepo := repositories.NewSubscriptionRepository(db)
 	notificationLogRepo := repositories.NewNotificationLogRepository(db)
+	renewalRepo := repositories.NewSubscriptionRenewalRepository(db)
+	reminderRepo := repositories.NewSubscriptionReminderRepository(db)
+	preferenceRepo := repositories.NewUserPreferenceRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	userTokenRepo := repositories.NewUserTokenRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(db)
+	loginFailureRepo := repositories.NewLoginFailureRepository(db)
+	organizationRepo := repositories.NewOrganizationRepository(db)
+	invitationRepo := repositories.NewOrganizationInvitationRepository(db)
+	calendarFeedRepo := repositories.NewCalendarFeedRepository(db)
+
+	// Promote bootstrap administrators
+	if promoted, err := userRepo.PromoteByEmails(cfg.Auth.AdminEmails); err != nil {
+		log.Fatalf("Failed to promote admin accounts: %v", err)
+	} else if promoted > 0 {
+		log.Printf("Promoted %d account(s) listed in ADMIN_EMAILS to admin", promoted)
+	}
 
 	// Initialize JWT utility
-	jwtUtil := jwt.NewJWTUtil(cfg.JWT.Secret, cfg.JWT.ExpirationHours)
+	jwtKeys, err := jwt.LoadKeys(cfg.JWT.KeyFiles, cfg.JWT.Secret)
+	if err != nil {
+		log.Fatalf("Failed to load JWT keys: %v", err)
+	}
+	jwtUtil, err := jwt.NewJWTUtil(jwtKeys, cfg.JWT.AccessTokenTTL, clk)
+	if err != nil {
+		log.Fatalf("Failed to initialize JWT signing: %v", err)
+	}
 
 	// Initialize email service
 	emailConfig := email.EmailConfig{
@@ -55,23 +103,71 @@ func main() {
 	}
 	emailService := email.NewEmailService(emailConfig)
 
+	// Initialize notification channels
+	httpClient := notifier.NewHTTPClient()
+	notifiers := notifier.NewRegistry(
+		notifier.NewEmailNotifier(emailService),
+		notifier.NewWebhookNotifier(httpClient),
+		notifier.NewSlackNotifier(httpClient),
+		notifier.NewDiscordNotifier(httpClient),
+	)
+
 	// Initialize services
-	authService := services.NewAuthService(userRepo, jwtUtil)
-	subscriptionService := services.NewSubscriptionService(subscriptionRepo)
-	notificationService := services.NewNotificationService(subscriptionRepo, notificationLogRepo, emailService)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo, &cfg.Auth, clk)
+	authService := services.NewAuthService(
+		userRepo, refreshTokenRepo, userTokenRepo, loginFailureRepo, twoFactorService, emailService, jwtUtil, cfg.JWT.RefreshTokenTTL, &cfg.Auth, clk,
+	)
+	organizationService := services.NewOrganizationService(organizationRepo, invitationRepo, userRepo, emailService, cfg.Auth.BaseURL, clk)
+	subscriptionService := services.NewSubscriptionService(
+		subscriptionRepo, renewalRepo, reminderRepo, notificationLogRepo, preferenceRepo, organizationService, clk,
+	)
+	outboxService := services.NewOutboxService(outboxRepo, notificationLogRepo, notifiers, &cfg.Outbox, clk)
+	calendarService := services.NewCalendarService(
+		calendarFeedRepo, subscriptionRepo, preferenceRepo, cfg.Auth.BaseURL, cfg.Scheduler.NotificationDaysBefore, clk,
+	)
+	notificationService := services.NewNotificationService(
+		subscriptionRepo, notificationLogRepo, reminderRepo, preferenceRepo, organizationRepo, outboxService, calendarService, clk,
+	)
+	renewalService := services.NewRenewalService(subscriptionRepo, clk)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, clk)
+	accountService := services.NewAccountService(userRepo, refreshTokenRepo, organizationRepo, authService, emailService, clk)
+	preferenceService := services.NewPreferenceService(preferenceRepo, cfg.Scheduler.NotificationDaysBefore)
+	adminService := services.NewAdminService(userRepo, subscriptionRepo, refreshTokenRepo, clk)
+
+	// Initialize scheduler
+	schedulerInstance := scheduler.NewScheduler(
+		notificationService, renewalService, outboxService, &cfg.Scheduler, &cfg.Outbox, clk,
+	)
 
 	// Initialize controllers
 	authController := controllers.NewAuthController(authService)
-	subscriptionController := controllers.NewSubscriptionController(subscriptionService, emailService)
+	subscriptionController := controllers.NewSubscriptionController(subscriptionService, notificationService, calendarService, emailService)
 	emailTestController := controllers.NewEmailTestController(emailService)
+	preferenceController := controllers.NewPreferenceController(preferenceService)
+	accountController := controllers.NewAccountController(accountService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	twoFactorController := controllers.NewTwoFactorController(twoFactorService)
+	adminController := controllers.NewAdminController(adminService, notificationService, schedulerInstance)
+	organizationController := controllers.NewOrganizationController(organizationService)
+	calendarController := controllers.NewCalendarController(calendarService)
 
 	// Initialize router
 	router := gin.Default()
-	appRouter := routes.NewRouter(authController, subscriptionController, emailTestController, jwtUtil)
+	// Client IPs key the rate limits, so forwarding headers are only
+	// believed from configured proxies
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	appRouter := routes.NewRouter(
+		authController, subscriptionController, emailTestController, preferenceController, accountController,
+		apiKeyController, twoFactorController, adminController, organizationController, calendarController,
+		jwtUtil, authService, apiKeyService,
+		ratelimit.New(cfg.Auth.PasswordResetIPLimit, time.Hour, clk),
+		ratelimit.New(cfg.Auth.LoginIPFailureLimit, cfg.Auth.LoginLockoutDuration, clk),
+	)
 	appRouter.SetupRoutes(router)
 
-	// Initialize and start scheduler
-	schedulerInstance := scheduler.NewScheduler(notificationService, &cfg.Scheduler)
+	// Start scheduler
 	if err := schedulerInstance.Start(); err != nil {
 		log.Fatalf("Failed to start scheduler: %v", err)
 	}
@@ -96,3 +192,11 @@ func main() {
 
 	log.Println("Shutting down server...")
 }
+
+// parseFakeNow accepts a full RFC 3339 timestamp or a bare date (midnight UTC)
+func parseFakeNow(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
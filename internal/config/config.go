@@ -5,6 +5,8 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -13,14 +15,17 @@ type Config struct {
 	Server    ServerConfig
 	Database  DatabaseConfig
 	JWT       JWTConfig
+	Auth      AuthConfig
 	Email     EmailConfig
 	Scheduler SchedulerConfig
+	Outbox    OutboxConfig
 }
 
 type ServerConfig struct {
-	Port   string
-	Env    string
-	GinMode string
+	Port           string
+	Env            string
+	GinMode        string
+	TrustedProxies []string // Proxies whose X-Forwarded-For is believed; none by default
 }
 
 type DatabaseConfig struct {
@@ -33,8 +38,25 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret          string
-	ExpirationHours int
+	Secret          string        // HS256 secret; only verifies old tokens once KeyFiles is set
+	KeyFiles        []string      // PEM files of RSA or Ed25519 keys; the first one signs
+	AccessTokenTTL  time.Duration // Lifetime of signed access tokens; keep short
+	RefreshTokenTTL time.Duration // Lifetime of a refresh token before it must be rotated
+}
+
+// AuthConfig controls account recovery and the links mailed to users
+type AuthConfig struct {
+	BaseURL                 string        // Public URL that emailed links point to
+	PasswordResetTTL        time.Duration // How long a password reset link stays valid
+	PasswordResetEmailLimit int           // Reset emails per address per hour
+	PasswordResetIPLimit    int           // Reset requests per client IP per hour
+	EmailVerificationTTL    time.Duration // How long an email verification link stays valid
+	TOTPIssuer              string        // Name authenticator apps show for enrolled accounts
+	LoginChallengeTTL       time.Duration // How long a two-factor login challenge stays valid
+	LoginMaxFailedAttempts  int           // Consecutive failed logins before an account is locked
+	LoginLockoutDuration    time.Duration // How long a locked account refuses logins
+	LoginIPFailureLimit     int           // Failed logins per client IP per lockout duration
+	AdminEmails             []string      // Accounts promoted to admin at startup
 }
 
 type EmailConfig struct {
@@ -47,9 +69,21 @@ type EmailConfig struct {
 }
 
 type SchedulerConfig struct {
-	Enabled              bool
-	CronExpression       string
+	Enabled                bool
+	CronExpression         string
 	NotificationDaysBefore int
+	RenewalCronExpression  string
+	DigestCronExpression   string // When digest-mode users get their grouped reminder
+	Timezone               string // Zone cron expressions are evaluated in
+}
+
+// OutboxConfig controls delivery retries of queued notifications
+type OutboxConfig struct {
+	CronExpression string
+	BatchSize      int
+	MaxAttempts    int
+	BackoffBase    time.Duration // Delay before the first retry, doubled on each attempt
+	BackoffMax     time.Duration
 }
 
 var AppConfig *Config
@@ -62,7 +96,6 @@ func Load() (*Config, error) {
 	// Validate required environment variables
 	required := []string{
 		"DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME",
-		"JWT_SECRET",
 		"SMTP_HOST", "SMTP_PORT", "SMTP_USERNAME", "SMTP_PASSWORD",
 	}
 
@@ -72,9 +105,10 @@ func Load() (*Config, error) {
 		}
 	}
 
-	jwtExpHours, err := strconv.Atoi(getEnv("JWT_EXPIRATION_HOURS", "72"))
-	if err != nil {
-		jwtExpHours = 72
+	// Tokens are signed with key files when given, otherwise with the shared secret
+	jwtKeyFiles := splitList(os.Getenv("JWT_KEY_FILES"))
+	if len(jwtKeyFiles) == 0 && os.Getenv("JWT_SECRET") == "" {
+		return nil, fmt.Errorf("either JWT_KEY_FILES or JWT_SECRET must be set")
 	}
 
 	schedulerEnabled, err := strconv.ParseBool(getEnv("SCHEDULER_ENABLED", "true"))
@@ -89,9 +123,10 @@ func Load() (*Config, error) {
 
 	config := &Config{
 		Server: ServerConfig{
-			Port:    getEnv("SERVER_PORT", "8080"),
-			Env:     getEnv("APP_ENV", "development"),
-			GinMode: getEnv("GIN_MODE", "debug"),
+			Port:           getEnv("SERVER_PORT", "8080"),
+			Env:            getEnv("APP_ENV", "development"),
+			GinMode:        getEnv("GIN_MODE", "debug"),
+			TrustedProxies: splitList(os.Getenv("TRUSTED_PROXIES")),
 		},
 		Database: DatabaseConfig{
 			Host:     os.Getenv("DB_HOST"),
@@ -103,7 +138,22 @@ func Load() (*Config, error) {
 		},
 		JWT: JWTConfig{
 			Secret:          os.Getenv("JWT_SECRET"),
-			ExpirationHours: jwtExpHours,
+			KeyFiles:        jwtKeyFiles,
+			AccessTokenTTL:  time.Duration(getEnvInt("JWT_ACCESS_TOKEN_MINUTES", 15)) * time.Minute,
+			RefreshTokenTTL: time.Duration(getEnvInt("JWT_REFRESH_TOKEN_HOURS", 720)) * time.Hour,
+		},
+		Auth: AuthConfig{
+			BaseURL:                 strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:8080"), "/"),
+			PasswordResetTTL:        time.Duration(getEnvInt("PASSWORD_RESET_TOKEN_MINUTES", 60)) * time.Minute,
+			PasswordResetEmailLimit: getEnvInt("PASSWORD_RESET_EMAIL_LIMIT", 3),
+			PasswordResetIPLimit:    getEnvInt("PASSWORD_RESET_IP_LIMIT", 10),
+			EmailVerificationTTL:    time.Duration(getEnvInt("EMAIL_VERIFICATION_TOKEN_HOURS", 48)) * time.Hour,
+			TOTPIssuer:              getEnv("TOTP_ISSUER", "RenewGuard"),
+			LoginChallengeTTL:       time.Duration(getEnvInt("LOGIN_CHALLENGE_MINUTES", 5)) * time.Minute,
+			LoginMaxFailedAttempts:  getEnvInt("LOGIN_MAX_FAILED_ATTEMPTS", 10),
+			LoginLockoutDuration:    time.Duration(getEnvInt("LOGIN_LOCKOUT_MINUTES", 15)) * time.Minute,
+			LoginIPFailureLimit:     getEnvInt("LOGIN_IP_FAILURE_LIMIT", 30),
+			AdminEmails:             splitList(os.Getenv("ADMIN_EMAILS")),
 		},
 		Email: EmailConfig{
 			SMTPHost:     os.Getenv("SMTP_HOST"),
@@ -113,13 +163,41 @@ func Load() (*Config, error) {
 			FromEmail:    getEnv("SMTP_FROM_EMAIL", "noreply@renewguard.com"),
 			FromName:     getEnv("SMTP_FROM_NAME", "RenewGuard"),
 		},
+		Outbox: OutboxConfig{
+			CronExpression: getEnv("OUTBOX_CRON", "@every 1m"),
+			BatchSize:      getEnvInt("OUTBOX_BATCH_SIZE", 50),
+			MaxAttempts:    getEnvInt("OUTBOX_MAX_ATTEMPTS", 5),
+			BackoffBase:    time.Duration(getEnvInt("OUTBOX_BACKOFF_BASE_SECONDS", 60)) * time.Second,
+			BackoffMax:     time.Duration(getEnvInt("OUTBOX_BACKOFF_MAX_SECONDS", 21600)) * time.Second,
+		},
 		Scheduler: SchedulerConfig{
-			Enabled:              schedulerEnabled,
-			CronExpression:       getEnv("SCHEDULER_CRON", "0 0 * * *"),
+			Enabled:                schedulerEnabled,
+			CronExpression:         getEnv("SCHEDULER_CRON", "0 0 * * *"),
 			NotificationDaysBefore: notificationDaysBefore,
+			RenewalCronExpression:  getEnv("RENEWAL_CRON", "0 * * * *"),
+			DigestCronExpression:   getEnv("DIGEST_CRON", "0 8 * * *"),
+			Timezone:               getEnv("SCHEDULER_TIMEZONE", "UTC"),
 		},
 	}
 
+	// Limits below one would refuse every request, deliver nothing or
+	// dead-letter every message on its first failure
+	limits := []struct {
+		key   string
+		value int
+	}{
+		{"PASSWORD_RESET_EMAIL_LIMIT", config.Auth.PasswordResetEmailLimit},
+		{"PASSWORD_RESET_IP_LIMIT", config.Auth.PasswordResetIPLimit},
+		{"LOGIN_IP_FAILURE_LIMIT", config.Auth.LoginIPFailureLimit},
+		{"OUTBOX_BATCH_SIZE", config.Outbox.BatchSize},
+		{"OUTBOX_MAX_ATTEMPTS", config.Outbox.MaxAttempts},
+	}
+	for _, limit := range limits {
+		if limit.value < 1 {
+			return nil, fmt.Errorf("%s must be at least 1", limit.key)
+		}
+	}
+
 	AppConfig = config
 	log.Println("Configuration loaded successfully")
 	return config, nil
@@ -139,3 +217,22 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// splitList parses a comma-separated list, dropping blank entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(getEnv(key, strconv.Itoa(defaultValue)))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
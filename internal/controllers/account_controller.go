@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	accountService services.AccountService
+}
+
+func NewAccountController(accountService services.AccountService) *AccountController {
+	return &AccountController{
+		accountService: accountService,
+	}
+}
+
+type UpdateProfileRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"` // Current password, re-checked for the change
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
+// GetProfile retrieves the authenticated user's account
+// @Summary Get current user
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.User
+// @Router /api/me [get]
+func (ctrl *AccountController) GetProfile(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	user, err := ctrl.accountService.GetProfile(userID)
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve account")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account retrieved successfully", user)
+}
+
+// UpdateProfile changes the authenticated user's email address
+// @Summary Change email address
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdateProfileRequest true "New email and current password"
+// @Success 200 {object} models.User
+// @Router /api/me [patch]
+func (ctrl *AccountController) UpdateProfile(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := ctrl.accountService.ChangeEmail(userID, req.Password, req.Email)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidPassword:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Current password is incorrect")
+		case services.ErrInvalidEmail:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid email format")
+		case services.ErrEmailUnchanged:
+			utils.ErrorResponse(c, http.StatusBadRequest, "New email is the same as the current one")
+		case services.ErrEmailAlreadyExists:
+			utils.ErrorResponse(c, http.StatusConflict, "Email already exists")
+		case services.ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update account")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email updated, please verify the new address", user)
+}
+
+// ChangePassword changes the authenticated user's password
+// @Summary Change password
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200
+// @Router /api/me/password [post]
+func (ctrl *AccountController) ChangePassword(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	sessionID, _ := middleware.GetSessionID(c)
+
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	err := ctrl.accountService.ChangePassword(userID, sessionID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidPassword:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Current password is incorrect")
+		case services.ErrWeakPassword:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Password must be at least 6 characters")
+		case services.ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to change password")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password changed, other sessions have been signed out", nil)
+}
+
+// DeleteAccount permanently deletes the authenticated user's account
+// @Summary Delete account
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DeleteAccountRequest true "Current password"
+// @Success 200
+// @Router /api/me [delete]
+func (ctrl *AccountController) DeleteAccount(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := ctrl.accountService.DeleteAccount(userID, req.Password); err != nil {
+		switch err {
+		case services.ErrInvalidPassword:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Current password is incorrect")
+		case services.ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		case services.ErrSoleOrganizationOwner:
+			utils.ErrorResponse(c, http.StatusConflict, "Transfer ownership of your organizations before deleting your account")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete account")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account deleted successfully", nil)
+}
@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
+	"renew-guard/internal/scheduler"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobRunner triggers the scheduler's jobs on demand
+type JobRunner interface {
+	RunNow() error
+	RunRenewalsNow() error
+	RunDigestsNow() error
+	RunOutboxNow() error
+}
+
+type AdminController struct {
+	adminService        services.AdminService
+	notificationService services.NotificationService
+	jobs                JobRunner
+}
+
+func NewAdminController(
+	adminService services.AdminService,
+	notificationService services.NotificationService,
+	jobs JobRunner,
+) *AdminController {
+	return &AdminController{
+		adminService:        adminService,
+		notificationService: notificationService,
+		jobs:                jobs,
+	}
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" binding:"required"` // "user" or "admin"
+}
+
+// ListUsers lists every account
+// @Summary List users
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.User
+// @Router /api/admin/users [get]
+func (ctrl *AdminController) ListUsers(c *gin.Context) {
+	users, err := ctrl.adminService.ListUsers()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve users")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Users retrieved successfully", users)
+}
+
+// GetUser retrieves a single account
+// @Summary Get user
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Router /api/admin/users/{id} [get]
+func (ctrl *AdminController) GetUser(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	user, err := ctrl.adminService.GetUser(id)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve user")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User retrieved successfully", user)
+}
+
+// DisableUser blocks an account from signing in and signs it out everywhere
+// @Summary Disable user
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Router /api/admin/users/{id}/disable [post]
+func (ctrl *AdminController) DisableUser(c *gin.Context) {
+	ctrl.setDisabled(c, true)
+}
+
+// EnableUser lifts a previous DisableUser
+// @Summary Enable user
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Router /api/admin/users/{id}/enable [post]
+func (ctrl *AdminController) EnableUser(c *gin.Context) {
+	ctrl.setDisabled(c, false)
+}
+
+func (ctrl *AdminController) setDisabled(c *gin.Context, disabled bool) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	user, err := ctrl.adminService.SetDisabled(adminID, id, disabled)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to update user")
+		return
+	}
+
+	message := "User enabled successfully"
+	if disabled {
+		message = "User disabled successfully"
+	}
+	log.Printf("Admin %d set disabled=%t on user %d", adminID, disabled, id)
+
+	utils.SuccessResponse(c, http.StatusOK, message, user)
+}
+
+// UpdateRole changes a user's role
+// @Summary Change user role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body UpdateRoleRequest true "New role"
+// @Success 200 {object} models.User
+// @Router /api/admin/users/{id}/role [put]
+func (ctrl *AdminController) UpdateRole(c *gin.Context) {
+	adminID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, err := ctrl.adminService.SetRole(adminID, id, req.Role)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to update role")
+		return
+	}
+	log.Printf("Admin %d set role %q on user %d", adminID, req.Role, id)
+
+	utils.SuccessResponse(c, http.StatusOK, "Role updated successfully", user)
+}
+
+// GetUserSubscriptions lists any user's subscriptions
+// @Summary List a user's subscriptions
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {array} models.Subscription
+// @Router /api/admin/users/{id}/subscriptions [get]
+func (ctrl *AdminController) GetUserSubscriptions(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	subscriptions, err := ctrl.adminService.GetUserSubscriptions(id)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve subscriptions")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscriptions retrieved successfully", subscriptions)
+}
+
+// GetSubscription retrieves any subscription regardless of owner
+// @Summary Get subscription
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} models.Subscription
+// @Router /api/admin/subscriptions/{id} [get]
+func (ctrl *AdminController) GetSubscription(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid subscription ID")
+	if !ok {
+		return
+	}
+
+	subscription, err := ctrl.adminService.GetSubscription(id)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve subscription")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Subscription retrieved successfully", subscription)
+}
+
+// GetNotificationLogs retrieves the delivery history of any subscription
+// @Summary Get subscription notification logs
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param status query string false "Filter by status (success, failed, dead_letter, skipped)"
+// @Success 200 {array} models.NotificationLog
+// @Router /api/admin/subscriptions/{id}/logs [get]
+func (ctrl *AdminController) GetNotificationLogs(c *gin.Context) {
+	id, ok := parseIDParam(c, "id", "Invalid subscription ID")
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.NotificationStatusSuccess, models.NotificationStatusFailed, models.NotificationStatusDeadLetter,
+		models.NotificationStatusSkipped:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status filter")
+		return
+	}
+
+	if _, err := ctrl.adminService.GetSubscription(id); err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve notification logs")
+		return
+	}
+
+	logs, err := ctrl.notificationService.GetLogs(id, status)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notification logs")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification logs retrieved successfully", logs)
+}
+
+// RunJob runs a scheduled job immediately and waits for it to finish
+// @Summary Run scheduled job
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param job path string true "notifications, renewals, digests or outbox"
+// @Success 200
+// @Router /api/admin/jobs/{job}/run [post]
+func (ctrl *AdminController) RunJob(c *gin.Context) {
+	var run func() error
+	job := c.Param("job")
+	switch job {
+	case "notifications":
+		run = ctrl.jobs.RunNow
+	case "renewals":
+		run = ctrl.jobs.RunRenewalsNow
+	case "digests":
+		run = ctrl.jobs.RunDigestsNow
+	case "outbox":
+		run = ctrl.jobs.RunOutboxNow
+	default:
+		utils.ErrorResponse(c, http.StatusNotFound, "Unknown job")
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(c)
+	log.Printf("Admin %d triggered %s job", adminID, job)
+
+	if err := run(); err != nil {
+		if errors.Is(err, scheduler.ErrJobRunning) {
+			utils.ErrorResponse(c, http.StatusConflict, "Job is already running")
+			return
+		}
+		log.Printf("Manual %s job failed: %v", job, err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Job failed")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Job completed successfully", gin.H{"job": job})
+}
+
+// handleError maps admin service errors to responses
+func (ctrl *AdminController) handleError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrUserNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+	case services.ErrSubscriptionNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "Subscription not found")
+	case services.ErrInvalidRole:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Role must be user or admin")
+	case services.ErrCannotModifySelf:
+		utils.ErrorResponse(c, http.StatusBadRequest, "You cannot disable or demote your own account")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
+
+// parseIDParam reads a numeric path parameter, writing a 400 response if it is malformed
+func parseIDParam(c *gin.Context, name, message string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, message)
+		return 0, false
+	}
+	return uint(id), true
+}
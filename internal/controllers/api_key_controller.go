@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at"` // Omit for a key that never expires
+}
+
+// CreateAPIKeyResponse includes the raw key, which is only ever shown here
+type CreateAPIKeyResponse struct {
+	models.APIKey
+	Key string `json:"key"`
+}
+
+// ListAPIKeys lists the authenticated user's API keys
+// @Summary List API keys
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.APIKey
+// @Router /api/me/api-keys [get]
+func (ctrl *APIKeyController) ListAPIKeys(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	keys, err := ctrl.apiKeyService.List(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve API keys")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+// CreateAPIKey issues a new API key for the authenticated user
+// @Summary Create API key
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body CreateAPIKeyRequest true "Key name, scopes and optional expiry"
+// @Success 201 {object} CreateAPIKeyResponse
+// @Router /api/me/api-keys [post]
+func (ctrl *APIKeyController) CreateAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	key, rawKey, err := ctrl.apiKeyService.Create(userID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidKeyName, services.ErrInvalidScopes, services.ErrInvalidKeyExpiry:
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create API key")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created, copy it now as it will not be shown again",
+		CreateAPIKeyResponse{APIKey: *key, Key: rawKey})
+}
+
+// RevokeAPIKey revokes one of the authenticated user's API keys
+// @Summary Revoke API key
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API key ID"
+// @Success 200
+// @Router /api/me/api-keys/{id} [delete]
+func (ctrl *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := ctrl.apiKeyService.Revoke(uint(id), userID); err != nil {
+		if err == services.ErrAPIKeyNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "API key not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to revoke API key")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
@@ -1,7 +1,10 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
 	"renew-guard/internal/services"
 	"renew-guard/pkg/utils"
 
@@ -28,14 +31,47 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+type LoginTwoFactorRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required"` // TOTP code or recovery code
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type LogoutRequest struct {
+	AllSessions bool `json:"all_sessions"` // Log out every device instead of just this session
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
 type AuthResponse struct {
-	User  UserResponse `json:"user"`
-	Token string       `json:"token"`
+	User         UserResponse `json:"user"`
+	Token        string       `json:"token"`
+	RefreshToken string       `json:"refresh_token"`
+	ExpiresIn    int          `json:"expires_in"` // Access token lifetime in seconds
+}
+
+// TwoFactorChallengeResponse is returned by login when a second factor is required
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int    `json:"expires_in"` // Challenge lifetime in seconds
 }
 
 type UserResponse struct {
-	ID    uint   `json:"id"`
-	Email string `json:"email"`
+	ID            uint   `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Role          string `json:"role"`
 }
 
 // Register handles user registration
@@ -53,7 +89,7 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	user, token, err := ctrl.authService.Register(req.Email, req.Password)
+	user, tokens, err := ctrl.authService.Register(req.Email, req.Password)
 	if err != nil {
 		switch err {
 		case services.ErrEmailAlreadyExists:
@@ -68,13 +104,7 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 		return
 	}
 
-	response := AuthResponse{
-		User: UserResponse{
-			ID:    user.ID,
-			Email: user.Email,
-		},
-		Token: token,
-	}
+	response := newAuthResponse(user, tokens)
 
 	utils.SuccessResponse(c, http.StatusCreated, "User registered successfully", response)
 }
@@ -86,6 +116,7 @@ func (ctrl *AuthController) Register(c *gin.Context) {
 // @Produce json
 // @Param request body LoginRequest true "Login credentials"
 // @Success 200 {object} AuthResponse
+// @Success 200 {object} TwoFactorChallengeResponse
 // @Router /api/auth/login [post]
 func (ctrl *AuthController) Login(c *gin.Context) {
 	var req LoginRequest
@@ -94,23 +125,264 @@ func (ctrl *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := ctrl.authService.Login(req.Email, req.Password)
+	user, tokens, challenge, err := ctrl.authService.Login(req.Email, req.Password)
 	if err != nil {
-		if err == services.ErrInvalidCredentials {
+		var lockout *services.LockoutError
+		switch {
+		case errors.As(err, &lockout):
+			middleware.SetRetryAfter(c, lockout.RetryAfter)
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many failed login attempts, please try again later")
+		case err == services.ErrInvalidCredentials:
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid email or password")
-		} else {
+		case err == services.ErrAccountDisabled:
+			utils.ErrorResponse(c, http.StatusForbidden, "Account has been disabled")
+		default:
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to login")
 		}
 		return
 	}
 
-	response := AuthResponse{
-		User: UserResponse{
-			ID:    user.ID,
-			Email: user.Email,
-		},
-		Token: token,
+	if challenge != nil {
+		utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication required", TwoFactorChallengeResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge.Token,
+			ExpiresIn:         challenge.ExpiresIn,
+		})
+		return
 	}
 
+	response := newAuthResponse(user, tokens)
+
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
 }
+
+// LoginTwoFactor completes a login with the challenge token and a second-factor code
+// @Summary Complete two-factor login
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginTwoFactorRequest true "Challenge token and code"
+// @Success 200 {object} AuthResponse
+// @Router /api/auth/login/2fa [post]
+func (ctrl *AuthController) LoginTwoFactor(c *gin.Context) {
+	var req LoginTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, tokens, err := ctrl.authService.LoginTwoFactor(req.ChallengeToken, req.Code)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidChallenge:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired login challenge")
+		case services.ErrInvalidTwoFactorCode:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid two-factor code")
+		case services.ErrTooManyTwoFactorCodes:
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many two-factor attempts, please try again later")
+		case services.ErrAccountDisabled:
+			utils.ErrorResponse(c, http.StatusForbidden, "Account has been disabled")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to login")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", newAuthResponse(user, tokens))
+}
+
+// Refresh exchanges a refresh token for a new access and refresh token
+// @Summary Refresh access token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body RefreshRequest true "Refresh token"
+// @Success 200 {object} AuthResponse
+// @Router /api/auth/refresh [post]
+func (ctrl *AuthController) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user, tokens, err := ctrl.authService.Refresh(req.RefreshToken)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidRefreshToken:
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		case services.ErrAccountDisabled:
+			utils.ErrorResponse(c, http.StatusForbidden, "Account has been disabled")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to refresh token")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", newAuthResponse(user, tokens))
+}
+
+// Logout revokes the current session, or all sessions of the user
+// @Summary Logout user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body LogoutRequest false "Logout options"
+// @Success 200
+// @Router /api/auth/logout [post]
+func (ctrl *AuthController) Logout(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+	sessionID, _ := middleware.GetSessionID(c)
+
+	var req LogoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	if err := ctrl.authService.Logout(userID, sessionID, req.AllSessions); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to logout")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Logged out successfully", nil)
+}
+
+// ForgotPassword emails a password reset link
+// @Summary Request a password reset email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ForgotPasswordRequest true "Account email"
+// @Success 200
+// @Router /api/auth/forgot-password [post]
+func (ctrl *AuthController) ForgotPassword(c *gin.Context) {
+	var req ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := ctrl.authService.ForgotPassword(req.Email); err != nil {
+		if err == services.ErrInvalidEmail {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid email format")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to process password reset request")
+		}
+		return
+	}
+
+	// Same answer whether or not the account exists
+	utils.SuccessResponse(c, http.StatusOK, "If an account exists for this email, a reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password using an emailed reset token
+// @Summary Reset password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ResetPasswordRequest true "Reset token and new password"
+// @Success 200
+// @Router /api/auth/reset-password [post]
+func (ctrl *AuthController) ResetPassword(c *gin.Context) {
+	var req ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := ctrl.authService.ResetPassword(req.Token, req.Password); err != nil {
+		switch err {
+		case services.ErrInvalidResetToken:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or expired reset token")
+		case services.ErrWeakPassword:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Password must be at least 6 characters")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reset password")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password has been reset, please log in again", nil)
+}
+
+// VerifyEmail confirms an email address using the emailed token
+// @Summary Verify email address
+// @Tags auth
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} UserResponse
+// @Router /api/auth/verify [get]
+func (ctrl *AuthController) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Verification token is required")
+		return
+	}
+
+	user, err := ctrl.authService.VerifyEmail(token)
+	if err != nil {
+		if err == services.ErrInvalidVerifyToken {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid or expired verification token")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to verify email")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email verified successfully", newUserResponse(user))
+}
+
+// ResendVerification sends a new verification email to the authenticated user
+// @Summary Resend verification email
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200
+// @Router /api/auth/verify/resend [post]
+func (ctrl *AuthController) ResendVerification(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := ctrl.authService.ResendVerification(userID); err != nil {
+		switch err {
+		case services.ErrAlreadyVerified:
+			utils.ErrorResponse(c, http.StatusConflict, "Email address is already verified")
+		case services.ErrTooManyEmails:
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many emails requested, please try again later")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to send verification email")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Verification email sent", nil)
+}
+
+func newUserResponse(user *models.User) UserResponse {
+	return UserResponse{
+		ID:            user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Role:          user.Role,
+	}
+}
+
+func newAuthResponse(user *models.User, tokens *services.TokenPair) AuthResponse {
+	return AuthResponse{
+		User:         newUserResponse(user),
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}
+}
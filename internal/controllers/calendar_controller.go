@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CalendarController struct {
+	calendarService services.CalendarService
+}
+
+func NewCalendarController(calendarService services.CalendarService) *CalendarController {
+	return &CalendarController{
+		calendarService: calendarService,
+	}
+}
+
+// RotateCalendarFeedResponse includes the feed URL, which is only ever shown here
+type RotateCalendarFeedResponse struct {
+	models.CalendarFeed
+	URL string `json:"url"`
+}
+
+// GetCalendarFeed shows whether the authenticated user has a calendar feed
+// @Summary Get calendar feed
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.CalendarFeed
+// @Router /api/me/calendar [get]
+func (ctrl *CalendarController) GetCalendarFeed(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	feed, err := ctrl.calendarService.GetFeed(userID)
+	if err != nil {
+		if err == services.ErrCalendarFeedNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Calendar feed not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve calendar feed")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Calendar feed retrieved successfully", feed)
+}
+
+// RotateCalendarFeed issues a new secret feed URL, replacing any previous one
+// @Summary Create or rotate calendar feed URL
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} RotateCalendarFeedResponse
+// @Router /api/me/calendar [post]
+func (ctrl *CalendarController) RotateCalendarFeed(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	feed, rawToken, err := ctrl.calendarService.RotateFeed(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to issue calendar feed")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Calendar feed issued, copy the URL now as it will not be shown again",
+		RotateCalendarFeedResponse{CalendarFeed: *feed, URL: ctrl.calendarService.FeedURL(rawToken)})
+}
+
+// DeleteCalendarFeed turns the authenticated user's calendar feed off
+// @Summary Delete calendar feed
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response
+// @Router /api/me/calendar [delete]
+func (ctrl *CalendarController) DeleteCalendarFeed(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	if err := ctrl.calendarService.DeleteFeed(userID); err != nil {
+		if err == services.ErrCalendarFeedNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Calendar feed not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete calendar feed")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Calendar feed deleted successfully", nil)
+}
+
+// GetCalendar serves the iCalendar feed behind a secret token. The token in
+// the URL is the only credential, so calendar apps can subscribe to it.
+// @Summary Get renewal calendar
+// @Tags calendar
+// @Produce text/calendar
+// @Param file path string true "Feed token followed by .ics"
+// @Success 200 {string} string "RFC 5545 calendar"
+// @Router /api/calendar/{token}.ics [get]
+func (ctrl *CalendarController) GetCalendar(c *gin.Context) {
+	rawToken, ok := strings.CutSuffix(c.Param("file"), ".ics")
+	if !ok || rawToken == "" {
+		utils.ErrorResponse(c, http.StatusNotFound, "Calendar not found")
+		return
+	}
+
+	calendar, err := ctrl.calendarService.Render(rawToken)
+	if err != nil {
+		if err == services.ErrInvalidCalendarToken {
+			utils.ErrorResponse(c, http.StatusNotFound, "Calendar not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to build calendar")
+		}
+		return
+	}
+
+	// The feed only changes with the subscriptions, so its hash makes a
+	// stable tag that lets polling clients skip unchanged calendars
+	body := calendar.Bytes()
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", body)
+}
@@ -0,0 +1,579 @@
+package controllers
+
+import (
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationController struct {
+	organizationService services.OrganizationService
+}
+
+func NewOrganizationController(organizationService services.OrganizationService) *OrganizationController {
+	return &OrganizationController{
+		organizationService: organizationService,
+	}
+}
+
+type OrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+type InviteOrganizationMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+type UpdateOrganizationMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member viewer"`
+}
+
+// OrganizationResponse is an organization as seen by one of its members
+type OrganizationResponse struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"` // The caller's role
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// OrganizationInvitationResponse is a pending invitation to join an organization
+type OrganizationInvitationResponse struct {
+	ID               uint      `json:"id"`
+	OrganizationID   uint      `json:"organization_id"`
+	OrganizationName string    `json:"organization_name"`
+	Email            string    `json:"email"`
+	Role             string    `json:"role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+func newOrganizationResponse(organization *models.Organization, role string) OrganizationResponse {
+	return OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Role:      role,
+		CreatedAt: organization.CreatedAt,
+		UpdatedAt: organization.UpdatedAt,
+	}
+}
+
+func newOrganizationMemberResponse(member *models.OrganizationMember) OrganizationMemberResponse {
+	return OrganizationMemberResponse{
+		UserID:   member.UserID,
+		Email:    member.User.Email,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt,
+	}
+}
+
+func newOrganizationInvitationResponse(invitation *models.OrganizationInvitation) OrganizationInvitationResponse {
+	return OrganizationInvitationResponse{
+		ID:               invitation.ID,
+		OrganizationID:   invitation.OrganizationID,
+		OrganizationName: invitation.Organization.Name,
+		Email:            invitation.Email,
+		Role:             invitation.Role,
+		ExpiresAt:        invitation.ExpiresAt,
+		CreatedAt:        invitation.CreatedAt,
+	}
+}
+
+func newOrganizationInvitationResponses(invitations []models.OrganizationInvitation) []OrganizationInvitationResponse {
+	response := make([]OrganizationInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		response = append(response, newOrganizationInvitationResponse(&invitations[i]))
+	}
+	return response
+}
+
+// ListOrganizations returns the organizations the user belongs to
+// @Summary List organizations
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} OrganizationResponse
+// @Router /api/organizations [get]
+func (ctrl *OrganizationController) ListOrganizations(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	memberships, err := ctrl.organizationService.ListForUser(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve organizations")
+		return
+	}
+
+	organizations := make([]OrganizationResponse, 0, len(memberships))
+	for i := range memberships {
+		organizations = append(organizations, newOrganizationResponse(&memberships[i].Organization, memberships[i].Role))
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Organizations retrieved successfully", organizations)
+}
+
+// CreateOrganization creates an organization owned by the user
+// @Summary Create organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body OrganizationRequest true "Organization name"
+// @Success 201 {object} OrganizationResponse
+// @Router /api/organizations [post]
+func (ctrl *OrganizationController) CreateOrganization(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	organization, err := ctrl.organizationService.Create(userID, req.Name)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to create organization")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Organization created successfully",
+		newOrganizationResponse(organization, models.OrganizationRoleOwner))
+}
+
+// GetOrganization returns one of the user's organizations
+// @Summary Get organization
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {object} OrganizationResponse
+// @Router /api/organizations/{id} [get]
+func (ctrl *OrganizationController) GetOrganization(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	organization, member, err := ctrl.organizationService.Get(id, userID)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve organization")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Organization retrieved successfully",
+		newOrganizationResponse(organization, member.Role))
+}
+
+// UpdateOrganization renames an organization; admins and owners only
+// @Summary Rename organization
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body OrganizationRequest true "Organization name"
+// @Success 200 {object} OrganizationResponse
+// @Router /api/organizations/{id} [put]
+func (ctrl *OrganizationController) UpdateOrganization(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	organization, member, err := ctrl.organizationService.Rename(id, userID, req.Name)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to update organization")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Organization updated successfully",
+		newOrganizationResponse(organization, member.Role))
+}
+
+// DeleteOrganization deletes an organization and its shared subscriptions; owners only
+// @Summary Delete organization
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200
+// @Router /api/organizations/{id} [delete]
+func (ctrl *OrganizationController) DeleteOrganization(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.organizationService.Delete(id, userID); err != nil {
+		ctrl.handleError(c, err, "Failed to delete organization")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Organization deleted successfully", nil)
+}
+
+// ListMembers returns the members of an organization
+// @Summary List organization members
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {array} OrganizationMemberResponse
+// @Router /api/organizations/{id}/members [get]
+func (ctrl *OrganizationController) ListMembers(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	members, err := ctrl.organizationService.ListMembers(id, userID)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve members")
+		return
+	}
+
+	response := make([]OrganizationMemberResponse, 0, len(members))
+	for i := range members {
+		response = append(response, newOrganizationMemberResponse(&members[i]))
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Members retrieved successfully", response)
+}
+
+// InviteMember invites an email address to join an organization; admins and
+// owners only. The response is the same whether or not the address has an account.
+// @Summary Invite organization member
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param request body InviteOrganizationMemberRequest true "Invitee email and role"
+// @Success 201 {object} OrganizationInvitationResponse
+// @Router /api/organizations/{id}/invitations [post]
+func (ctrl *OrganizationController) InviteMember(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	var req InviteOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	invitation, err := ctrl.organizationService.Invite(id, userID, req.Email, req.Role)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to send invitation")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Invitation sent successfully", newOrganizationInvitationResponse(invitation))
+}
+
+// ListInvitations returns an organization's pending invitations; admins and owners only
+// @Summary List organization invitations
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Success 200 {array} OrganizationInvitationResponse
+// @Router /api/organizations/{id}/invitations [get]
+func (ctrl *OrganizationController) ListInvitations(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+
+	invitations, err := ctrl.organizationService.ListInvitations(id, userID)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve invitations")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitations retrieved successfully", newOrganizationInvitationResponses(invitations))
+}
+
+// RevokeInvitation withdraws a pending invitation; admins and owners only
+// @Summary Revoke organization invitation
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param invitationId path int true "Invitation ID"
+// @Success 200
+// @Router /api/organizations/{id}/invitations/{invitationId} [delete]
+func (ctrl *OrganizationController) RevokeInvitation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	invitationID, ok := parseIDParam(c, "invitationId", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.organizationService.RevokeInvitation(id, userID, invitationID); err != nil {
+		ctrl.handleError(c, err, "Failed to revoke invitation")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitation revoked successfully", nil)
+}
+
+// ListMyInvitations returns the pending invitations sent to the user's verified email
+// @Summary List my organization invitations
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} OrganizationInvitationResponse
+// @Router /api/organizations/invitations [get]
+func (ctrl *OrganizationController) ListMyInvitations(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	invitations, err := ctrl.organizationService.ListInvitationsForUser(userID)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to retrieve invitations")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitations retrieved successfully", newOrganizationInvitationResponses(invitations))
+}
+
+// AcceptInvitation joins the organization an invitation is for
+// @Summary Accept organization invitation
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 {object} OrganizationResponse
+// @Router /api/organizations/invitations/{invitationId}/accept [post]
+func (ctrl *OrganizationController) AcceptInvitation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	invitationID, ok := parseIDParam(c, "invitationId", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	member, err := ctrl.organizationService.AcceptInvitation(invitationID, userID)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitation accepted successfully",
+		newOrganizationResponse(&member.Organization, member.Role))
+}
+
+// DeclineInvitation discards an invitation sent to the user
+// @Summary Decline organization invitation
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param invitationId path int true "Invitation ID"
+// @Success 200
+// @Router /api/organizations/invitations/{invitationId} [delete]
+func (ctrl *OrganizationController) DeclineInvitation(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	invitationID, ok := parseIDParam(c, "invitationId", "Invalid invitation ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.organizationService.DeclineInvitation(invitationID, userID); err != nil {
+		ctrl.handleError(c, err, "Failed to decline invitation")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invitation declined successfully", nil)
+}
+
+// UpdateMember changes a member's role; admins and owners only
+// @Summary Change organization member role
+// @Tags organizations
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param userId path int true "User ID"
+// @Param request body UpdateOrganizationMemberRequest true "New role"
+// @Success 200 {object} OrganizationMemberResponse
+// @Router /api/organizations/{id}/members/{userId} [put]
+func (ctrl *OrganizationController) UpdateMember(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userId", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateOrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	member, err := ctrl.organizationService.UpdateMemberRole(id, userID, memberID, req.Role)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to update member")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Member updated successfully", newOrganizationMemberResponse(member))
+}
+
+// RemoveMember removes a member from an organization, or lets a member leave
+// @Summary Remove organization member
+// @Tags organizations
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Organization ID"
+// @Param userId path int true "User ID"
+// @Success 200
+// @Router /api/organizations/{id}/members/{userId} [delete]
+func (ctrl *OrganizationController) RemoveMember(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, ok := parseIDParam(c, "id", "Invalid organization ID")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userId", "Invalid user ID")
+	if !ok {
+		return
+	}
+
+	if err := ctrl.organizationService.RemoveMember(id, userID, memberID); err != nil {
+		ctrl.handleError(c, err, "Failed to remove member")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Member removed successfully", nil)
+}
+
+// handleError maps organization service errors to responses
+func (ctrl *OrganizationController) handleError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrOrganizationNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "Organization not found")
+	case services.ErrOrganizationMemberNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "Member not found")
+	case services.ErrInvitationNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "Invitation not found")
+	case services.ErrUserNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+	case services.ErrEmailNotVerified:
+		utils.ErrorResponse(c, http.StatusForbidden, "Verify your email address to join or invite to an organization")
+	case services.ErrTooManyInvitations:
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many invitations, please try again later")
+	case services.ErrInvalidEmail:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid email format")
+	case services.ErrInvalidOrganizationName:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Organization name is required")
+	case services.ErrInvalidOrganizationRole:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Role must be owner, admin, member or viewer")
+	case services.ErrInsufficientOrganizationRole:
+		utils.ErrorResponse(c, http.StatusForbidden, "Your organization role does not allow this")
+	case services.ErrAlreadyOrganizationMember:
+		utils.ErrorResponse(c, http.StatusConflict, "User is already a member")
+	case services.ErrLastOrganizationOwner:
+		utils.ErrorResponse(c, http.StatusConflict, "An organization must keep at least one owner")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,104 @@
+package controllers
+
+import (
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PreferenceController struct {
+	preferenceService services.PreferenceService
+}
+
+func NewPreferenceController(preferenceService services.PreferenceService) *PreferenceController {
+	return &PreferenceController{
+		preferenceService: preferenceService,
+	}
+}
+
+// UpdatePreferencesRequest is a partial update; omitted fields keep their current value
+type UpdatePreferencesRequest struct {
+	DefaultDaysBefore *int     `json:"default_days_before" binding:"omitempty,min=0,max=365"`
+	Channels          []string `json:"channels"`
+	WebhookURL        *string  `json:"webhook_url"`
+	SlackWebhookURL   *string  `json:"slack_webhook_url"`
+	DiscordWebhookURL *string  `json:"discord_webhook_url"`
+	Timezone          *string  `json:"timezone"`
+	Locale            *string  `json:"locale"`
+	NotificationMode  *string  `json:"notification_mode" binding:"omitempty,oneof=daily digest"`
+	DigestFrequency   *string  `json:"digest_frequency" binding:"omitempty,oneof=daily weekly"`
+}
+
+// GetPreferences retrieves the notification preferences of the authenticated user
+// @Summary Get notification preferences
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} models.UserPreference
+// @Router /api/me/preferences [get]
+func (ctrl *PreferenceController) GetPreferences(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	preference, err := ctrl.preferenceService.Get(userID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve preferences")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Preferences retrieved successfully", preference)
+}
+
+// UpdatePreferences updates the notification preferences of the authenticated user
+// @Summary Update notification preferences
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body UpdatePreferencesRequest true "Preferences to change"
+// @Success 200 {object} models.UserPreference
+// @Router /api/me/preferences [put]
+func (ctrl *PreferenceController) UpdatePreferences(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	preference, err := ctrl.preferenceService.Update(userID, services.PreferenceInput{
+		DefaultDaysBefore: req.DefaultDaysBefore,
+		Channels:          req.Channels,
+		WebhookURL:        req.WebhookURL,
+		SlackWebhookURL:   req.SlackWebhookURL,
+		DiscordWebhookURL: req.DiscordWebhookURL,
+		Timezone:          req.Timezone,
+		Locale:            req.Locale,
+		NotificationMode:  req.NotificationMode,
+		DigestFrequency:   req.DigestFrequency,
+	})
+	if err != nil {
+		switch err {
+		case services.ErrInvalidLeadTime, services.ErrInvalidChannel, services.ErrInvalidTimezone,
+			services.ErrInvalidLocale, services.ErrInvalidNotificationMode, services.ErrInvalidDigestFrequency,
+			services.ErrInvalidWebhookURL, services.ErrChannelNotConfigured:
+			utils.ErrorResponse(c, http.StatusBadRequest, err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update preferences")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Preferences updated successfully", preference)
+}
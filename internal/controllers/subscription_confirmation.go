@@ -2,19 +2,40 @@ package controllers
 
 import (
 	"log"
+	"renew-guard/internal/models"
 	"renew-guard/pkg/email"
-	"time"
 )
 
-// sendSubscriptionConfirmation sends a confirmation email when a subscription is created
-func (ctrl *SubscriptionController) sendSubscriptionConfirmation(userEmail, subscriptionName string, startDate, endDate time.Time) {
-	subject := email.GetSubscriptionConfirmationSubject(subscriptionName)
-	htmlBody := email.GetSubscriptionConfirmationTemplate(subscriptionName, startDate, endDate)
-	
-	err := ctrl.emailService.SendHTML(userEmail, subject, htmlBody)
+// sendSubscriptionConfirmation sends a confirmation email when a subscription
+// is created, with a calendar invite for its end date. Like reminders, it is
+// only sent to a verified address, so an account registered with someone
+// else's address cannot mail them.
+func (ctrl *SubscriptionController) sendSubscriptionConfirmation(userEmail string, subscription *models.Subscription) {
+	// Reload with the owner, whose verification state the token does not carry
+	loaded, err := ctrl.subscriptionService.GetByID(subscription.ID, subscription.UserID)
+	if err != nil {
+		log.Printf("Failed to load subscription %d for its confirmation email: %v", subscription.ID, err)
+		return
+	}
+	subscription = loaded
+	if !subscription.User.HasVerifiedEmail(userEmail) {
+		log.Printf("Skipping confirmation email for subscription %d: address is not verified", subscription.ID)
+		return
+	}
+
+	loc := ctrl.subscriptionService.Location(subscription)
+	subject := email.GetSubscriptionConfirmationSubject(subscription.Name)
+	htmlBody := email.GetSubscriptionConfirmationTemplate(subscription.Name, subscription.StartDate.In(loc), subscription.EndDate.In(loc))
+
+	if invite, inviteErr := ctrl.calendarService.Invite(subscription, nil); inviteErr != nil {
+		log.Printf("Failed to build calendar invite for subscription %d: %v", subscription.ID, inviteErr)
+		err = ctrl.emailService.SendHTML(userEmail, subject, htmlBody)
+	} else {
+		err = ctrl.emailService.SendHTMLWithAttachments(userEmail, subject, htmlBody, []email.Attachment{*invite})
+	}
 	if err != nil {
 		log.Printf("Failed to send subscription confirmation email to %s: %v", userEmail, err)
 	} else {
-		log.Printf("Subscription confirmation email sent to %s for %s", userEmail, subscriptionName)
+		log.Printf("Subscription confirmation email sent to %s for %s", userEmail, subscription.Name)
 	}
 }
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/email"
+)
+
+type storedSubscriptionService struct {
+	services.SubscriptionService
+	subscription *models.Subscription
+}
+
+func (s *storedSubscriptionService) GetByID(id, userID uint) (*models.Subscription, error) {
+	return s.subscription, nil
+}
+
+func (s *storedSubscriptionService) Location(subscription *models.Subscription) *time.Location {
+	return subscription.Location()
+}
+
+type inviteCalendarService struct {
+	services.CalendarService
+}
+
+func (inviteCalendarService) Invite(subscription *models.Subscription, preference *models.UserPreference) (*email.Attachment, error) {
+	return &email.Attachment{Filename: "renewal.ics"}, nil
+}
+
+// recordingEmailService remembers who was mailed
+type recordingEmailService struct {
+	recipients []string
+}
+
+func (r *recordingEmailService) Send(to, subject, body string) error {
+	r.recipients = append(r.recipients, to)
+	return nil
+}
+
+func (r *recordingEmailService) SendHTML(to, subject, htmlBody string) error {
+	r.recipients = append(r.recipients, to)
+	return nil
+}
+
+func (r *recordingEmailService) SendHTMLWithAttachments(to, subject, htmlBody string, attachments []email.Attachment) error {
+	r.recipients = append(r.recipients, to)
+	return nil
+}
+
+func TestSubscriptionConfirmationRequiresVerifiedEmail(t *testing.T) {
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		verifiedAt *time.Time
+		sent       bool
+	}{
+		{"unverified", nil, false},
+		{"verified", &verifiedAt, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := &models.Subscription{
+				ID:        3,
+				UserID:    1,
+				Name:      "Netflix",
+				StartDate: verifiedAt,
+				EndDate:   verifiedAt.AddDate(0, 1, 0),
+				User:      models.User{ID: 1, Email: "owner@example.com", EmailVerifiedAt: tt.verifiedAt},
+			}
+			mailer := &recordingEmailService{}
+			ctrl := NewSubscriptionController(
+				&storedSubscriptionService{subscription: subscription}, nil, inviteCalendarService{}, mailer,
+			)
+
+			ctrl.sendSubscriptionConfirmation("owner@example.com", subscription)
+
+			if sent := len(mailer.recipients) > 0; sent != tt.sent {
+				t.Errorf("confirmation sent = %v, want %v", sent, tt.sent)
+			}
+		})
+	}
+}
@@ -1,12 +1,19 @@
 package controllers
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
 	"renew-guard/internal/services"
 	"renew-guard/pkg/email"
 	"renew-guard/pkg/utils"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,27 +21,105 @@ import (
 
 type SubscriptionController struct {
 	subscriptionService services.SubscriptionService
+	notificationService services.NotificationService
+	calendarService     services.CalendarService
 	emailService        email.EmailService
 }
 
-func NewSubscriptionController(subscriptionService services.SubscriptionService, emailService email.EmailService) *SubscriptionController {
+func NewSubscriptionController(
+	subscriptionService services.SubscriptionService,
+	notificationService services.NotificationService,
+	calendarService services.CalendarService,
+	emailService email.EmailService,
+) *SubscriptionController {
 	return &SubscriptionController{
 		subscriptionService: subscriptionService,
+		notificationService: notificationService,
+		calendarService:     calendarService,
 		emailService:        emailService,
 	}
 }
 
+// CreateSubscriptionRequest accepts either duration_days (fixed length) or
+// billing_cycle; billing_interval and billing_unit are required for "custom"
 type CreateSubscriptionRequest struct {
-	Name         string    `json:"name" binding:"required"`
-	StartDate    time.Time `json:"start_date" binding:"required"`
-	DurationDays int       `json:"duration_days" binding:"required,min=1"`
+	Name            string    `json:"name" binding:"required"`
+	StartDate       time.Time `json:"start_date" binding:"required"`
+	DurationDays    int       `json:"duration_days" binding:"omitempty,min=1"`
+	BillingCycle    string    `json:"billing_cycle" binding:"omitempty,oneof=weekly monthly quarterly yearly custom"`
+	BillingInterval int       `json:"billing_interval" binding:"omitempty,min=1"`
+	BillingUnit     string    `json:"billing_unit" binding:"omitempty,oneof=day week month year"`
+	AutoRenew       bool      `json:"auto_renew"`
+	PriceMinor      int64     `json:"price_minor" binding:"omitempty,min=0"`
+	Currency        string    `json:"currency" binding:"omitempty,len=3"`
+	ReminderDays    []int     `json:"reminder_days" binding:"omitempty,dive,min=0,max=365"`
+	Timezone        string    `json:"timezone"`
+	Channels        []string  `json:"channels"`        // Omit to use the owner's preferred channels
+	OrganizationID  *uint     `json:"organization_id"` // Share with an organization you are a member of
+	NotifyRoles     []string  `json:"notify_roles"`    // Organization roles to remind; defaults to owners and admins
+	Tags            []string  `json:"tags"`
 }
 
 type UpdateSubscriptionRequest struct {
 	Name                string    `json:"name" binding:"required"`
 	StartDate           time.Time `json:"start_date" binding:"required"`
-	DurationDays        int       `json:"duration_days" binding:"required,min=1"`
+	DurationDays        int       `json:"duration_days" binding:"omitempty,min=1"`
+	BillingCycle        string    `json:"billing_cycle" binding:"omitempty,oneof=weekly monthly quarterly yearly custom"`
+	BillingInterval     int       `json:"billing_interval" binding:"omitempty,min=1"`
+	BillingUnit         string    `json:"billing_unit" binding:"omitempty,oneof=day week month year"`
 	NotificationEnabled bool      `json:"notification_enabled"`
+	AutoRenew           bool      `json:"auto_renew"`
+	PriceMinor          int64     `json:"price_minor" binding:"omitempty,min=0"`
+	Currency            string    `json:"currency" binding:"omitempty,len=3"`
+	ReminderDays        []int     `json:"reminder_days" binding:"omitempty,dive,min=0,max=365"` // Omit to keep existing reminders
+	Timezone            string    `json:"timezone"`
+	Channels            []string  `json:"channels"`     // Omit to keep, [] to fall back to the owner's channels
+	NotifyRoles         []string  `json:"notify_roles"` // Omit to keep, [] to restore the default roles
+	Tags                []string  `json:"tags"`         // Omit to keep, [] to clear
+}
+
+// ListSubscriptionsQuery holds the paging, filter and sort parameters of a
+// subscription listing. Dates are RFC 3339 and prices in minor units.
+type ListSubscriptionsQuery struct {
+	Page           int        `form:"page" binding:"omitempty,min=1"`
+	PerPage        int        `form:"per_page" binding:"omitempty,min=1,max=100"`
+	OrganizationID *uint      `form:"organization_id"`
+	ExpiresAfter   *time.Time `form:"expires_after"`
+	ExpiresBefore  *time.Time `form:"expires_before"`
+	Status         string     `form:"status" binding:"omitempty,oneof=active expired"`
+	Notifications  *bool      `form:"notifications"`
+	Search         string     `form:"q"`
+	Tag            string     `form:"tag"`
+	MinPrice       *int64     `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice       *int64     `form:"max_price" binding:"omitempty,min=0"`
+	Currency       string     `form:"currency" binding:"omitempty,len=3"`
+	Sort           string     `form:"sort" binding:"omitempty,oneof=name end_date start_date price created_at"`
+	Order          string     `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// ImportSubscriptionsQuery holds the options of a subscription import. The
+// format defaults to the upload's content type or file extension.
+type ImportSubscriptionsQuery struct {
+	Format         string `form:"format" binding:"omitempty,oneof=csv json"`
+	Mapping        string `form:"mapping"` // Source:field pairs, e.g. "Service:name,Renews:start_date"
+	OrganizationID *uint  `form:"organization_id"`
+	DryRun         bool   `form:"dry_run"`
+	Atomic         bool   `form:"atomic"`
+	Duplicates     string `form:"duplicates" binding:"omitempty,oneof=skip allow"`
+}
+
+// ExportSubscriptionsQuery holds the options of a subscription export
+type ExportSubscriptionsQuery struct {
+	Format         string `form:"format" binding:"omitempty,oneof=csv json ndjson"`
+	OrganizationID *uint  `form:"organization_id"`
+	IncludeLogs    bool   `form:"include_logs"`
+}
+
+// maxImportBytes bounds the size of an uploaded import
+const maxImportBytes = 5 << 20
+
+type SetRemindersRequest struct {
+	DaysBefore []int `json:"days_before" binding:"dive,min=0,max=365"`
 }
 
 type ToggleNotificationRequest struct {
@@ -71,10 +156,46 @@ func (ctrl *SubscriptionController) CreateSubscription(c *gin.Context) {
 	}
 
 	// Create subscription with user's email
-	subscription, err := ctrl.subscriptionService.Create(userID, userEmail, req.Name, req.StartDate, req.DurationDays)
+	subscription, err := ctrl.subscriptionService.Create(userID, userEmail, services.SubscriptionInput{
+		Name:            req.Name,
+		StartDate:       req.StartDate,
+		DurationDays:    req.DurationDays,
+		BillingCycle:    req.BillingCycle,
+		BillingInterval: req.BillingInterval,
+		BillingUnit:     req.BillingUnit,
+		AutoRenew:       req.AutoRenew,
+		PriceMinor:      req.PriceMinor,
+		Currency:        req.Currency,
+		ReminderDays:    req.ReminderDays,
+		Timezone:        req.Timezone,
+		Channels:        req.Channels,
+		OrganizationID:  req.OrganizationID,
+		NotifyRoles:     req.NotifyRoles,
+		Tags:            req.Tags,
+	})
 	if err != nil {
-		if err == services.ErrInvalidSubscriptionData {
+		if err == services.ErrOrganizationNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Organization not found")
+		} else if err == services.ErrInsufficientOrganizationRole {
+			utils.ErrorResponse(c, http.StatusForbidden, "Viewers cannot add subscriptions to an organization")
+		} else if err == services.ErrInvalidNotifyRoles {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Notify roles must be organization roles on a shared subscription")
+		} else if err == services.ErrInvalidTags {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Tags must be 1-32 characters, at most 20 per subscription")
+		} else if err == services.ErrInvalidSubscriptionData {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subscription data")
+		} else if err == services.ErrInvalidBillingCycle {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid billing cycle")
+		} else if err == services.ErrInvalidPrice {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid price or currency")
+		} else if err == services.ErrInvalidReminders {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Reminder offsets must be between 0 and 365 days")
+		} else if err == services.ErrInvalidSubscriptionZone {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid timezone")
+		} else if err == services.ErrInvalidChannel {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Unsupported notification channel")
+		} else if err == services.ErrChannelNotConfigured {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Set a webhook URL in your preferences before selecting that channel")
 		} else {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create subscription")
 		}
@@ -82,16 +203,220 @@ func (ctrl *SubscriptionController) CreateSubscription(c *gin.Context) {
 	}
 
 	// Send confirmation email asynchronously
-	go ctrl.sendSubscriptionConfirmation(userEmail, subscription.Name, subscription.StartDate, subscription.EndDate)
+	go ctrl.sendSubscriptionConfirmation(userEmail, subscription)
 
 	utils.SuccessResponse(c, http.StatusCreated, "Subscription created successfully", subscription)
 }
 
-// GetSubscriptions retrieves all subscriptions for the authenticated user
+// ImportSubscriptions creates subscriptions in bulk from a CSV or JSON
+// upload, sent as the request body or as the "file" field of a form, and
+// reports the outcome of every row
+// @Summary Import subscriptions from CSV or JSON
+// @Tags subscriptions
+// @Accept text/csv,json,mpfd
+// @Produce json
+// @Security BearerAuth
+// @Param format query string false "csv or json"
+// @Param mapping query string false "Column mapping as source:field pairs"
+// @Param organization_id query int false "Share the imported subscriptions with this organization"
+// @Param dry_run query bool false "Validate without creating anything"
+// @Param atomic query bool false "Create nothing unless every row is valid"
+// @Param duplicates query string false "skip (default) or allow rows named like an existing subscription"
+// @Success 200 {object} services.ImportReport
+// @Router /api/subscriptions/import [post]
+func (ctrl *SubscriptionController) ImportSubscriptions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userEmail, exists := middleware.GetUserEmail(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "User email not found")
+		return
+	}
+
+	var query ImportSubscriptionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+
+	mapping, err := services.ParseImportMapping(query.Mapping)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Column mapping must be source:field pairs naming import fields")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	var body io.Reader = c.Request.Body
+	format := query.Format
+	if c.ContentType() == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Upload the import as the file field")
+			return
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Import file could not be read")
+			return
+		}
+		defer file.Close()
+
+		body = file
+		if format == "" {
+			format = importFormat("", fileHeader.Filename)
+		}
+	} else if format == "" {
+		format = importFormat(c.ContentType(), "")
+	}
+
+	rows, err := services.ParseSubscriptionImport(body, format, mapping)
+	if err != nil {
+		switch err {
+		case services.ErrUnsupportedImportFormat:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Import format must be csv or json")
+		case services.ErrImportMissingName:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Import has no name column")
+		case services.ErrTooManyImportRows:
+			utils.ErrorResponse(c, http.StatusBadRequest, "An import may hold at most 1000 subscriptions")
+		default:
+			utils.ErrorResponse(c, http.StatusBadRequest, "Import file could not be read")
+		}
+		return
+	}
+
+	report, err := ctrl.subscriptionService.Import(userID, userEmail, rows, services.ImportOptions{
+		OrganizationID:  query.OrganizationID,
+		DryRun:          query.DryRun,
+		Atomic:          query.Atomic,
+		AllowDuplicates: query.Duplicates == "allow",
+	})
+	if err != nil {
+		switch err {
+		case services.ErrImportRejected:
+			// Nothing was created; the report shows which rows to fix
+			utils.ValidationErrorResponse(c, report)
+		case services.ErrOrganizationNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "Organization not found")
+		case services.ErrInsufficientOrganizationRole:
+			utils.ErrorResponse(c, http.StatusForbidden, "Viewers cannot add subscriptions to an organization")
+		case services.ErrTooManyImportRows:
+			utils.ErrorResponse(c, http.StatusBadRequest, "An import may hold at most 1000 subscriptions")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to import subscriptions")
+		}
+		return
+	}
+
+	if query.DryRun {
+		utils.SuccessResponse(c, http.StatusOK, "Import validated, nothing was created", report)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "Import completed", report)
+}
+
+// ExportSubscriptions streams every subscription the user can see as a file
+// download, optionally with each subscription's notification history
+// @Summary Export subscriptions as CSV, JSON or NDJSON
+// @Tags subscriptions
+// @Produce text/csv,json,application/x-ndjson
+// @Security BearerAuth
+// @Param format query string false "csv (default), json or ndjson"
+// @Param organization_id query int false "Only subscriptions of this organization"
+// @Param include_logs query bool false "Include notification history (json and ndjson only)"
+// @Success 200 {array} services.SubscriptionExport
+// @Router /api/subscriptions/export [get]
+func (ctrl *SubscriptionController) ExportSubscriptions(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var query ExportSubscriptionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+	if query.Format == "" {
+		query.Format = services.ExportFormatCSV
+	}
+	if query.IncludeLogs && query.Format == services.ExportFormatCSV {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Notification history can only be exported as json or ndjson")
+		return
+	}
+
+	buffer := bufio.NewWriter(c.Writer)
+	writer := newExportWriter(query.Format, buffer)
+
+	// The response starts with the first record, so failures before it,
+	// such as a foreign organization, still get a JSON error
+	started := false
+	begin := func() error {
+		if started {
+			return nil
+		}
+		started = true
+		filename := ctrl.subscriptionService.ExportFilename(query.Format)
+		c.Header("Content-Type", exportContentTypes[query.Format])
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+		return writer.Begin()
+	}
+
+	err := ctrl.subscriptionService.Export(userID, query.OrganizationID, query.IncludeLogs, func(record services.SubscriptionExport) error {
+		if err := begin(); err != nil {
+			return err
+		}
+		return writer.Write(record)
+	})
+	if err == nil {
+		err = begin()
+	}
+	if err == nil {
+		err = writer.End()
+	}
+	if err == nil {
+		err = buffer.Flush()
+	}
+
+	if err != nil {
+		if started {
+			// Too late for an error response; the client sees a truncated file
+			log.Printf("Subscription export for user %d failed: %v", userID, err)
+			c.Abort()
+		} else if err == services.ErrOrganizationNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Organization not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export subscriptions")
+		}
+	}
+}
+
+// GetSubscriptions retrieves one page of the subscriptions the authenticated
+// user can see, including those shared with their organizations
 // @Summary Get all user subscriptions
 // @Tags subscriptions
 // @Produce json
 // @Security BearerAuth
+// @Param page query int false "Page number, from 1"
+// @Param per_page query int false "Page size, up to 100 (default 20)"
+// @Param organization_id query int false "Only subscriptions of this organization"
+// @Param expires_after query string false "Expiring on or after this time (RFC 3339)"
+// @Param expires_before query string false "Expiring before this time (RFC 3339)"
+// @Param status query string false "active or expired"
+// @Param notifications query bool false "Notifications on or off"
+// @Param q query string false "Name contains"
+// @Param tag query string false "Has this tag"
+// @Param min_price query int false "Minimum price in minor units"
+// @Param max_price query int false "Maximum price in minor units"
+// @Param currency query string false "ISO 4217 currency code"
+// @Param sort query string false "name, end_date, start_date, price or created_at"
+// @Param order query string false "asc or desc"
 // @Success 200 {array} models.Subscription
 // @Router /api/subscriptions [get]
 func (ctrl *SubscriptionController) GetSubscriptions(c *gin.Context) {
@@ -101,13 +426,86 @@ func (ctrl *SubscriptionController) GetSubscriptions(c *gin.Context) {
 		return
 	}
 
-	subscriptions, err := ctrl.subscriptionService.GetAllByUserID(userID)
+	var query ListSubscriptionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid query parameters")
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PerPage == 0 {
+		query.PerPage = services.DefaultPageSize
+	}
+
+	filter := repositories.SubscriptionFilter{
+		OrganizationID:      query.OrganizationID,
+		ExpiresAfter:        query.ExpiresAfter,
+		ExpiresBefore:       query.ExpiresBefore,
+		NotificationEnabled: query.Notifications,
+		Search:              query.Search,
+		Tag:                 query.Tag,
+		MinPriceMinor:       query.MinPrice,
+		MaxPriceMinor:       query.MaxPrice,
+		Currency:            query.Currency,
+		Sort:                query.Sort,
+		Descending:          query.Order == "desc",
+		Limit:               query.PerPage,
+		Offset:              (query.Page - 1) * query.PerPage,
+	}
+	if query.Status != "" {
+		expired := query.Status == "expired"
+		filter.Expired = &expired
+	}
+
+	subscriptions, total, err := ctrl.subscriptionService.GetAllByUserID(userID, filter)
+	if err != nil {
+		if err == services.ErrOrganizationNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Organization not found")
+		} else if err == services.ErrInvalidFilter {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid filter range")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve subscriptions")
+		}
+		return
+	}
+
+	utils.PaginatedResponse(c, http.StatusOK, "Subscriptions retrieved successfully", subscriptions,
+		utils.NewPagination(query.Page, query.PerPage, total))
+}
+
+// GetSpendSummary returns the user's recurring spend per currency, or an
+// organization's when organization_id is given
+// @Summary Get monthly and yearly spend summary
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param organization_id query int false "Summarise this organization's subscriptions"
+// @Success 200 {array} services.CurrencySpend
+// @Router /api/subscriptions/summary [get]
+func (ctrl *SubscriptionController) GetSpendSummary(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	organizationID, ok := organizationIDQuery(c)
+	if !ok {
+		return
+	}
+
+	summary, err := ctrl.subscriptionService.GetSpendSummary(userID, organizationID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve subscriptions")
+		if err == services.ErrOrganizationNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Organization not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to compute spend summary")
+		}
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Subscriptions retrieved successfully", subscriptions)
+	utils.SuccessResponse(c, http.StatusOK, "Spend summary retrieved successfully", summary)
 }
 
 // GetSubscription retrieves a specific subscription by ID
@@ -177,9 +575,23 @@ func (ctrl *SubscriptionController) UpdateSubscription(c *gin.Context) {
 		return
 	}
 
-	subscription, err := ctrl.subscriptionService.Update(
-		uint(id), userID, req.Name, req.StartDate, req.DurationDays, req.NotificationEnabled,
-	)
+	subscription, err := ctrl.subscriptionService.Update(uint(id), userID, services.SubscriptionInput{
+		Name:                req.Name,
+		StartDate:           req.StartDate,
+		DurationDays:        req.DurationDays,
+		BillingCycle:        req.BillingCycle,
+		BillingInterval:     req.BillingInterval,
+		BillingUnit:         req.BillingUnit,
+		NotificationEnabled: req.NotificationEnabled,
+		AutoRenew:           req.AutoRenew,
+		PriceMinor:          req.PriceMinor,
+		Currency:            req.Currency,
+		ReminderDays:        req.ReminderDays,
+		Timezone:            req.Timezone,
+		Channels:            req.Channels,
+		NotifyRoles:         req.NotifyRoles,
+		Tags:                req.Tags,
+	})
 	if err != nil {
 		if err == services.ErrSubscriptionNotFound {
 			utils.ErrorResponse(c, http.StatusNotFound, "Subscription not found")
@@ -187,6 +599,22 @@ func (ctrl *SubscriptionController) UpdateSubscription(c *gin.Context) {
 			utils.ErrorResponse(c, http.StatusForbidden, "Unauthorized access")
 		} else if err == services.ErrInvalidSubscriptionData {
 			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subscription data")
+		} else if err == services.ErrInvalidBillingCycle {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid billing cycle")
+		} else if err == services.ErrInvalidPrice {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid price or currency")
+		} else if err == services.ErrInvalidReminders {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Reminder offsets must be between 0 and 365 days")
+		} else if err == services.ErrInvalidSubscriptionZone {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid timezone")
+		} else if err == services.ErrInvalidChannel {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Unsupported notification channel")
+		} else if err == services.ErrChannelNotConfigured {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Set a webhook URL in your preferences before selecting that channel")
+		} else if err == services.ErrInvalidNotifyRoles {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Notify roles must be organization roles on a shared subscription")
+		} else if err == services.ErrInvalidTags {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Tags must be 1-32 characters, at most 20 per subscription")
 		} else {
 			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update subscription")
 		}
@@ -277,3 +705,211 @@ func (ctrl *SubscriptionController) ToggleNotification(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Notification settings updated successfully", subscription)
 }
+
+// GetRenewals retrieves the automatic renewal history of a subscription
+// @Summary Get subscription renewal history
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} models.SubscriptionRenewal
+// @Router /api/subscriptions/{id}/renewals [get]
+func (ctrl *SubscriptionController) GetRenewals(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subscription ID")
+		return
+	}
+
+	renewals, err := ctrl.subscriptionService.GetRenewals(uint(id), userID)
+	if err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Subscription not found")
+		} else if err == services.ErrUnauthorizedAccess {
+			utils.ErrorResponse(c, http.StatusForbidden, "Unauthorized access")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve renewal history")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Renewal history retrieved successfully", renewals)
+}
+
+// GetReminders retrieves the reminder offsets of a subscription
+// @Summary Get subscription reminders
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} models.SubscriptionReminder
+// @Router /api/subscriptions/{id}/reminders [get]
+func (ctrl *SubscriptionController) GetReminders(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subscription ID")
+		return
+	}
+
+	reminders, err := ctrl.subscriptionService.GetReminders(uint(id), userID)
+	if err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Subscription not found")
+		} else if err == services.ErrUnauthorizedAccess {
+			utils.ErrorResponse(c, http.StatusForbidden, "Unauthorized access")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve reminders")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reminders retrieved successfully", reminders)
+}
+
+// SetReminders replaces the reminder offsets of a subscription
+// @Summary Set subscription reminders
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param request body SetRemindersRequest true "Reminder offsets in days"
+// @Success 200 {array} models.SubscriptionReminder
+// @Router /api/subscriptions/{id}/reminders [put]
+func (ctrl *SubscriptionController) SetReminders(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subscription ID")
+		return
+	}
+
+	var req SetRemindersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	reminders, err := ctrl.subscriptionService.SetReminders(uint(id), userID, req.DaysBefore)
+	if err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Subscription not found")
+		} else if err == services.ErrUnauthorizedAccess {
+			utils.ErrorResponse(c, http.StatusForbidden, "Unauthorized access")
+		} else if err == services.ErrInvalidReminders {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Reminder offsets must be between 0 and 365 days")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update reminders")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reminders updated successfully", reminders)
+}
+
+// GetNotificationLogs retrieves the delivery history of a subscription's notifications
+// @Summary Get subscription notification logs
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Subscription ID"
+// @Param status query string false "Filter by status (success, failed, dead_letter)"
+// @Success 200 {array} models.NotificationLog
+// @Router /api/subscriptions/{id}/logs [get]
+func (ctrl *SubscriptionController) GetNotificationLogs(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	idStr := c.Param("id")
+	id, err := strconv.ParseUint(idStr, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid subscription ID")
+		return
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", models.NotificationStatusSuccess, models.NotificationStatusFailed, models.NotificationStatusDeadLetter,
+		models.NotificationStatusSkipped:
+	default:
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid status filter")
+		return
+	}
+
+	// Verify ownership
+	if _, err := ctrl.subscriptionService.GetByID(uint(id), userID); err != nil {
+		if err == services.ErrSubscriptionNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "Subscription not found")
+		} else if err == services.ErrUnauthorizedAccess {
+			utils.ErrorResponse(c, http.StatusForbidden, "Unauthorized access")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notification logs")
+		}
+		return
+	}
+
+	logs, err := ctrl.notificationService.GetLogs(uint(id), status)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notification logs")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification logs retrieved successfully", logs)
+}
+
+// importFormat guesses the format of an import from its content type or
+// file name, returning "" when neither says
+func importFormat(contentType, filename string) string {
+	switch {
+	case contentType == "text/csv" || contentType == "application/csv":
+		return services.ImportFormatCSV
+	case contentType == "application/json":
+		return services.ImportFormatJSON
+	case strings.HasSuffix(strings.ToLower(filename), ".csv"):
+		return services.ImportFormatCSV
+	case strings.HasSuffix(strings.ToLower(filename), ".json"):
+		return services.ImportFormatJSON
+	}
+	return ""
+}
+
+// organizationIDQuery reads the optional organization_id filter, writing a
+// 400 response and returning false if it is malformed
+func organizationIDQuery(c *gin.Context) (*uint, bool) {
+	raw := c.Query("organization_id")
+	if raw == "" {
+		return nil, true
+	}
+
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid organization ID")
+		return nil, false
+	}
+
+	organizationID := uint(id)
+	return &organizationID, true
+}
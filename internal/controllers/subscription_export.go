@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"renew-guard/internal/services"
+)
+
+// exportWriter writes the records of a subscription export in one format
+type exportWriter interface {
+	Begin() error
+	Write(record services.SubscriptionExport) error
+	End() error
+}
+
+// exportContentTypes maps export formats to their media types
+var exportContentTypes = map[string]string{
+	services.ExportFormatCSV:    "text/csv; charset=utf-8",
+	services.ExportFormatJSON:   "application/json; charset=utf-8",
+	services.ExportFormatNDJSON: "application/x-ndjson",
+}
+
+func newExportWriter(format string, w io.Writer) exportWriter {
+	switch format {
+	case services.ExportFormatJSON:
+		return &jsonExportWriter{w: w}
+	case services.ExportFormatNDJSON:
+		return &ndjsonExportWriter{encoder: json.NewEncoder(w)}
+	default:
+		return &csvExportWriter{w: csv.NewWriter(w)}
+	}
+}
+
+// csvExportWriter writes a header line followed by one line per subscription
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+func (e *csvExportWriter) Begin() error {
+	return e.w.Write(services.SubscriptionExportColumns)
+}
+
+func (e *csvExportWriter) Write(record services.SubscriptionExport) error {
+	return e.w.Write(record.CSVRecord())
+}
+
+func (e *csvExportWriter) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonExportWriter writes a single JSON array, one element at a time
+type jsonExportWriter struct {
+	w       io.Writer
+	written int
+}
+
+func (e *jsonExportWriter) Begin() error {
+	_, err := io.WriteString(e.w, "[")
+	return err
+}
+
+func (e *jsonExportWriter) Write(record services.SubscriptionExport) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	separator := "\n"
+	if e.written > 0 {
+		separator = ",\n"
+	}
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	e.written++
+	return err
+}
+
+func (e *jsonExportWriter) End() error {
+	_, err := io.WriteString(e.w, "\n]\n")
+	return err
+}
+
+// ndjsonExportWriter writes one JSON object per line
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (e *ndjsonExportWriter) Begin() error {
+	return nil
+}
+
+func (e *ndjsonExportWriter) Write(record services.SubscriptionExport) error {
+	return e.encoder.Encode(record)
+}
+
+func (e *ndjsonExportWriter) End() error {
+	return nil
+}
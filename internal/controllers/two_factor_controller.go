@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"net/http"
+	"renew-guard/internal/middleware"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/utils"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{
+		twoFactorService: twoFactorService,
+	}
+}
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required"` // TOTP code, or a recovery code where accepted
+}
+
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"` // Render as a QR code for authenticator apps
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// GetStatus reports whether two-factor login is enabled for the authenticated user
+// @Summary Get two-factor status
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TwoFactorStatusResponse
+// @Router /api/me/2fa [get]
+func (ctrl *TwoFactorController) GetStatus(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	status, err := ctrl.twoFactorService.Status(userID)
+	if err != nil {
+		if err == services.ErrUserNotFound {
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		} else {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve two-factor status")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor status retrieved successfully", TwoFactorStatusResponse{
+		Enabled:                status.Enabled,
+		EnabledAt:              status.EnabledAt,
+		RecoveryCodesRemaining: status.RecoveryCodesRemaining,
+	})
+}
+
+// Setup starts TOTP enrollment and returns the secret to add to an authenticator app
+// @Summary Start two-factor enrollment
+// @Tags me
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} TwoFactorSetupResponse
+// @Router /api/me/2fa/setup [post]
+func (ctrl *TwoFactorController) Setup(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	enrollment, err := ctrl.twoFactorService.BeginEnrollment(userID)
+	if err != nil {
+		switch err {
+		case services.ErrTwoFactorAlreadyEnabled:
+			utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+		case services.ErrUserNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to start two-factor enrollment")
+		}
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Add this secret to your authenticator app, then confirm with a code",
+		TwoFactorSetupResponse{Secret: enrollment.Secret, OTPAuthURI: enrollment.URI})
+}
+
+// Confirm enables two-factor login after checking a code from the newly enrolled app
+// @Summary Confirm two-factor enrollment
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "Current TOTP code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Router /api/me/2fa/confirm [post]
+func (ctrl *TwoFactorController) Confirm(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := ctrl.twoFactorService.ConfirmEnrollment(userID, req.Code)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to enable two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication enabled, store these recovery codes safely",
+		RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable turns off two-factor login
+// @Summary Disable two-factor authentication
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body DisableTwoFactorRequest true "Current password and a TOTP or recovery code"
+// @Success 200
+// @Router /api/me/2fa [delete]
+func (ctrl *TwoFactorController) Disable(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := ctrl.twoFactorService.Disable(userID, req.Password, req.Code); err != nil {
+		ctrl.handleError(c, err, "Failed to disable two-factor authentication")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// RegenerateRecoveryCodes replaces the authenticated user's recovery codes
+// @Summary Regenerate recovery codes
+// @Tags me
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body TwoFactorCodeRequest true "Current TOTP or recovery code"
+// @Success 200 {object} RecoveryCodesResponse
+// @Router /api/me/2fa/recovery-codes [post]
+func (ctrl *TwoFactorController) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := ctrl.twoFactorService.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		ctrl.handleError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Recovery codes regenerated, previous codes no longer work",
+		RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// handleError maps two-factor service errors to responses
+func (ctrl *TwoFactorController) handleError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrInvalidTwoFactorCode:
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid two-factor code")
+	case services.ErrInvalidPassword:
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Current password is incorrect")
+	case services.ErrTooManyTwoFactorCodes:
+		utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many two-factor attempts, please try again later")
+	case services.ErrTwoFactorAlreadyEnabled:
+		utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is already enabled")
+	case services.ErrTwoFactorNotEnabled:
+		utils.ErrorResponse(c, http.StatusConflict, "Two-factor authentication is not enabled")
+	case services.ErrTwoFactorNotEnrolled:
+		utils.ErrorResponse(c, http.StatusConflict, "Start two-factor enrollment first")
+	case services.ErrUserNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, "User not found")
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, fallback)
+	}
+}
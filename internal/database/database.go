@@ -5,6 +5,7 @@ import (
 	"log"
 	"renew-guard/internal/config"
 	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -14,8 +15,9 @@ import (
 
 var DB *gorm.DB
 
-// Initialize establishes a connection to the PostgreSQL database
-func Initialize(cfg *config.Config) (*gorm.DB, error) {
+// Initialize establishes a connection to the PostgreSQL database. GORM
+// timestamps are taken from clk so simulated time reaches the database layer.
+func Initialize(cfg *config.Config, clk clock.Clock) (*gorm.DB, error) {
 	dsn := cfg.Database.GetDSN()
 
 	// Configure GORM logger
@@ -27,7 +29,7 @@ func Initialize(cfg *config.Config) (*gorm.DB, error) {
 	gormConfig := &gorm.Config{
 		Logger: logger.Default.LogMode(logLevel),
 		NowFunc: func() time.Time {
-			return time.Now().UTC()
+			return clk.Now().UTC()
 		},
 	}
 
@@ -72,6 +74,19 @@ func AutoMigrate(db *gorm.DB) error {
 		&models.User{},
 		&models.Subscription{},
 		&models.NotificationLog{},
+		&models.SubscriptionRenewal{},
+		&models.SubscriptionReminder{},
+		&models.UserPreference{},
+		&models.OutboxMessage{},
+		&models.RefreshToken{},
+		&models.UserToken{},
+		&models.APIKey{},
+		&models.RecoveryCode{},
+		&models.LoginFailure{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.OrganizationInvitation{},
+		&models.CalendarFeed{},
 	)
 
 	if err != nil {
@@ -13,10 +13,26 @@ const (
 	AuthorizationHeader = "Authorization"
 	UserIDKey           = "userID"
 	UserEmailKey        = "userEmail"
+	UserRoleKey         = "userRole"
+	SessionIDKey        = "sessionID"
+	APIKeyScopesKey     = "apiKeyScopes"
 )
 
-// AuthMiddleware validates JWT tokens and adds user information to context
-func AuthMiddleware(jwtUtil *jwt.JWTUtil) gin.HandlerFunc {
+// SessionValidator confirms that the session behind a valid access token has
+// not been logged out or otherwise revoked
+type SessionValidator interface {
+	ValidateSession(claims *jwt.Claims) error
+}
+
+// APIKeyValidator resolves a personal API key to its owner and granted scopes
+type APIKeyValidator interface {
+	ValidateAPIKey(rawKey string) (userID uint, email string, scopes []string, err error)
+}
+
+// AuthMiddleware authenticates either a Bearer JWT or an "ApiKey" personal
+// key and adds user information to context. Requests made with an API key
+// also carry the key's scopes; see RequireScopes and RequireSession.
+func AuthMiddleware(jwtUtil *jwt.JWTUtil, sessions SessionValidator, apiKeys APIKeyValidator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get authorization header
 		authHeader := c.GetHeader(AuthorizationHeader)
@@ -26,14 +42,30 @@ func AuthMiddleware(jwtUtil *jwt.JWTUtil) gin.HandlerFunc {
 			return
 		}
 
-		// Extract bearer token
+		// Extract bearer token or API key
 		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
+		if len(parts) != 2 || (parts[0] != "Bearer" && parts[0] != "ApiKey") {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid authorization header format")
 			c.Abort()
 			return
 		}
 
+		if parts[0] == "ApiKey" {
+			userID, email, scopes, err := apiKeys.ValidateAPIKey(parts[1])
+			if err != nil {
+				utils.ErrorResponse(c, http.StatusUnauthorized, "Invalid API key")
+				c.Abort()
+				return
+			}
+
+			c.Set(UserIDKey, userID)
+			c.Set(UserEmailKey, email)
+			c.Set(APIKeyScopesKey, scopes)
+
+			c.Next()
+			return
+		}
+
 		tokenString := parts[1]
 
 		// Validate token
@@ -48,14 +80,91 @@ func AuthMiddleware(jwtUtil *jwt.JWTUtil) gin.HandlerFunc {
 			return
 		}
 
+		// Reject tokens whose session was revoked
+		if err := sessions.ValidateSession(claims); err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Session has been revoked")
+			c.Abort()
+			return
+		}
+
 		// Add user information to context
 		c.Set(UserIDKey, claims.UserID)
 		c.Set(UserEmailKey, claims.Email)
+		c.Set(UserRoleKey, claims.Role)
+		c.Set(SessionIDKey, claims.SessionID)
+
+		c.Next()
+	}
+}
+
+// RequireScopes limits API key access to keys holding readScope for GET and
+// HEAD requests and writeScope for everything else. JWT sessions have full access.
+func RequireScopes(readScope, writeScope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, isAPIKey := GetAPIKeyScopes(c)
+		if !isAPIKey {
+			c.Next()
+			return
+		}
+
+		required := writeScope
+		if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+			required = readScope
+		}
+
+		for _, scope := range scopes {
+			if scope == required {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "API key lacks the "+required+" scope")
+		c.Abort()
+	}
+}
+
+// RequireRole only lets through sessions whose access token carries one of
+// the given roles. API keys carry no role and are always rejected.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := GetUserRole(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		utils.ErrorResponse(c, http.StatusForbidden, "Insufficient permissions")
+		c.Abort()
+	}
+}
+
+// RequireSession rejects API keys on endpoints reserved for interactive
+// sessions, such as account and key management
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := GetAPIKeyScopes(c); isAPIKey {
+			utils.ErrorResponse(c, http.StatusForbidden, "This endpoint cannot be used with an API key")
+			c.Abort()
+			return
+		}
 
 		c.Next()
 	}
 }
 
+// GetAPIKeyScopes retrieves the scopes of the API key used for the request;
+// ok is false for JWT-authenticated requests
+func GetAPIKeyScopes(c *gin.Context) ([]string, bool) {
+	scopes, exists := c.Get(APIKeyScopesKey)
+	if !exists {
+		return nil, false
+	}
+	return scopes.([]string), true
+}
+
 // GetUserID retrieves the user ID from the context
 func GetUserID(c *gin.Context) (uint, bool) {
 	userID, exists := c.Get(UserIDKey)
@@ -73,3 +182,21 @@ func GetUserEmail(c *gin.Context) (string, bool) {
 	}
 	return email.(string), true
 }
+
+// GetUserRole retrieves the user's role from the context
+func GetUserRole(c *gin.Context) (string, bool) {
+	role, exists := c.Get(UserRoleKey)
+	if !exists {
+		return "", false
+	}
+	return role.(string), true
+}
+
+// GetSessionID retrieves the session ID of the access token from the context
+func GetSessionID(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get(SessionIDKey)
+	if !exists {
+		return "", false
+	}
+	return sessionID.(string), true
+}
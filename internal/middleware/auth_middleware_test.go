@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+type allowSessions struct{}
+
+func (allowSessions) ValidateSession(claims *jwt.Claims) error {
+	return nil
+}
+
+// staticAPIKeys accepts a single key with fixed scopes
+type staticAPIKeys struct {
+	key    string
+	scopes []string
+}
+
+func (k staticAPIKeys) ValidateAPIKey(rawKey string) (uint, string, []string, error) {
+	if rawKey != k.key {
+		return 0, "", nil, errors.New("invalid api key")
+	}
+	return 1, "owner@example.com", k.scopes, nil
+}
+
+func newScopedRouter(jwtUtil *jwt.JWTUtil, apiKeys APIKeyValidator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	protected := router.Group("/", AuthMiddleware(jwtUtil, allowSessions{}, apiKeys))
+	subscriptions := protected.Group("/subscriptions", RequireScopes(models.APIKeyScopeSubscriptionsRead, models.APIKeyScopeSubscriptionsWrite))
+	subscriptions.GET("", ok)
+	subscriptions.POST("", ok)
+	protected.GET("/account", RequireSession(), ok)
+	return router
+}
+
+func TestAPIKeyScopesAndSessionOnlyRoutes(t *testing.T) {
+	jwtUtil, err := jwt.NewJWTUtil([]*jwt.Key{jwt.NewHMACKey("test-secret")}, 15*time.Minute, clock.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newScopedRouter(jwtUtil, staticAPIKeys{key: "rg_read", scopes: []string{models.APIKeyScopeSubscriptionsRead}})
+	accessToken, err := jwtUtil.GenerateToken(1, "owner@example.com", models.RoleUser, "session", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		want          int
+	}{
+		{"read scope reads", http.MethodGet, "/subscriptions", "ApiKey rg_read", http.StatusOK},
+		{"read scope cannot write", http.MethodPost, "/subscriptions", "ApiKey rg_read", http.StatusForbidden},
+		{"unknown key", http.MethodGet, "/subscriptions", "ApiKey rg_other", http.StatusUnauthorized},
+		{"key on a session-only route", http.MethodGet, "/account", "ApiKey rg_read", http.StatusForbidden},
+		{"session writes", http.MethodPost, "/subscriptions", "Bearer " + accessToken, http.StatusOK},
+		{"session on a session-only route", http.MethodGet, "/account", "Bearer " + accessToken, http.StatusOK},
+		{"unknown scheme", http.MethodGet, "/subscriptions", "Basic rg_read", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set(AuthorizationHeader, tt.authorization)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s: status %d, want %d", tt.name, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"renew-guard/pkg/ratelimit"
+	"renew-guard/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware rejects clients that exceed limiter for the matched
+// route with 429 Too Many Requests and a Retry-After header
+func RateLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Allow(c.FullPath() + "|" + c.ClientIP())
+		if !allowed {
+			SetRetryAfter(c, retryAfter)
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many requests, please try again later")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// FailedAttemptLimitMiddleware only counts requests the handler rejects with
+// 401 Unauthorized. Once a client reaches the limit on the matched route, it
+// gets 429 Too Many Requests before the handler runs, so failed logins from
+// one address cannot be retried indefinitely.
+func FailedAttemptLimitMiddleware(limiter *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.FullPath() + "|" + c.ClientIP()
+
+		if allowed, retryAfter := limiter.Check(key); !allowed {
+			SetRetryAfter(c, retryAfter)
+			utils.ErrorResponse(c, http.StatusTooManyRequests, "Too many failed attempts, please try again later")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		if c.Writer.Status() == http.StatusUnauthorized {
+			limiter.Allow(key)
+		}
+	}
+}
+
+// SetRetryAfter tells the client how many whole seconds to wait before retrying
+func SetRetryAfter(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+}
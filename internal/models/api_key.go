@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scopes an API key can be granted. Read scopes allow GET requests, write
+// scopes everything else on the same resource.
+const (
+	APIKeyScopeSubscriptionsRead  = "subscriptions:read"
+	APIKeyScopeSubscriptionsWrite = "subscriptions:write"
+	APIKeyScopePreferencesRead    = "preferences:read"
+	APIKeyScopePreferencesWrite   = "preferences:write"
+)
+
+// APIKeyScopes lists every grantable scope
+var APIKeyScopes = []string{
+	APIKeyScopeSubscriptionsRead,
+	APIKeyScopeSubscriptionsWrite,
+	APIKeyScopePreferencesRead,
+	APIKeyScopePreferencesWrite,
+}
+
+// APIKey is a personal access key for scripts and integrations. The key is
+// shown once at creation; only its hash and a short display prefix are stored.
+type APIKey struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"` // Leading characters of the key, to tell keys apart
+	KeyHash    string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes     []string   `gorm:"type:jsonb;serializer:json" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// TableName keeps the conventional api_keys table name
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// BeforeCreate is a GORM hook that runs before creating an API key
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	k.CreatedAt = tx.NowFunc()
+	return nil
+}
+
+// IsActive reports whether the key can authenticate at now
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// IsValidAPIKeyScope reports whether scope can be granted to a key
+func IsValidAPIKeyScope(scope string) bool {
+	for _, s := range APIKeyScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
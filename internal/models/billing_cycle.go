@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Billing cycle presets accepted by the API
+const (
+	BillingCycleWeekly    = "weekly"
+	BillingCycleMonthly   = "monthly"
+	BillingCycleQuarterly = "quarterly"
+	BillingCycleYearly    = "yearly"
+	BillingCycleCustom    = "custom"
+)
+
+// Billing units used by custom "every N units" cycles
+const (
+	BillingUnitDay   = "day"
+	BillingUnitWeek  = "week"
+	BillingUnitMonth = "month"
+	BillingUnitYear  = "year"
+)
+
+var ErrInvalidBillingCycle = errors.New("invalid billing cycle")
+
+// ResolveBillingCycle maps a cycle preset to its interval and unit.
+// Custom cycles must supply their own interval and unit.
+func ResolveBillingCycle(cycle string, interval int, unit string) (int, string, error) {
+	switch cycle {
+	case BillingCycleWeekly:
+		return 1, BillingUnitWeek, nil
+	case BillingCycleMonthly:
+		return 1, BillingUnitMonth, nil
+	case BillingCycleQuarterly:
+		return 3, BillingUnitMonth, nil
+	case BillingCycleYearly:
+		return 1, BillingUnitYear, nil
+	case BillingCycleCustom:
+		if interval <= 0 {
+			return 0, "", ErrInvalidBillingCycle
+		}
+		switch unit {
+		case BillingUnitDay, BillingUnitWeek, BillingUnitMonth, BillingUnitYear:
+			return interval, unit, nil
+		}
+	}
+	return 0, "", ErrInvalidBillingCycle
+}
+
+// AddBillingPeriod advances t by interval units. Month and year arithmetic
+// lands on anchorDay, clamped to the last day of the target month, so a
+// cycle anchored on the 31st renews on Feb 28/29 and then Mar 31 again.
+// An anchorDay of 0 uses t's own day of month.
+func AddBillingPeriod(t time.Time, interval int, unit string, anchorDay int) time.Time {
+	switch unit {
+	case BillingUnitDay:
+		return t.AddDate(0, 0, interval)
+	case BillingUnitWeek:
+		return t.AddDate(0, 0, 7*interval)
+	case BillingUnitMonth:
+		return addMonthsClamped(t, interval, anchorDay)
+	case BillingUnitYear:
+		return addMonthsClamped(t, 12*interval, anchorDay)
+	}
+	return t
+}
+
+// addMonthsClamped adds months to t without overflowing into the next month
+func addMonthsClamped(t time.Time, months int, anchorDay int) time.Time {
+	if anchorDay <= 0 {
+		anchorDay = t.Day()
+	}
+
+	year, month, _ := t.Date()
+	hour, min, sec := t.Clock()
+	firstOfTarget := time.Date(year, month+time.Month(months), 1, hour, min, sec, t.Nanosecond(), t.Location())
+
+	day := anchorDay
+	if last := daysInMonth(firstOfTarget); day > last {
+		day = last
+	}
+
+	return time.Date(firstOfTarget.Year(), firstOfTarget.Month(), day, hour, min, sec, t.Nanosecond(), t.Location())
+}
+
+// daysInMonth returns the number of days in t's month
+func daysInMonth(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
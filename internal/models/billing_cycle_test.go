@@ -0,0 +1,127 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveBillingCycle(t *testing.T) {
+	tests := []struct {
+		cycle        string
+		interval     int
+		unit         string
+		wantInterval int
+		wantUnit     string
+		wantErr      bool
+	}{
+		{cycle: BillingCycleWeekly, wantInterval: 1, wantUnit: BillingUnitWeek},
+		{cycle: BillingCycleMonthly, wantInterval: 1, wantUnit: BillingUnitMonth},
+		{cycle: BillingCycleQuarterly, wantInterval: 3, wantUnit: BillingUnitMonth},
+		{cycle: BillingCycleYearly, wantInterval: 1, wantUnit: BillingUnitYear},
+		// Presets ignore any interval and unit sent along
+		{cycle: BillingCycleMonthly, interval: 5, unit: BillingUnitDay, wantInterval: 1, wantUnit: BillingUnitMonth},
+		{cycle: BillingCycleCustom, interval: 2, unit: BillingUnitWeek, wantInterval: 2, wantUnit: BillingUnitWeek},
+		{cycle: BillingCycleCustom, interval: 0, unit: BillingUnitDay, wantErr: true},
+		{cycle: BillingCycleCustom, interval: 1, unit: "fortnight", wantErr: true},
+		{cycle: "daily", wantErr: true},
+	}
+	for _, tt := range tests {
+		interval, unit, err := ResolveBillingCycle(tt.cycle, tt.interval, tt.unit)
+		if tt.wantErr {
+			if err != ErrInvalidBillingCycle {
+				t.Errorf("ResolveBillingCycle(%q, %d, %q) error = %v, want %v", tt.cycle, tt.interval, tt.unit, err, ErrInvalidBillingCycle)
+			}
+			continue
+		}
+		if err != nil || interval != tt.wantInterval || unit != tt.wantUnit {
+			t.Errorf("ResolveBillingCycle(%q, %d, %q) = %d, %q, %v; want %d, %q",
+				tt.cycle, tt.interval, tt.unit, interval, unit, err, tt.wantInterval, tt.wantUnit)
+		}
+	}
+}
+
+func TestAddBillingPeriodClampsToMonthEnd(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 9, 30, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		from      time.Time
+		interval  int
+		unit      string
+		anchorDay int
+		want      time.Time
+	}{
+		{"days", date(2024, 2, 27), 3, BillingUnitDay, 0, date(2024, 3, 1)},
+		{"weeks", date(2024, 12, 30), 2, BillingUnitWeek, 0, date(2025, 1, 13)},
+		{"into a leap February", date(2024, 1, 31), 1, BillingUnitMonth, 31, date(2024, 2, 29)},
+		{"into a short February", date(2023, 1, 31), 1, BillingUnitMonth, 31, date(2023, 2, 28)},
+		{"back to the anchor", date(2024, 2, 29), 1, BillingUnitMonth, 31, date(2024, 3, 31)},
+		{"into a 30 day month", date(2024, 3, 31), 1, BillingUnitMonth, 31, date(2024, 4, 30)},
+		{"quarter across a year", date(2024, 11, 30), 3, BillingUnitMonth, 30, date(2025, 2, 28)},
+		{"own day without anchor", date(2024, 1, 15), 1, BillingUnitMonth, 0, date(2024, 2, 15)},
+		{"leap day to a common year", date(2024, 2, 29), 1, BillingUnitYear, 29, date(2025, 2, 28)},
+		{"leap day to the next leap year", date(2024, 2, 29), 4, BillingUnitYear, 29, date(2028, 2, 29)},
+		{"unknown unit", date(2024, 1, 1), 1, "fortnight", 0, date(2024, 1, 1)},
+	}
+	for _, tt := range tests {
+		if got := AddBillingPeriod(tt.from, tt.interval, tt.unit, tt.anchorDay); !got.Equal(tt.want) {
+			t.Errorf("%s: AddBillingPeriod(%s, %d, %s, %d) = %s, want %s",
+				tt.name, tt.from.Format("2006-01-02"), tt.interval, tt.unit, tt.anchorDay, got, tt.want)
+		}
+	}
+}
+
+func TestComputeEndDate(t *testing.T) {
+	start := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		subscription Subscription
+		wantEnd      time.Time
+		wantDuration int
+	}{
+		{
+			name:         "fixed duration",
+			subscription: Subscription{StartDate: start, DurationDays: 45},
+			wantEnd:      time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
+			wantDuration: 45,
+		},
+		{
+			name: "monthly from the 31st",
+			subscription: Subscription{
+				StartDate:        start,
+				DurationDays:     365,
+				BillingCycle:     BillingCycleMonthly,
+				BillingInterval:  1,
+				BillingUnit:      BillingUnitMonth,
+				BillingAnchorDay: 31,
+			},
+			wantEnd:      time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+			wantDuration: 29,
+		},
+		{
+			name: "yearly over a leap day",
+			subscription: Subscription{
+				StartDate:       start,
+				BillingCycle:    BillingCycleYearly,
+				BillingInterval: 1,
+				BillingUnit:     BillingUnitYear,
+			},
+			wantEnd:      time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+			wantDuration: 366,
+		},
+	}
+	for _, tt := range tests {
+		subscription := tt.subscription
+		subscription.ComputeEndDate()
+		if !subscription.EndDate.Equal(tt.wantEnd) {
+			t.Errorf("%s: ends %s, want %s", tt.name, subscription.EndDate, tt.wantEnd)
+		}
+		// Cycles keep DurationDays in step with the period they computed
+		if subscription.DurationDays != tt.wantDuration {
+			t.Errorf("%s: duration = %d days, want %d", tt.name, subscription.DurationDays, tt.wantDuration)
+		}
+	}
+}
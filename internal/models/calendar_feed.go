@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CalendarFeed is a user's secret iCalendar URL. The token in the URL is
+// shown once when issued; only its hash and a short display prefix are
+// stored. Rotating the token replaces the feed, so a leaked URL stops working.
+type CalendarFeed struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	UserID         uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	Prefix         string     `gorm:"not null" json:"prefix"` // Leading characters of the token, to recognise the URL
+	TokenHash      string     `gorm:"not null;uniqueIndex" json:"-"`
+	LastAccessedAt *time.Time `json:"last_accessed_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a calendar feed
+func (f *CalendarFeed) BeforeCreate(tx *gorm.DB) error {
+	f.CreatedAt = tx.NowFunc()
+	return nil
+}
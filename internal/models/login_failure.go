@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// LoginFailure counts consecutive failed logins for an email address. It is
+// kept per address rather than per user, so addresses without an account
+// are delayed and locked exactly like registered ones and a lockout does not
+// reveal whether an account exists.
+type LoginFailure struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Email        string     `gorm:"not null;uniqueIndex" json:"email"` // Lower-cased address the logins were attempted for
+	FailedLogins int        `gorm:"not null;default:0" json:"failed_logins"`
+	LastFailedAt time.Time  `gorm:"not null" json:"last_failed_at"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"` // Password logins are refused until then
+}
+
+// IsLocked reports whether password logins are refused at now, and for how long
+func (f *LoginFailure) IsLocked(now time.Time) (bool, time.Duration) {
+	if f.LockedUntil == nil || !now.Before(*f.LockedUntil) {
+		return false, 0
+	}
+	return true, f.LockedUntil.Sub(now)
+}
@@ -10,8 +10,13 @@ type NotificationLog struct {
 	ID             uint      `gorm:"primaryKey" json:"id"`
 	SubscriptionID uint      `gorm:"not null;index:idx_subscription_sent" json:"subscription_id"`
 	SentAt         time.Time `gorm:"not null;index:idx_subscription_sent" json:"sent_at"`
-	Status         string    `gorm:"not null" json:"status"` // "success", "failed"
+	Channel        string    `gorm:"not null;default:'email'" json:"channel"`
+	Status         string    `gorm:"not null" json:"status"` // "success", "failed", "dead_letter", "skipped"
 	ErrorMessage   string    `json:"error_message,omitempty"`
+	DaysBefore     *int      `json:"days_before,omitempty"` // Reminder offset that fired, nil for the default daily window
+	OutboxID       *uint     `gorm:"index" json:"outbox_id,omitempty"`
+	Attempt        int       `gorm:"default:1" json:"attempt"`
+	Digest         bool      `gorm:"default:false" json:"digest"` // Sent as part of a grouped digest
 
 	// Relationships
 	Subscription Subscription `gorm:"foreignKey:SubscriptionID" json:"subscription,omitempty"`
@@ -19,6 +24,8 @@ type NotificationLog struct {
 
 // BeforeCreate is a GORM hook that runs before creating a notification log
 func (n *NotificationLog) BeforeCreate(tx *gorm.DB) error {
-	n.SentAt = time.Now()
+	if n.SentAt.IsZero() {
+		n.SentAt = tx.NowFunc()
+	}
 	return nil
 }
@@ -0,0 +1,112 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Roles a member can hold within an organization, from least to most privileged
+const (
+	OrganizationRoleViewer = "viewer"
+	OrganizationRoleMember = "member"
+	OrganizationRoleAdmin  = "admin"
+	OrganizationRoleOwner  = "owner"
+)
+
+// organizationRoleRanks orders the organization roles; higher ranks include
+// the permissions of lower ones
+var organizationRoleRanks = map[string]int{
+	OrganizationRoleViewer: 1,
+	OrganizationRoleMember: 2,
+	OrganizationRoleAdmin:  3,
+	OrganizationRoleOwner:  4,
+}
+
+// DefaultNotifyRoles receive reminders for organization subscriptions that
+// do not choose their own roles
+var DefaultNotifyRoles = []string{OrganizationRoleOwner, OrganizationRoleAdmin}
+
+// IsValidOrganizationRole reports whether role is a known organization role
+func IsValidOrganizationRole(role string) bool {
+	_, ok := organizationRoleRanks[role]
+	return ok
+}
+
+// Organization groups users who share subscriptions, e.g. a team paying for
+// tools together
+type Organization struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationships
+	Members []OrganizationMember `gorm:"foreignKey:OrganizationID" json:"members,omitempty"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating an organization
+func (o *Organization) BeforeCreate(tx *gorm.DB) error {
+	o.CreatedAt = tx.NowFunc()
+	o.UpdatedAt = tx.NowFunc()
+	return nil
+}
+
+// BeforeUpdate is a GORM hook that runs before updating an organization
+func (o *Organization) BeforeUpdate(tx *gorm.DB) error {
+	o.UpdatedAt = tx.NowFunc()
+	return nil
+}
+
+// OrganizationMember links a user to an organization with a role. Viewers can
+// read the organization's subscriptions, members can also edit them, admins
+// manage the membership and owners the organization itself.
+type OrganizationMember struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_organization_members_org_user" json:"organization_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_organization_members_org_user;index" json:"user_id"`
+	Role           string    `gorm:"not null;default:'member'" json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relationships
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+	User         User         `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a membership
+func (m *OrganizationMember) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = tx.NowFunc()
+	return nil
+}
+
+// HasRole reports whether the member's role is at least role
+func (m *OrganizationMember) HasRole(role string) bool {
+	return organizationRoleRanks[m.Role] >= organizationRoleRanks[role]
+}
+
+// OrganizationInvitation offers a role in an organization to an email
+// address. It only becomes a membership once the account holding that
+// verified address accepts it.
+type OrganizationInvitation struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	OrganizationID uint      `gorm:"not null;uniqueIndex:idx_organization_invitations_org_email" json:"organization_id"`
+	Email          string    `gorm:"not null;uniqueIndex:idx_organization_invitations_org_email;index" json:"email"` // Lower-case
+	Role           string    `gorm:"not null;default:'member'" json:"role"`
+	InvitedByID    *uint     `json:"invited_by_id,omitempty"` // Cleared when the inviter's account is deleted
+	ExpiresAt      time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Relationships
+	Organization Organization `gorm:"foreignKey:OrganizationID" json:"-"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating an invitation
+func (i *OrganizationInvitation) BeforeCreate(tx *gorm.DB) error {
+	i.CreatedAt = tx.NowFunc()
+	return nil
+}
+
+// IsPending reports whether the invitation can still be accepted at now
+func (i *OrganizationInvitation) IsPending(now time.Time) bool {
+	return now.Before(i.ExpiresAt)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Outbox delivery states
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusDead    = "dead"
+)
+
+// Notification log statuses
+const (
+	NotificationStatusSuccess    = "success"
+	NotificationStatusFailed     = "failed"
+	NotificationStatusDeadLetter = "dead_letter"
+	NotificationStatusSkipped    = "skipped"
+)
+
+// OutboxMessage is a notification waiting to be delivered. Messages are
+// retried with exponential backoff until they are sent or run out of attempts.
+type OutboxMessage struct {
+	ID              uint                   `gorm:"primaryKey" json:"id"`
+	SubscriptionID  *uint                  `gorm:"index" json:"subscription_id,omitempty"`                       // Nil for digests
+	SubscriptionIDs []uint                 `gorm:"type:jsonb;serializer:json" json:"subscription_ids,omitempty"` // Subscriptions covered by a digest
+	DaysBefore      *int                   `json:"days_before,omitempty"`
+	Channel         string                 `gorm:"not null;default:'email'" json:"channel"`
+	Recipient       string                 `gorm:"type:text;not null" json:"recipient"` // Email address or webhook URL
+	Subject         string                 `gorm:"not null" json:"subject"`
+	HTMLBody        string                 `gorm:"type:text;not null" json:"-"`
+	TextBody        string                 `gorm:"type:text;not null;default:''" json:"-"`
+	Data            map[string]interface{} `gorm:"type:jsonb;serializer:json" json:"-"` // Structured payload for the JSON webhook
+	Attachments     []OutboxAttachment     `gorm:"type:jsonb;serializer:json" json:"-"` // Files sent with an email
+	Status          string                 `gorm:"not null;default:'pending';index:idx_outbox_status_next" json:"status"`
+	Attempts        int                    `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts     int                    `gorm:"not null" json:"max_attempts"`
+	NextAttemptAt   time.Time              `gorm:"not null;index:idx_outbox_status_next" json:"next_attempt_at"`
+	LastError       string                 `json:"last_error,omitempty"`
+	SentAt          *time.Time             `json:"sent_at,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
+
+// OutboxAttachment is a file queued with an email notification
+type OutboxAttachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Data        []byte `json:"data"`
+}
+
+// TableName keeps the outbox in a singular, queue-like table
+func (OutboxMessage) TableName() string {
+	return "notification_outbox"
+}
+
+// BeforeCreate is a GORM hook that runs before enqueueing a message
+func (m *OutboxMessage) BeforeCreate(tx *gorm.DB) error {
+	m.CreatedAt = tx.NowFunc()
+	m.UpdatedAt = tx.NowFunc()
+	return nil
+}
+
+// BeforeUpdate is a GORM hook that runs before updating a message
+func (m *OutboxMessage) BeforeUpdate(tx *gorm.DB) error {
+	m.UpdatedAt = tx.NowFunc()
+	return nil
+}
+
+// CoveredSubscriptionIDs returns the subscriptions the message is about: the
+// single subscription of a warning, or every subscription in a digest
+func (m *OutboxMessage) CoveredSubscriptionIDs() []uint {
+	if len(m.SubscriptionIDs) > 0 {
+		return m.SubscriptionIDs
+	}
+	if m.SubscriptionID != nil {
+		return []uint{*m.SubscriptionID}
+	}
+	return nil
+}
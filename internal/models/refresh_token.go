@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken is one link in a session's rotation chain. Only a hash of the
+// token is stored. Every refresh marks the presented token as rotated and
+// issues a successor in the same family; presenting a rotated token again is
+// treated as theft and revokes the whole family.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID  string     `gorm:"not null;index" json:"family_id"` // Session identifier shared by all rotations
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a refresh token
+func (t *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	t.CreatedAt = tx.NowFunc()
+	return nil
+}
+
+// IsActive reports whether the token can still be exchanged at now
+func (t *RefreshToken) IsActive(now time.Time) bool {
+	return t.RotatedAt == nil && t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sort"
 	"time"
 
 	"gorm.io/gorm"
@@ -8,78 +9,201 @@ import (
 
 type Subscription struct {
 	ID                   uint       `gorm:"primaryKey" json:"id"`
-	UserID               uint       `gorm:"not null;index" json:"user_id"`
-	Email                string     `gorm:"default:''" json:"email"` // User's email at subscription creation
+	UserID               uint       `gorm:"not null;index" json:"user_id"`          // Owner, or the creator of an organization subscription
+	OrganizationID       *uint      `gorm:"index" json:"organization_id,omitempty"` // Set for subscriptions shared with an organization
+	Email                string     `gorm:"default:''" json:"email"`                // User's email at subscription creation
 	Name                 string     `gorm:"not null" json:"name"`
 	StartDate            time.Time  `gorm:"not null" json:"start_date"`
 	DurationDays         int        `gorm:"not null" json:"duration_days"`
+	BillingCycle         string     `gorm:"default:''" json:"billing_cycle,omitempty"`   // weekly, monthly, quarterly, yearly, custom; empty uses DurationDays
+	BillingInterval      int        `gorm:"default:0" json:"billing_interval,omitempty"` // Number of BillingUnit per cycle
+	BillingUnit          string     `gorm:"default:''" json:"billing_unit,omitempty"`    // day, week, month, year
+	BillingAnchorDay     int        `gorm:"default:0" json:"billing_anchor_day,omitempty"`
+	PriceMinor           int64      `gorm:"default:0" json:"price_minor"`                // Price per cycle in minor units (e.g. cents)
+	Currency             string     `gorm:"size:3;default:''" json:"currency,omitempty"` // ISO 4217 code
+	Timezone             string     `gorm:"default:''" json:"timezone,omitempty"`        // IANA zone the billing calendar runs in
 	EndDate              time.Time  `gorm:"not null;index" json:"end_date"`
 	NotificationEnabled  bool       `gorm:"default:true" json:"notification_enabled"`
+	Channels             []string   `gorm:"type:jsonb;serializer:json" json:"channels,omitempty"`     // Overrides the owner's preferred channels when set
+	NotifyRoles          []string   `gorm:"type:jsonb;serializer:json" json:"notify_roles,omitempty"` // Organization roles that receive reminders
+	Tags                 []string   `gorm:"type:jsonb;serializer:json" json:"tags,omitempty"`         // Lower-case labels for filtering
+	AutoRenew            bool       `gorm:"default:false" json:"auto_renew"`
 	LastNotificationSent *time.Time `json:"last_notification_sent,omitempty"`
 	CreatedAt            time.Time  `json:"created_at"`
 	UpdatedAt            time.Time  `json:"updated_at"`
 
 	// Relationships
-	User             User              `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	NotificationLogs []NotificationLog `gorm:"foreignKey:SubscriptionID" json:"notification_logs,omitempty"`
+	User             User                   `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	NotificationLogs []NotificationLog      `gorm:"foreignKey:SubscriptionID" json:"notification_logs,omitempty"`
+	Reminders        []SubscriptionReminder `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"reminders,omitempty"`
 }
 
 // BeforeCreate is a GORM hook that runs before creating a subscription
 func (s *Subscription) BeforeCreate(tx *gorm.DB) error {
-	s.CreatedAt = time.Now()
-	s.UpdatedAt = time.Now()
+	s.CreatedAt = tx.NowFunc()
+	s.UpdatedAt = tx.NowFunc()
 	s.ComputeEndDate()
 	return nil
 }
 
 // BeforeUpdate is a GORM hook that runs before updating a subscription
 func (s *Subscription) BeforeUpdate(tx *gorm.DB) error {
-	s.UpdatedAt = time.Now()
+	s.UpdatedAt = tx.NowFunc()
 	s.ComputeEndDate()
 	return nil
 }
 
-// ComputeEndDate calculates the end date based on start date and duration
+// HasBillingCycle reports whether the subscription renews on a calendar cycle
+// rather than a fixed number of days
+func (s *Subscription) HasBillingCycle() bool {
+	return s.BillingCycle != "" && s.BillingInterval > 0 && s.BillingUnit != ""
+}
+
+// Location returns the subscription's own timezone, or UTC if unset. Billing
+// arithmetic runs in it; dates shown to people use LocationFor.
+func (s *Subscription) Location() *time.Location {
+	return ResolveLocation(s.Timezone)
+}
+
+// LocationFor returns the zone the subscription's dates are shown and
+// reminded in: its own timezone, else the owner's preferred one, else UTC.
+// preference may be nil.
+func (s *Subscription) LocationFor(preference *UserPreference) *time.Location {
+	if preference == nil {
+		return s.Location()
+	}
+	return ResolveLocation(s.Timezone, preference.Timezone)
+}
+
+// ComputeEndDate calculates the end date based on start date and billing cycle,
+// falling back to DurationDays for subscriptions without a cycle. Calendar
+// arithmetic happens in the subscription's timezone so month ends and DST
+// shifts land on the right local date; the result is stored in UTC.
 func (s *Subscription) ComputeEndDate() {
-	s.EndDate = s.StartDate.AddDate(0, 0, s.DurationDays)
+	start := s.StartDate.In(s.Location())
+
+	if !s.HasBillingCycle() {
+		s.EndDate = start.AddDate(0, 0, s.DurationDays).UTC()
+		return
+	}
+
+	s.EndDate = AddBillingPeriod(start, s.BillingInterval, s.BillingUnit, s.BillingAnchorDay).UTC()
+
+	// Keep duration_days meaningful for clients that predate billing cycles
+	s.DurationDays = CalendarDaysBetween(start, s.EndDate, s.Location())
+}
+
+// NotificationChannels returns the channels reminders go out on: the
+// subscription's own override if set, else the owner's preference
+func (s *Subscription) NotificationChannels(preference *UserPreference) []string {
+	if len(s.Channels) > 0 {
+		return s.Channels
+	}
+	return preference.Channels
+}
+
+// IsShared reports whether the subscription belongs to an organization
+func (s *Subscription) IsShared() bool {
+	return s.OrganizationID != nil
+}
+
+// ReminderRoles returns the organization roles whose members receive
+// reminders for a shared subscription
+func (s *Subscription) ReminderRoles() []string {
+	if len(s.NotifyRoles) > 0 {
+		return s.NotifyRoles
+	}
+	return DefaultNotifyRoles
+}
+
+// CyclesPerYear returns how many billing periods fit in a year, used to
+// normalise prices across cycles
+func (s *Subscription) CyclesPerYear() float64 {
+	const daysPerYear = 365.25
+
+	if !s.HasBillingCycle() {
+		if s.DurationDays <= 0 {
+			return 0
+		}
+		return daysPerYear / float64(s.DurationDays)
+	}
+
+	interval := float64(s.BillingInterval)
+	switch s.BillingUnit {
+	case BillingUnitDay:
+		return daysPerYear / interval
+	case BillingUnitWeek:
+		return daysPerYear / 7 / interval
+	case BillingUnitMonth:
+		return 12 / interval
+	case BillingUnitYear:
+		return 1 / interval
+	}
+	return 0
 }
 
-// DaysUntilExpiration returns the number of days until the subscription expires
-func (s *Subscription) DaysUntilExpiration() int {
-	now := time.Now()
-	duration := s.EndDate.Sub(now)
-	days := int(duration.Hours() / 24)
-	return days
+// AdvancePeriod rolls the subscription forward to its next billing period and
+// clears LastNotificationSent so reminders fire again for the new cycle
+func (s *Subscription) AdvancePeriod() {
+	s.StartDate = s.EndDate
+	s.ComputeEndDate()
+	s.LastNotificationSent = nil
 }
 
-// IsExpired checks if the subscription has already expired
-func (s *Subscription) IsExpired() bool {
-	return time.Now().After(s.EndDate)
+// DaysUntilExpiration returns the number of calendar days from now until the
+// subscription expires, counted on the local calendar of loc
+func (s *Subscription) DaysUntilExpiration(now time.Time, loc *time.Location) int {
+	return CalendarDaysBetween(now, s.EndDate, loc)
 }
 
-// ShouldNotify determines if a notification should be sent
-func (s *Subscription) ShouldNotify(daysBefore int) bool {
+// IsExpired checks if the subscription had already expired at now
+func (s *Subscription) IsExpired(now time.Time) bool {
+	return now.After(s.EndDate)
+}
+
+// ShouldNotify determines if a notification should be sent, treating days
+// and the once-per-day limit according to the local calendar of loc
+func (s *Subscription) ShouldNotify(now time.Time, daysBefore int, loc *time.Location) bool {
 	if !s.NotificationEnabled {
 		return false
 	}
 
-	if s.IsExpired() {
+	if s.IsExpired(now) {
 		return false
 	}
 
-	daysLeft := s.DaysUntilExpiration()
+	daysLeft := s.DaysUntilExpiration(now, loc)
 	if daysLeft > daysBefore || daysLeft < 0 {
 		return false
 	}
 
 	// Check if notification was already sent today
-	if s.LastNotificationSent != nil {
-		today := time.Now().Truncate(24 * time.Hour)
-		lastSent := s.LastNotificationSent.Truncate(24 * time.Hour)
-		if today.Equal(lastSent) {
-			return false
-		}
+	if s.LastNotificationSent != nil && SameLocalDay(*s.LastNotificationSent, now, loc) {
+		return false
 	}
 
 	return true
 }
+
+// DueReminders returns the reminders that have come due and not yet fired for
+// the current period, closest offset first
+func (s *Subscription) DueReminders(now time.Time, loc *time.Location) []SubscriptionReminder {
+	if !s.NotificationEnabled || s.IsExpired(now) {
+		return nil
+	}
+
+	daysLeft := s.DaysUntilExpiration(now, loc)
+
+	var due []SubscriptionReminder
+	for _, reminder := range s.Reminders {
+		if daysLeft <= reminder.DaysBefore && !reminder.SentFor(s.EndDate) {
+			due = append(due, reminder)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].DaysBefore < due[j].DaysBefore
+	})
+
+	return due
+}
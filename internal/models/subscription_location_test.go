@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocationForFallsBackToPreference(t *testing.T) {
+	preference := &UserPreference{Timezone: "Australia/Sydney"}
+	// 14:00 UTC on Jan 31 is already Feb 1 in Sydney
+	end := time.Date(2024, 1, 31, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		timezone   string
+		preference *UserPreference
+		wantZone   string
+		wantDay    int
+	}{
+		{"own zone wins", "America/New_York", preference, "America/New_York", 31},
+		{"preference when unset", "", preference, "Australia/Sydney", 1},
+		{"UTC without preference", "", nil, "UTC", 31},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := &Subscription{Timezone: tt.timezone, EndDate: end}
+			loc := subscription.LocationFor(tt.preference)
+			if loc.String() != tt.wantZone {
+				t.Errorf("zone = %s, want %s", loc, tt.wantZone)
+			}
+			if day := subscription.EndDate.In(loc).Day(); day != tt.wantDay {
+				t.Errorf("end date shown on day %d, want %d", day, tt.wantDay)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SubscriptionReminder is one "N days before expiration" reminder offset.
+// Each offset fires once per billing period; LastSentForEndDate records the
+// period end date it last fired for.
+type SubscriptionReminder struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID     uint       `gorm:"not null;uniqueIndex:idx_reminder_subscription_days" json:"subscription_id"`
+	DaysBefore         int        `gorm:"not null;uniqueIndex:idx_reminder_subscription_days" json:"days_before"`
+	LastSentForEndDate *time.Time `json:"last_sent_for_end_date,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a reminder
+func (r *SubscriptionReminder) BeforeCreate(tx *gorm.DB) error {
+	r.CreatedAt = tx.NowFunc()
+	return nil
+}
+
+// SentFor reports whether the reminder already fired for the period ending at endDate
+func (r *SubscriptionReminder) SentFor(endDate time.Time) bool {
+	return r.LastSentForEndDate != nil && r.LastSentForEndDate.Equal(endDate)
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SubscriptionRenewal records one automatic rollover of a subscription period
+type SubscriptionRenewal struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	SubscriptionID    uint      `gorm:"not null;index" json:"subscription_id"`
+	PreviousStartDate time.Time `gorm:"not null" json:"previous_start_date"`
+	PreviousEndDate   time.Time `gorm:"not null" json:"previous_end_date"`
+	NewStartDate      time.Time `gorm:"not null" json:"new_start_date"`
+	NewEndDate        time.Time `gorm:"not null" json:"new_end_date"`
+	RenewedAt         time.Time `gorm:"not null" json:"renewed_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating a renewal record
+func (r *SubscriptionRenewal) BeforeCreate(tx *gorm.DB) error {
+	r.RenewedAt = tx.NowFunc()
+	return nil
+}
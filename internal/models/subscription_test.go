@@ -0,0 +1,213 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"renew-guard/pkg/clock"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestAdvancePeriodKeepsMonthEndAnchorAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	subscription := &Subscription{
+		StartDate:        time.Date(2024, 1, 31, 10, 0, 0, 0, newYork),
+		BillingCycle:     BillingCycleMonthly,
+		BillingInterval:  1,
+		BillingUnit:      BillingUnitMonth,
+		BillingAnchorDay: 31,
+		Timezone:         "America/New_York",
+	}
+	subscription.ComputeEndDate()
+
+	// Clamped to Feb 29, back on the 31st in March, at 10:00 local time
+	// before and after the switch to daylight saving on Mar 10
+	want := []time.Time{
+		time.Date(2024, 2, 29, 15, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 31, 14, 0, 0, 0, time.UTC),
+		time.Date(2024, 4, 30, 14, 0, 0, 0, time.UTC),
+	}
+	for i, end := range want {
+		if i > 0 {
+			subscription.AdvancePeriod()
+		}
+		if !subscription.EndDate.Equal(end) {
+			t.Errorf("period %d ends %s, want %s", i+1, subscription.EndDate, end)
+		}
+	}
+	if subscription.DurationDays != 30 {
+		t.Errorf("duration = %d days, want 30", subscription.DurationDays)
+	}
+}
+
+func TestShouldNotifyOncePerLocalDayAcrossDST(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name string
+		end  time.Time
+		want []string
+	}{
+		{
+			// Mar 10 has 23 hours
+			name: "spring forward",
+			end:  time.Date(2024, 3, 12, 9, 0, 0, 0, newYork),
+			want: []string{"2024-03-09", "2024-03-10", "2024-03-11", "2024-03-12"},
+		},
+		{
+			// Nov 3 has 25 hours
+			name: "fall back",
+			end:  time.Date(2024, 11, 5, 9, 0, 0, 0, newYork),
+			want: []string{"2024-11-02", "2024-11-03", "2024-11-04", "2024-11-05"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			subscription := &Subscription{EndDate: tt.end.UTC(), NotificationEnabled: true}
+			clk := clock.NewFake(tt.end.AddDate(0, 0, -7))
+
+			// Check every half hour, as the scheduler would, until expiry
+			var sent []string
+			for ; !clk.Now().After(tt.end.Add(time.Hour)); clk.Advance(30 * time.Minute) {
+				now := clk.Now()
+				if subscription.ShouldNotify(now, 3, newYork) {
+					sent = append(sent, now.In(newYork).Format("2006-01-02"))
+					subscription.LastNotificationSent = &now
+				}
+			}
+
+			if !reflect.DeepEqual(sent, tt.want) {
+				t.Errorf("notified on %v, want %v", sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestShouldNotifyCountsDaysInLocalCalendar(t *testing.T) {
+	sydney := mustLoadLocation(t, "Australia/Sydney")
+	// Ends at noon on Mar 5 in Sydney, 01:00 on Mar 5 in UTC
+	subscription := &Subscription{
+		EndDate:             time.Date(2024, 3, 5, 12, 0, 0, 0, sydney).UTC(),
+		NotificationEnabled: true,
+	}
+	// 20:00 UTC on Mar 1 is already Mar 2 in Sydney, three days out
+	now := time.Date(2024, 3, 1, 20, 0, 0, 0, time.UTC)
+
+	if got := subscription.DaysUntilExpiration(now, sydney); got != 3 {
+		t.Errorf("days left in Sydney = %d, want 3", got)
+	}
+	if !subscription.ShouldNotify(now, 3, sydney) {
+		t.Error("not notified three Sydney days before expiry")
+	}
+	if subscription.ShouldNotify(now, 3, time.UTC) {
+		t.Error("notified although four UTC days remain")
+	}
+
+	// A notification at 10:00 UTC on Mar 1 was still on Mar 1 in Sydney
+	sentAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	subscription.LastNotificationSent = &sentAt
+	if !subscription.ShouldNotify(now, 3, sydney) {
+		t.Error("suppressed by a notification sent on the previous Sydney day")
+	}
+	if subscription.ShouldNotify(now, 4, time.UTC) {
+		t.Error("notified twice on the same UTC day")
+	}
+}
+
+func TestShouldNotifySkipsDisabledAndExpired(t *testing.T) {
+	end := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	subscription := &Subscription{EndDate: end}
+	if subscription.ShouldNotify(end.Add(-time.Hour), 3, time.UTC) {
+		t.Error("notified with notifications disabled")
+	}
+
+	subscription.NotificationEnabled = true
+	if subscription.ShouldNotify(end.Add(time.Second), 3, time.UTC) {
+		t.Error("notified after expiry")
+	}
+}
+
+func TestDueRemindersFireOncePerPeriod(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	subscription := &Subscription{
+		StartDate:           start,
+		BillingCycle:        BillingCycleMonthly,
+		BillingInterval:     1,
+		BillingUnit:         BillingUnitMonth,
+		BillingAnchorDay:    31,
+		NotificationEnabled: true,
+		Reminders: []SubscriptionReminder{
+			{ID: 1, DaysBefore: 14},
+			{ID: 2, DaysBefore: 7},
+			{ID: 3, DaysBefore: 1},
+		},
+	}
+	subscription.ComputeEndDate()
+	clk := clock.NewFake(start)
+
+	// Walk two periods a day at a time, marking reminders sent the way the
+	// notification service does
+	fired := make(map[string][]int)
+	for day := 0; day < 60; day++ {
+		now := clk.Now()
+		if !subscription.EndDate.After(now) {
+			subscription.AdvancePeriod()
+		}
+		for _, reminder := range subscription.DueReminders(now, time.UTC) {
+			period := subscription.EndDate.Format("2006-01-02")
+			fired[period] = append(fired[period], reminder.DaysBefore)
+			for i := range subscription.Reminders {
+				if subscription.Reminders[i].ID == reminder.ID {
+					endDate := subscription.EndDate
+					subscription.Reminders[i].LastSentForEndDate = &endDate
+				}
+			}
+		}
+		clk.Advance(24 * time.Hour)
+	}
+
+	want := map[string][]int{
+		"2024-02-29": {14, 7, 1},
+		"2024-03-31": {14, 7, 1},
+	}
+	if !reflect.DeepEqual(fired, want) {
+		t.Errorf("reminders fired %v, want %v", fired, want)
+	}
+}
+
+func TestDueRemindersClosestFirst(t *testing.T) {
+	end := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	previousEnd := end.AddDate(0, -1, 0)
+	subscription := &Subscription{
+		EndDate:             end,
+		NotificationEnabled: true,
+		Reminders: []SubscriptionReminder{
+			{ID: 1, DaysBefore: 30, LastSentForEndDate: &end},
+			{ID: 2, DaysBefore: 7, LastSentForEndDate: &previousEnd},
+			{ID: 3, DaysBefore: 3},
+		},
+	}
+
+	// Added late: both outstanding offsets are due, the closest one first
+	due := subscription.DueReminders(end.AddDate(0, 0, -2), time.UTC)
+	var offsets []int
+	for _, reminder := range due {
+		offsets = append(offsets, reminder.DaysBefore)
+	}
+	if !reflect.DeepEqual(offsets, []int{3, 7}) {
+		t.Errorf("due offsets = %v, want [3 7]", offsets)
+	}
+
+	if due := subscription.DueReminders(end.Add(time.Second), time.UTC); due != nil {
+		t.Errorf("reminders due after expiry: %v", due)
+	}
+}
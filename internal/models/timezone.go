@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// ResolveLocation returns the first valid IANA zone among names, or UTC
+func ResolveLocation(names ...string) *time.Location {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// CalendarDaysBetween counts the midnights crossed between from and to as
+// observed on the wall clock in loc, so "tomorrow" means the next local date
+func CalendarDaysBetween(from, to time.Time, loc *time.Location) int {
+	fromYear, fromMonth, fromDay := from.In(loc).Date()
+	toYear, toMonth, toDay := to.In(loc).Date()
+
+	// Compare dates in UTC to avoid DST-length days skewing the division
+	fromDate := time.Date(fromYear, fromMonth, fromDay, 0, 0, 0, 0, time.UTC)
+	toDate := time.Date(toYear, toMonth, toDay, 0, 0, 0, 0, time.UTC)
+
+	return int(toDate.Sub(fromDate).Hours() / 24)
+}
+
+// SameLocalDay reports whether a and b fall on the same date in loc
+func SameLocalDay(a, b time.Time, loc *time.Location) bool {
+	return CalendarDaysBetween(a, b, loc) == 0
+}
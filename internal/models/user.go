@@ -1,18 +1,37 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Roles a user can hold
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// IsValidRole reports whether role is a known role
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 type User struct {
-	ID           uint      `gorm:"primaryKey" json:"id"`
-	Email        string    `gorm:"unique;not null" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Email           string     `gorm:"unique;not null" json:"email"`
+	PasswordHash    string     `gorm:"not null" json:"-"`
+	Role            string     `gorm:"not null;default:'user'" json:"role"`
+	DisabledAt      *time.Time `json:"disabled_at,omitempty"`       // Disabled accounts cannot sign in or use API keys
+	TokenVersion    int        `gorm:"not null;default:0" json:"-"` // Bumped to invalidate every issued access token
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	TOTPSecret      string     `gorm:"column:totp_secret;default:''" json:"-"` // Set on enrollment, active once TOTPEnabledAt is set
+	TOTPEnabledAt   *time.Time `gorm:"column:totp_enabled_at" json:"totp_enabled_at,omitempty"`
+	TOTPLastStep    int64      `gorm:"column:totp_last_step;not null;default:0" json:"-"` // Last accepted time step, so a code cannot be replayed
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 
 	// Relationships
 	Subscriptions []Subscription `gorm:"foreignKey:UserID" json:"subscriptions,omitempty"`
@@ -34,15 +53,34 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// HasVerifiedEmail reports whether address is the user's current email and
+// has been verified, i.e. whether it is safe to send mail to it
+func (u *User) HasVerifiedEmail(address string) bool {
+	return u.EmailVerifiedAt != nil && strings.EqualFold(u.Email, address)
+}
+
+// TwoFactorEnabled reports whether login requires a TOTP code
+func (u *User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != ""
+}
+
+// IsDisabled reports whether an administrator has disabled the account
+func (u *User) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
 // BeforeCreate is a GORM hook that runs before creating a user
 func (u *User) BeforeCreate(tx *gorm.DB) error {
-	u.CreatedAt = time.Now()
-	u.UpdatedAt = time.Now()
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	u.CreatedAt = tx.NowFunc()
+	u.UpdatedAt = tx.NowFunc()
 	return nil
 }
 
 // BeforeUpdate is a GORM hook that runs before updating a user
 func (u *User) BeforeUpdate(tx *gorm.DB) error {
-	u.UpdatedAt = time.Now()
+	u.UpdatedAt = tx.NowFunc()
 	return nil
 }
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification channels a user can opt into
+const (
+	NotificationChannelEmail   = "email"
+	NotificationChannelWebhook = "webhook"
+	NotificationChannelSlack   = "slack"
+	NotificationChannelDiscord = "discord"
+)
+
+// Notification modes: one mail per subscription, or a grouped digest
+const (
+	NotificationModeDaily  = "daily"
+	NotificationModeDigest = "digest"
+)
+
+// How often digest-mode users receive their grouped reminder
+const (
+	DigestFrequencyDaily  = "daily"
+	DigestFrequencyWeekly = "weekly"
+)
+
+// UserPreference holds per-user notification settings. Users without a
+// stored row get the application defaults.
+type UserPreference struct {
+	ID                uint       `gorm:"primaryKey" json:"id"`
+	UserID            uint       `gorm:"not null;uniqueIndex" json:"user_id"`
+	DefaultDaysBefore int        `gorm:"not null" json:"default_days_before"` // Lead time for subscriptions without their own reminders
+	Channels          []string   `gorm:"type:jsonb;serializer:json" json:"channels"`
+	WebhookURL        string     `gorm:"default:''" json:"webhook_url,omitempty"`         // Target of the generic JSON webhook channel
+	SlackWebhookURL   string     `gorm:"default:''" json:"slack_webhook_url,omitempty"`   // Slack-compatible incoming webhook
+	DiscordWebhookURL string     `gorm:"default:''" json:"discord_webhook_url,omitempty"` // Discord-compatible webhook
+	Timezone          string     `gorm:"not null;default:'UTC'" json:"timezone"`          // IANA zone name
+	Locale            string     `gorm:"not null;default:'en'" json:"locale"`
+	NotificationMode  string     `gorm:"not null;default:'daily'" json:"notification_mode"`
+	DigestFrequency   string     `gorm:"not null;default:'daily'" json:"digest_frequency"`
+	LastDigestSentAt  *time.Time `json:"last_digest_sent_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// BeforeCreate is a GORM hook that runs before creating preferences
+func (p *UserPreference) BeforeCreate(tx *gorm.DB) error {
+	p.CreatedAt = tx.NowFunc()
+	p.UpdatedAt = tx.NowFunc()
+	return nil
+}
+
+// BeforeUpdate is a GORM hook that runs before updating preferences
+func (p *UserPreference) BeforeUpdate(tx *gorm.DB) error {
+	p.UpdatedAt = tx.NowFunc()
+	return nil
+}
+
+// HasChannel reports whether the user opted into the given channel
+func (p *UserPreference) HasChannel(channel string) bool {
+	for _, c := range p.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookURLFor returns the configured URL of a webhook-based channel, or an
+// empty string for email and unconfigured channels
+func (p *UserPreference) WebhookURLFor(channel string) string {
+	switch channel {
+	case NotificationChannelWebhook:
+		return p.WebhookURL
+	case NotificationChannelSlack:
+		return p.SlackWebhookURL
+	case NotificationChannelDiscord:
+		return p.DiscordWebhookURL
+	}
+	return ""
+}
+
+// DigestDue reports whether a digest may be sent at now given the user's
+// frequency, counting days on the local calendar of loc
+func (p *UserPreference) DigestDue(now time.Time, loc *time.Location) bool {
+	if p.LastDigestSentAt == nil {
+		return true
+	}
+	if p.DigestFrequency == DigestFrequencyWeekly {
+		return CalendarDaysBetween(*p.LastDigestSentAt, now, loc) >= 7
+	}
+	return !SameLocalDay(*p.LastDigestSentAt, now, loc)
+}
@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CalendarFeedRepository interface {
+	FindByUserID(userID uint) (*models.CalendarFeed, error)
+	FindByHash(tokenHash string) (*models.CalendarFeed, error)
+	Replace(feed *models.CalendarFeed) error
+	DeleteByUserID(userID uint) error
+	UpdateLastAccessed(id uint, accessedAt time.Time) error
+}
+
+type calendarFeedRepository struct {
+	db *gorm.DB
+}
+
+func NewCalendarFeedRepository(db *gorm.DB) CalendarFeedRepository {
+	return &calendarFeedRepository{db: db}
+}
+
+func (r *calendarFeedRepository) FindByUserID(userID uint) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.db.Where("user_id = ?", userID).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// FindByHash returns the feed with its user loaded
+func (r *calendarFeedRepository) FindByHash(tokenHash string) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := r.db.Preload("User").Where("token_hash = ?", tokenHash).First(&feed).Error
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+// Replace swaps the user's feed, if any, for feed in one transaction
+func (r *calendarFeedRepository) Replace(feed *models.CalendarFeed) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", feed.UserID).Delete(&models.CalendarFeed{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(feed).Error
+	})
+}
+
+// DeleteByUserID removes the user's feed; gorm.ErrRecordNotFound if there is none
+func (r *calendarFeedRepository) DeleteByUserID(userID uint) error {
+	result := r.db.Where("user_id = ?", userID).Delete(&models.CalendarFeed{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *calendarFeedRepository) UpdateLastAccessed(id uint, accessedAt time.Time) error {
+	return r.db.Model(&models.CalendarFeed{}).
+		Where("id = ?", id).
+		Update("last_accessed_at", accessedAt).Error
+}
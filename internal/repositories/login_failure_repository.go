@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginFailureRepository interface {
+	FindByEmail(email string) (*models.LoginFailure, error)
+	RecordFailure(email string, now, windowStart time.Time) (int, error)
+	LockUntil(email string, until time.Time) error
+	Reset(email string) error
+}
+
+type loginFailureRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginFailureRepository(db *gorm.DB) LoginFailureRepository {
+	return &loginFailureRepository{db: db}
+}
+
+func (r *loginFailureRepository) FindByEmail(email string) (*models.LoginFailure, error) {
+	var failure models.LoginFailure
+	if err := r.db.Where("email = ?", email).First(&failure).Error; err != nil {
+		return nil, err
+	}
+	return &failure, nil
+}
+
+// RecordFailure counts a failed login for the address and returns the number
+// of consecutive failures. The count starts over when the previous failure
+// happened before windowStart. It is incremented in the database so
+// concurrent attempts are all counted.
+func (r *loginFailureRepository) RecordFailure(email string, now, windowStart time.Time) (int, error) {
+	var failure models.LoginFailure
+	err := r.db.Raw(`
+		INSERT INTO login_failures (email, failed_logins, last_failed_at)
+		VALUES (?, 1, ?)
+		ON CONFLICT (email) DO UPDATE SET
+			failed_logins = CASE WHEN login_failures.last_failed_at < ? THEN 1 ELSE login_failures.failed_logins + 1 END,
+			last_failed_at = EXCLUDED.last_failed_at
+		RETURNING failed_logins`,
+		email, now, windowStart,
+	).Scan(&failure).Error
+	return failure.FailedLogins, err
+}
+
+func (r *loginFailureRepository) LockUntil(email string, until time.Time) error {
+	return r.db.Model(&models.LoginFailure{}).
+		Where("email = ?", email).
+		Update("locked_until", until).Error
+}
+
+// Reset forgets the failures recorded for the address, lifting any lockout
+func (r *loginFailureRepository) Reset(email string) error {
+	return r.db.Where("email = ?", email).Delete(&models.LoginFailure{}).Error
+}
@@ -9,6 +9,8 @@ import (
 type NotificationLogRepository interface {
 	Create(log *models.NotificationLog) error
 	FindBySubscriptionID(subscriptionID uint) ([]models.NotificationLog, error)
+	FindBySubscriptionIDAndStatus(subscriptionID uint, status string) ([]models.NotificationLog, error)
+	FindBySubscriptionIDs(subscriptionIDs []uint) ([]models.NotificationLog, error)
 }
 
 type notificationLogRepository struct {
@@ -30,3 +32,24 @@ func (r *notificationLogRepository) FindBySubscriptionID(subscriptionID uint) ([
 		Find(&logs).Error
 	return logs, err
 }
+
+func (r *notificationLogRepository) FindBySubscriptionIDAndStatus(subscriptionID uint, status string) ([]models.NotificationLog, error) {
+	var logs []models.NotificationLog
+	err := r.db.Where("subscription_id = ? AND status = ?", subscriptionID, status).
+		Order("sent_at DESC").
+		Find(&logs).Error
+	return logs, err
+}
+
+// FindBySubscriptionIDs returns the logs of several subscriptions, grouped by
+// subscription and oldest first
+func (r *notificationLogRepository) FindBySubscriptionIDs(subscriptionIDs []uint) ([]models.NotificationLog, error) {
+	var logs []models.NotificationLog
+	if len(subscriptionIDs) == 0 {
+		return logs, nil
+	}
+	err := r.db.Where("subscription_id IN ?", subscriptionIDs).
+		Order("subscription_id ASC, sent_at ASC").
+		Find(&logs).Error
+	return logs, err
+}
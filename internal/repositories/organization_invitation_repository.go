@@ -0,0 +1,94 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OrganizationInvitationRepository interface {
+	Upsert(invitation *models.OrganizationInvitation) error
+	FindByID(id uint) (*models.OrganizationInvitation, error)
+	FindPendingByOrganization(organizationID uint, now time.Time) ([]models.OrganizationInvitation, error)
+	FindPendingByEmail(email string, now time.Time) ([]models.OrganizationInvitation, error)
+	Delete(id uint) error
+	Accept(invitation *models.OrganizationInvitation, member *models.OrganizationMember) error
+}
+
+type organizationInvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationInvitationRepository(db *gorm.DB) OrganizationInvitationRepository {
+	return &organizationInvitationRepository{db: db}
+}
+
+// Upsert creates the invitation, or renews the one already sent to the same
+// address with the new role, inviter and expiry
+func (r *organizationInvitationRepository) Upsert(invitation *models.OrganizationInvitation) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "email"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "invited_by_id", "expires_at", "created_at"}),
+	}).Create(invitation).Error
+}
+
+// FindByID returns the invitation with its organization loaded
+func (r *organizationInvitationRepository) FindByID(id uint) (*models.OrganizationInvitation, error) {
+	var invitation models.OrganizationInvitation
+	err := r.db.Preload("Organization").First(&invitation, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// FindPendingByOrganization returns the organization's unexpired invitations
+// with the organization loaded
+func (r *organizationInvitationRepository) FindPendingByOrganization(organizationID uint, now time.Time) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	err := r.db.Preload("Organization").
+		Where("organization_id = ? AND expires_at > ?", organizationID, now).
+		Order("created_at ASC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+// FindPendingByEmail returns the invitations sent to email, with their
+// organizations loaded
+func (r *organizationInvitationRepository) FindPendingByEmail(email string, now time.Time) ([]models.OrganizationInvitation, error) {
+	var invitations []models.OrganizationInvitation
+	err := r.db.Preload("Organization").
+		Where("email = ? AND expires_at > ?", email, now).
+		Order("created_at ASC").
+		Find(&invitations).Error
+	return invitations, err
+}
+
+func (r *organizationInvitationRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.OrganizationInvitation{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Accept turns the invitation into member in one transaction. It fails with
+// gorm.ErrRecordNotFound if the invitation was already used or withdrawn, so
+// it cannot be accepted twice concurrently.
+func (r *organizationInvitationRepository) Accept(invitation *models.OrganizationInvitation, member *models.OrganizationMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.OrganizationInvitation{}, invitation.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(member).Error
+	})
+}
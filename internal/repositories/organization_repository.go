@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	CreateWithOwner(organization *models.Organization, ownerID uint) error
+	FindByID(id uint) (*models.Organization, error)
+	Update(organization *models.Organization) error
+	Delete(id uint) error
+	FindMembership(organizationID, userID uint) (*models.OrganizationMember, error)
+	FindMembershipsByUserID(userID uint) ([]models.OrganizationMember, error)
+	FindMembers(organizationID uint) ([]models.OrganizationMember, error)
+	FindMembersByRoles(organizationID uint, roles []string) ([]models.OrganizationMember, error)
+	UpdateMemberRole(organizationID, userID uint, role string) error
+	RemoveMember(organizationID, userID uint) error
+	CountOwners(organizationID uint) (int64, error)
+	CountSoleOwnerships(userID uint) (int64, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+// CreateWithOwner creates the organization and makes ownerID its first owner
+func (r *organizationRepository) CreateWithOwner(organization *models.Organization, ownerID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           models.OrganizationRoleOwner,
+		}).Error
+	})
+}
+
+func (r *organizationRepository) FindByID(id uint) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.First(&organization, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *organizationRepository) Update(organization *models.Organization) error {
+	return r.db.Save(organization).Error
+}
+
+// Delete removes the organization together with its memberships and
+// invitations, its shared subscriptions and their notification history
+func (r *organizationRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		subscriptionIDs := tx.Model(&models.Subscription{}).Select("id").Where("organization_id = ?", id)
+		if err := deleteSubscriptionHistory(tx, subscriptionIDs); err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{
+			&models.Subscription{},
+			&models.OrganizationMember{},
+			&models.OrganizationInvitation{},
+		} {
+			if err := tx.Where("organization_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(&models.Organization{}, id).Error
+	})
+}
+
+// FindMembership returns the user's membership with the user loaded
+func (r *organizationRepository) FindMembership(organizationID, userID uint) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.Preload("User").Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// FindMembershipsByUserID returns the user's memberships with their organizations loaded
+func (r *organizationRepository) FindMembershipsByUserID(userID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Preload("Organization").
+		Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// FindMembers returns the organization's members with their users loaded
+func (r *organizationRepository) FindMembers(organizationID uint) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Preload("User").
+		Where("organization_id = ?", organizationID).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// FindMembersByRoles returns the members holding one of roles, with their users loaded
+func (r *organizationRepository) FindMembersByRoles(organizationID uint, roles []string) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Preload("User").
+		Where("organization_id = ? AND role IN ?", organizationID, roles).
+		Order("created_at ASC").
+		Find(&members).Error
+	return members, err
+}
+
+// UpdateMemberRole changes a member's role; gorm.ErrRecordNotFound if they are not a member
+func (r *organizationRepository) UpdateMemberRole(organizationID, userID uint, role string) error {
+	result := r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RemoveMember ends a membership; gorm.ErrRecordNotFound if there is none
+func (r *organizationRepository) RemoveMember(organizationID, userID uint) error {
+	result := r.db.Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Delete(&models.OrganizationMember{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *organizationRepository) CountOwners(organizationID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", organizationID, models.OrganizationRoleOwner).
+		Count(&count).Error
+	return count, err
+}
+
+// CountSoleOwnerships counts the organizations where the user is the only
+// owner but not the only member, i.e. that would be left without an owner
+// if the user went away
+func (r *organizationRepository) CountSoleOwnerships(userID uint) (int64, error) {
+	otherOwners := r.db.Table("organization_members AS other").
+		Select("1").
+		Where("other.organization_id = mine.organization_id AND other.role = ? AND other.user_id <> ?",
+			models.OrganizationRoleOwner, userID)
+	otherMembers := r.db.Table("organization_members AS other").
+		Select("1").
+		Where("other.organization_id = mine.organization_id AND other.user_id <> ?", userID)
+
+	var count int64
+	err := r.db.Table("organization_members AS mine").
+		Where("mine.user_id = ? AND mine.role = ?", userID, models.OrganizationRoleOwner).
+		Where("NOT EXISTS (?) AND EXISTS (?)", otherOwners, otherMembers).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	Create(message *models.OutboxMessage) error
+	ClaimNext(now, leaseUntil time.Time) (*models.OutboxMessage, error)
+	Update(message *models.OutboxMessage) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Create(message *models.OutboxMessage) error {
+	return r.db.Create(message).Error
+}
+
+// ClaimNext takes the pending message that has been due longest and leases
+// it by moving its next attempt to leaseUntil, so no other worker picks it up
+// while it is being sent. Rows another worker is claiming are skipped. If the
+// claiming worker dies, the message becomes due again once the lease ends.
+// It fails with gorm.ErrRecordNotFound when nothing is due.
+func (r *outboxRepository) ClaimNext(now, leaseUntil time.Time) (*models.OutboxMessage, error) {
+	var message models.OutboxMessage
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.OutboxStatusPending).
+			Where("next_attempt_at <= ?", now).
+			Order("next_attempt_at ASC").
+			Take(&message).Error
+		if err != nil {
+			return err
+		}
+		message.NextAttemptAt = leaseUntil
+		return tx.Model(&message).Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *outboxRepository) Update(message *models.OutboxMessage) error {
+	return r.db.Save(message).Error
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SubscriptionReminderRepository interface {
+	FindBySubscriptionID(subscriptionID uint) ([]models.SubscriptionReminder, error)
+	ReplaceForSubscription(subscriptionID uint, daysBefore []int) error
+	MarkSent(ids []uint, endDate time.Time) error
+	MaxDaysBefore() (int, error)
+}
+
+type subscriptionReminderRepository struct {
+	db *gorm.DB
+}
+
+func NewSubscriptionReminderRepository(db *gorm.DB) SubscriptionReminderRepository {
+	return &subscriptionReminderRepository{db: db}
+}
+
+func (r *subscriptionReminderRepository) FindBySubscriptionID(subscriptionID uint) ([]models.SubscriptionReminder, error) {
+	var reminders []models.SubscriptionReminder
+	err := r.db.Where("subscription_id = ?", subscriptionID).
+		Order("days_before DESC").
+		Find(&reminders).Error
+	return reminders, err
+}
+
+// ReplaceForSubscription sets the subscription's reminder offsets to exactly
+// daysBefore. Offsets that already exist keep their sent state.
+func (r *subscriptionReminderRepository) ReplaceForSubscription(subscriptionID uint, daysBefore []int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		remove := tx.Where("subscription_id = ?", subscriptionID)
+		if len(daysBefore) > 0 {
+			remove = remove.Where("days_before NOT IN ?", daysBefore)
+		}
+		if err := remove.Delete(&models.SubscriptionReminder{}).Error; err != nil {
+			return err
+		}
+
+		if len(daysBefore) == 0 {
+			return nil
+		}
+
+		reminders := make([]models.SubscriptionReminder, 0, len(daysBefore))
+		for _, days := range daysBefore {
+			reminders = append(reminders, models.SubscriptionReminder{
+				SubscriptionID: subscriptionID,
+				DaysBefore:     days,
+			})
+		}
+
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&reminders).Error
+	})
+}
+
+func (r *subscriptionReminderRepository) MarkSent(ids []uint, endDate time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.SubscriptionReminder{}).
+		Where("id IN ?", ids).
+		Update("last_sent_for_end_date", endDate).Error
+}
+
+// MaxDaysBefore returns the largest reminder offset in use, or 0 if none exist
+func (r *subscriptionReminderRepository) MaxDaysBefore() (int, error) {
+	var maxDays int
+	err := r.db.Model(&models.SubscriptionReminder{}).
+		Select("COALESCE(MAX(days_before), 0)").
+		Scan(&maxDays).Error
+	return maxDays, err
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type SubscriptionRenewalRepository interface {
+	FindBySubscriptionID(subscriptionID uint) ([]models.SubscriptionRenewal, error)
+}
+
+type subscriptionRenewalRepository struct {
+	db *gorm.DB
+}
+
+func NewSubscriptionRenewalRepository(db *gorm.DB) SubscriptionRenewalRepository {
+	return &subscriptionRenewalRepository{db: db}
+}
+
+func (r *subscriptionRenewalRepository) FindBySubscriptionID(subscriptionID uint) ([]models.SubscriptionRenewal, error) {
+	var renewals []models.SubscriptionRenewal
+	err := r.db.Where("subscription_id = ?", subscriptionID).
+		Order("renewed_at DESC").
+		Find(&renewals).Error
+	return renewals, err
+}
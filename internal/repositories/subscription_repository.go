@@ -1,20 +1,57 @@
 package repositories
 
 import (
+	"encoding/json"
 	"renew-guard/internal/models"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
+// SubscriptionFilter narrows, orders and pages a subscription listing. Zero
+// values leave a criterion out.
+type SubscriptionFilter struct {
+	OrganizationID      *uint // Only this organization's subscriptions, else all the user can see
+	ExpiresAfter        *time.Time
+	ExpiresBefore       *time.Time
+	Expired             *bool // Lapsed (true) or current (false) subscriptions as of Now
+	NotificationEnabled *bool
+	Search              string // Case-insensitive part of the name
+	Tag                 string
+	MinPriceMinor       *int64
+	MaxPriceMinor       *int64
+	Currency            string
+	Now                 time.Time
+	Sort                string // name, end_date, start_date, price or created_at; defaults to end_date
+	Descending          bool
+	Limit               int
+	Offset              int
+}
+
+// subscriptionSortColumns maps the sort keys of a listing to columns
+var subscriptionSortColumns = map[string]string{
+	"name":       "LOWER(name)",
+	"end_date":   "end_date",
+	"start_date": "start_date",
+	"price":      "price_minor",
+	"created_at": "created_at",
+}
+
 type SubscriptionRepository interface {
 	Create(subscription *models.Subscription) error
+	CreateAll(subscriptions []*models.Subscription) error
 	FindByID(id uint) (*models.Subscription, error)
 	FindByUserID(userID uint) ([]models.Subscription, error)
+	FindByOrganizationID(organizationID uint) ([]models.Subscription, error)
+	FindPage(userID uint, filter SubscriptionFilter) ([]models.Subscription, int64, error)
+	FindEach(userID uint, organizationID *uint, batchSize int, fn func(batch []models.Subscription) error) error
 	Update(subscription *models.Subscription) error
 	Delete(id uint) error
-	FindExpiringSubscriptions(daysBefore int) ([]models.Subscription, error)
+	FindExpiringSubscriptions(now time.Time, daysBefore int) ([]models.Subscription, error)
 	UpdateLastNotificationSent(id uint, sentAt time.Time) error
+	FindDueForRenewal(now time.Time) ([]models.Subscription, error)
+	Renew(subscription *models.Subscription, renewals []models.SubscriptionRenewal) error
 }
 
 type subscriptionRepository struct {
@@ -29,21 +66,131 @@ func (r *subscriptionRepository) Create(subscription *models.Subscription) error
 	return r.db.Create(subscription).Error
 }
 
+// CreateAll creates the subscriptions in one transaction: either all of
+// them are saved or none is
+func (r *subscriptionRepository) CreateAll(subscriptions []*models.Subscription) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, subscription := range subscriptions {
+			if err := tx.Create(subscription).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *subscriptionRepository) FindByID(id uint) (*models.Subscription, error) {
 	var subscription models.Subscription
-	err := r.db.Preload("User").First(&subscription, id).Error
+	err := r.db.Preload("User").Preload("Reminders").First(&subscription, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &subscription, nil
 }
 
+// FindByUserID returns every subscription the user can see: their personal
+// ones and those shared with organizations they belong to
 func (r *subscriptionRepository) FindByUserID(userID uint) ([]models.Subscription, error) {
 	var subscriptions []models.Subscription
-	err := r.db.Where("user_id = ?", userID).Order("end_date ASC").Find(&subscriptions).Error
+	err := r.db.Scopes(r.accessibleBy(userID)).Order("end_date ASC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *subscriptionRepository) FindByOrganizationID(organizationID uint) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := r.db.Where("organization_id = ?", organizationID).Order("end_date ASC").Find(&subscriptions).Error
 	return subscriptions, err
 }
 
+// FindPage returns one page of the subscriptions the user can see that match
+// filter, along with the total number of matches
+func (r *subscriptionRepository) FindPage(userID uint, filter SubscriptionFilter) ([]models.Subscription, int64, error) {
+	query := r.db.Model(&models.Subscription{})
+	if filter.OrganizationID != nil {
+		query = query.Where("organization_id = ?", *filter.OrganizationID)
+	} else {
+		query = query.Scopes(r.accessibleBy(userID))
+	}
+
+	if filter.ExpiresAfter != nil {
+		query = query.Where("end_date >= ?", *filter.ExpiresAfter)
+	}
+	if filter.ExpiresBefore != nil {
+		query = query.Where("end_date < ?", *filter.ExpiresBefore)
+	}
+	if filter.Expired != nil {
+		if *filter.Expired {
+			query = query.Where("end_date < ?", filter.Now)
+		} else {
+			query = query.Where("end_date >= ?", filter.Now)
+		}
+	}
+	if filter.NotificationEnabled != nil {
+		query = query.Where("notification_enabled = ?", *filter.NotificationEnabled)
+	}
+	if filter.Search != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(filter.Search)+"%")
+	}
+	if filter.Tag != "" {
+		tag, err := json.Marshal([]string{filter.Tag})
+		if err != nil {
+			return nil, 0, err
+		}
+		query = query.Where("tags @> ?::jsonb", string(tag))
+	}
+	if filter.MinPriceMinor != nil {
+		query = query.Where("price_minor >= ?", *filter.MinPriceMinor)
+	}
+	if filter.MaxPriceMinor != nil {
+		query = query.Where("price_minor <= ?", *filter.MaxPriceMinor)
+	}
+	if filter.Currency != "" {
+		query = query.Where("currency = ?", filter.Currency)
+	}
+
+	// Share the conditions between the count and the page query
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column, ok := subscriptionSortColumns[filter.Sort]
+	if !ok {
+		column = subscriptionSortColumns["end_date"]
+	}
+	direction := "ASC"
+	if filter.Descending {
+		direction = "DESC"
+	}
+
+	var subscriptions []models.Subscription
+	err := query.Order(column + " " + direction).
+		Order("id " + direction).
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&subscriptions).Error
+	return subscriptions, total, err
+}
+
+// FindEach passes the subscriptions the user can see, or only those of
+// organizationID when given, to fn batchSize at a time in ID order with
+// their reminders loaded, so callers never hold the whole set in memory
+func (r *subscriptionRepository) FindEach(userID uint, organizationID *uint, batchSize int, fn func(batch []models.Subscription) error) error {
+	query := r.db.Preload("Reminders")
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	} else {
+		query = query.Scopes(r.accessibleBy(userID))
+	}
+
+	var batch []models.Subscription
+	return query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
 func (r *subscriptionRepository) Update(subscription *models.Subscription) error {
 	return r.db.Save(subscription).Error
 }
@@ -52,13 +199,14 @@ func (r *subscriptionRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Subscription{}, id).Error
 }
 
-func (r *subscriptionRepository) FindExpiringSubscriptions(daysBefore int) ([]models.Subscription, error) {
+func (r *subscriptionRepository) FindExpiringSubscriptions(now time.Time, daysBefore int) ([]models.Subscription, error) {
 	var subscriptions []models.Subscription
-	
-	now := time.Now()
-	targetDate := now.AddDate(0, 0, daysBefore)
 
-	err := r.db.Preload("User").
+	// One extra day covers users whose local date is ahead of UTC; callers
+	// apply the exact per-timezone cut-off
+	targetDate := now.AddDate(0, 0, daysBefore+1)
+
+	err := r.db.Preload("User").Preload("Reminders").
 		Where("notification_enabled = ?", true).
 		Where("end_date >= ?", now).
 		Where("end_date <= ?", targetDate).
@@ -72,3 +220,72 @@ func (r *subscriptionRepository) UpdateLastNotificationSent(id uint, sentAt time
 		Where("id = ?", id).
 		Update("last_notification_sent", sentAt).Error
 }
+
+func (r *subscriptionRepository) FindDueForRenewal(now time.Time) ([]models.Subscription, error) {
+	var subscriptions []models.Subscription
+	err := r.db.Where("auto_renew = ?", true).
+		Where("end_date <= ?", now).
+		Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// Renew moves the subscription into the period it was advanced to and records
+// the renewal history, in one transaction. Only the period columns are
+// written, so edits made since the subscription was loaded are kept. The
+// update applies only while the subscription still ends where the first
+// renewal began; if another run renewed it first, or its dates were changed
+// meanwhile, nothing is written and gorm.ErrRecordNotFound is returned.
+func (r *subscriptionRepository) Renew(subscription *models.Subscription, renewals []models.SubscriptionRenewal) error {
+	if len(renewals) == 0 {
+		return nil
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Subscription{}).
+			Where("id = ? AND end_date = ?", subscription.ID, renewals[0].PreviousEndDate).
+			Updates(map[string]interface{}{
+				"start_date":             subscription.StartDate,
+				"end_date":               subscription.EndDate,
+				"duration_days":          subscription.DurationDays,
+				"last_notification_sent": subscription.LastNotificationSent,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 1 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(&renewals).Error
+	})
+}
+
+// accessibleBy limits a query to the user's personal subscriptions and the
+// shared subscriptions of their organizations. Shared subscriptions keep the
+// creator's user_id, so a member who leaves loses access to them.
+func (r *subscriptionRepository) accessibleBy(userID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		organizationIDs := r.db.Model(&models.OrganizationMember{}).Select("organization_id").Where("user_id = ?", userID)
+		return db.Where("(organization_id IS NULL AND user_id = ?) OR organization_id IN (?)", userID, organizationIDs)
+	}
+}
+
+// deleteSubscriptionHistory removes the rows that hang off the subscriptions
+// selected by subscriptionIDs, ahead of deleting the subscriptions themselves
+func deleteSubscriptionHistory(tx *gorm.DB, subscriptionIDs *gorm.DB) error {
+	for _, model := range []interface{}{
+		&models.NotificationLog{},
+		&models.OutboxMessage{},
+		&models.SubscriptionReminder{},
+		&models.SubscriptionRenewal{},
+	} {
+		if err := tx.Where("subscription_id IN (?)", subscriptionIDs).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,170 @@
+package repositories
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// capturedQuery is a statement built by a dry-run session
+type capturedQuery struct {
+	sql  string
+	vars []interface{}
+}
+
+// newDryRunDB builds Postgres statements without a database and records the
+// ones against the subscriptions table, leaving out subqueries
+func newDryRunDB(t *testing.T) (*gorm.DB, *[]capturedQuery) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{
+		DryRun:               true,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var queries []capturedQuery
+	err = db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		if tx.Statement.Table == "subscriptions" {
+			queries = append(queries, capturedQuery{sql: tx.Statement.SQL.String(), vars: tx.Statement.Vars})
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, &queries
+}
+
+func TestFindPageFilters(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	after := now.AddDate(0, 0, -7)
+	organizationID := uint(10)
+	expired, enabled := true, false
+	minPrice, maxPrice := int64(100), int64(2000)
+
+	tests := []struct {
+		name     string
+		filter   SubscriptionFilter
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			name:     "own and organization subscriptions by default",
+			filter:   SubscriptionFilter{},
+			wantSQL:  `WHERE (organization_id IS NULL AND user_id = $1) OR organization_id IN (SELECT "organization_id" FROM "organization_members" WHERE user_id = $2)`,
+			wantVars: []interface{}{uint(1), uint(1)},
+		},
+		{
+			name:     "other conditions do not widen access",
+			filter:   SubscriptionFilter{Currency: "EUR"},
+			wantSQL:  `WHERE currency = $1 AND ((organization_id IS NULL AND user_id = $2) OR organization_id IN (SELECT "organization_id" FROM "organization_members" WHERE user_id = $3))`,
+			wantVars: []interface{}{"EUR", uint(1), uint(1)},
+		},
+		{
+			name:     "one organization",
+			filter:   SubscriptionFilter{OrganizationID: &organizationID},
+			wantSQL:  `WHERE organization_id = $1`,
+			wantVars: []interface{}{organizationID},
+		},
+		{
+			name:     "expiry window",
+			filter:   SubscriptionFilter{OrganizationID: &organizationID, ExpiresAfter: &after, ExpiresBefore: &now},
+			wantSQL:  `WHERE organization_id = $1 AND end_date >= $2 AND end_date < $3`,
+			wantVars: []interface{}{organizationID, after, now},
+		},
+		{
+			name:     "lapsed and muted",
+			filter:   SubscriptionFilter{OrganizationID: &organizationID, Expired: &expired, NotificationEnabled: &enabled, Now: now},
+			wantSQL:  `WHERE organization_id = $1 AND end_date < $2 AND notification_enabled = $3`,
+			wantVars: []interface{}{organizationID, now, false},
+		},
+		{
+			name:     "search escapes wildcards",
+			filter:   SubscriptionFilter{OrganizationID: &organizationID, Search: `50%_off\`},
+			wantSQL:  `WHERE organization_id = $1 AND name ILIKE $2`,
+			wantVars: []interface{}{organizationID, `%50\%\_off\\%`},
+		},
+		{
+			name:     "tag containment",
+			filter:   SubscriptionFilter{OrganizationID: &organizationID, Tag: `work "team"`},
+			wantSQL:  `WHERE organization_id = $1 AND tags @> $2::jsonb`,
+			wantVars: []interface{}{organizationID, `["work \"team\""]`},
+		},
+		{
+			name:     "price range and currency",
+			filter:   SubscriptionFilter{OrganizationID: &organizationID, MinPriceMinor: &minPrice, MaxPriceMinor: &maxPrice, Currency: "EUR"},
+			wantSQL:  `WHERE organization_id = $1 AND price_minor >= $2 AND price_minor <= $3 AND currency = $4`,
+			wantVars: []interface{}{organizationID, minPrice, maxPrice, "EUR"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, queries := newDryRunDB(t)
+			if _, _, err := NewSubscriptionRepository(db).FindPage(1, tt.filter); err != nil {
+				t.Fatal(err)
+			}
+			if len(*queries) != 2 {
+				t.Fatalf("ran %d queries, want a count and a page", len(*queries))
+			}
+
+			// The count and the page share the same conditions
+			for _, query := range *queries {
+				if !strings.Contains(query.sql, tt.wantSQL) {
+					t.Errorf("query %s\nwant conditions %s", query.sql, tt.wantSQL)
+				}
+			}
+			if count := (*queries)[0]; !reflect.DeepEqual(count.vars, tt.wantVars) {
+				t.Errorf("count vars = %#v, want %#v", count.vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestFindPageOrdersAndPages(t *testing.T) {
+	tests := []struct {
+		name       string
+		sort       string
+		descending bool
+		want       string
+	}{
+		{"default", "", false, "ORDER BY end_date ASC,id ASC"},
+		{"name ignores case", "name", false, "ORDER BY LOWER(name) ASC,id ASC"},
+		{"price descending", "price", true, "ORDER BY price_minor DESC,id DESC"},
+		{"unknown key", "user_id; DROP TABLE users", false, "ORDER BY end_date ASC,id ASC"},
+	}
+	for _, tt := range tests {
+		db, queries := newDryRunDB(t)
+		filter := SubscriptionFilter{Sort: tt.sort, Descending: tt.descending, Limit: 20, Offset: 40}
+		if _, _, err := NewSubscriptionRepository(db).FindPage(1, filter); err != nil {
+			t.Fatal(err)
+		}
+
+		page := (*queries)[len(*queries)-1].sql
+		if !strings.HasSuffix(page, tt.want+" LIMIT 20 OFFSET 40") {
+			t.Errorf("%s: page query %s\nwant it to end with %s LIMIT 20 OFFSET 40", tt.name, page, tt.want)
+		}
+		if count := (*queries)[0].sql; strings.Contains(count, "ORDER BY") || strings.Contains(count, "LIMIT") {
+			t.Errorf("%s: count query is ordered or paged: %s", tt.name, count)
+		}
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"netflix":   "netflix",
+		"100%":      `100\%`,
+		"a_b":       `a\_b`,
+		`C:\backup`: `C:\\backup`,
+		`\%_`:       `\\\%\_`,
+	}
+	for in, want := range tests {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserPreferenceRepository interface {
+	FindByUserID(userID uint) (*models.UserPreference, error)
+	FindByUserIDs(userIDs []uint) ([]models.UserPreference, error)
+	Save(preference *models.UserPreference) error
+	MaxDefaultDaysBefore() (int, error)
+	UpdateLastDigestSent(userID uint, sentAt time.Time) error
+}
+
+type userPreferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewUserPreferenceRepository(db *gorm.DB) UserPreferenceRepository {
+	return &userPreferenceRepository{db: db}
+}
+
+func (r *userPreferenceRepository) FindByUserID(userID uint) (*models.UserPreference, error) {
+	var preference models.UserPreference
+	err := r.db.Where("user_id = ?", userID).First(&preference).Error
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+func (r *userPreferenceRepository) FindByUserIDs(userIDs []uint) ([]models.UserPreference, error) {
+	var preferences []models.UserPreference
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+	err := r.db.Where("user_id IN ?", userIDs).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *userPreferenceRepository) Save(preference *models.UserPreference) error {
+	return r.db.Save(preference).Error
+}
+
+// MaxDefaultDaysBefore returns the largest per-user lead time, or 0 if none exist
+func (r *userPreferenceRepository) MaxDefaultDaysBefore() (int, error) {
+	var maxDays int
+	err := r.db.Model(&models.UserPreference{}).
+		Select("COALESCE(MAX(default_days_before), 0)").
+		Scan(&maxDays).Error
+	return maxDays, err
+}
+
+func (r *userPreferenceRepository) UpdateLastDigestSent(userID uint, sentAt time.Time) error {
+	return r.db.Model(&models.UserPreference{}).
+		Where("user_id = ?", userID).
+		Update("last_digest_sent_at", sentAt).Error
+}
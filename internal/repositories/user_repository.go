@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"renew-guard/internal/models"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -10,6 +12,19 @@ type UserRepository interface {
 	Create(user *models.User) error
 	FindByEmail(email string) (*models.User, error)
 	FindByID(id uint) (*models.User, error)
+	FindAll() ([]models.User, error)
+	IncrementTokenVersion(id uint) error
+	UpdatePassword(id uint, passwordHash string) error
+	MarkEmailVerified(id uint, verifiedAt time.Time) error
+	ChangeEmail(id uint, oldEmail, newEmail string) error
+	SetTOTPSecret(id uint, secret string) error
+	EnableTOTP(id uint, step int64, enabledAt time.Time) error
+	DisableTOTP(id uint) error
+	AcceptTOTPStep(id uint, step int64) error
+	UpdateRole(id uint, role string) error
+	SetDisabled(id uint, disabledAt *time.Time) error
+	PromoteByEmails(emails []string) (int64, error)
+	Delete(user *models.User) error
 }
 
 type userRepository struct {
@@ -41,3 +56,225 @@ func (r *userRepository) FindByID(id uint) (*models.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *userRepository) FindAll() ([]models.User, error) {
+	var users []models.User
+	err := r.db.Order("id ASC").Find(&users).Error
+	return users, err
+}
+
+// IncrementTokenVersion invalidates every access token issued to the user so far
+func (r *userRepository) IncrementTokenVersion(id uint) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Update("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// UpdatePassword stores a new password hash and invalidates existing access tokens
+func (r *userRepository) UpdatePassword(id uint, passwordHash string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password_hash": passwordHash,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error
+}
+
+func (r *userRepository) MarkEmailVerified(id uint, verifiedAt time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Update("email_verified_at", verifiedAt).Error
+}
+
+// ChangeEmail sets a new, unverified address and moves subscriptions that
+// were mailing the old address over to it. The token version is bumped so
+// access tokens carrying the old address are refreshed.
+func (r *userRepository) ChangeEmail(id uint, oldEmail, newEmail string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{
+				"email":             newEmail,
+				"email_verified_at": nil,
+				"token_version":     gorm.Expr("token_version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Subscription{}).
+			Where("user_id = ? AND LOWER(email) = LOWER(?)", id, oldEmail).
+			Update("email", newEmail).Error
+	})
+}
+
+// SetTOTPSecret stores a pending secret for an enrollment that has not been confirmed yet
+func (r *userRepository) SetTOTPSecret(id uint, secret string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ? AND totp_enabled_at IS NULL", id).
+		Update("totp_secret", secret).Error
+}
+
+// EnableTOTP activates the pending secret, recording the step of the
+// confirmation code so it cannot be reused to log in
+func (r *userRepository) EnableTOTP(id uint, step int64, enabledAt time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_enabled_at": enabledAt,
+			"totp_last_step":  step,
+		}).Error
+}
+
+func (r *userRepository) DisableTOTP(id uint) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"totp_secret":     "",
+			"totp_enabled_at": nil,
+			"totp_last_step":  0,
+		}).Error
+}
+
+// AcceptTOTPStep records step as used. It fails with gorm.ErrRecordNotFound
+// if that step or a later one was already accepted, which stops a code from
+// being replayed.
+func (r *userRepository) AcceptTOTPStep(id uint, step int64) error {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", id, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateRole changes the user's role and invalidates access tokens, which
+// carry the role, so clients pick up the change on their next refresh
+func (r *userRepository) UpdateRole(id uint, role string) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"role":          role,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error
+}
+
+// SetDisabled disables the account when disabledAt is set, or re-enables it
+// when nil. Access tokens are invalidated either way.
+func (r *userRepository) SetDisabled(id uint, disabledAt *time.Time) error {
+	return r.db.Model(&models.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"disabled_at":   disabledAt,
+			"token_version": gorm.Expr("token_version + 1"),
+		}).Error
+}
+
+// PromoteByEmails makes the users with the given addresses admins and
+// returns how many were changed
+func (r *userRepository) PromoteByEmails(emails []string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	lowered := make([]string, 0, len(emails))
+	for _, email := range emails {
+		lowered = append(lowered, strings.ToLower(email))
+	}
+
+	result := r.db.Model(&models.User{}).
+		Where("LOWER(email) IN ? AND role <> ?", lowered, models.RoleAdmin).
+		Updates(map[string]interface{}{
+			"role":          models.RoleAdmin,
+			"token_version": gorm.Expr("token_version + 1"),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Delete removes the user together with their subscriptions and everything
+// hanging off them, and with any organization they were the last member of.
+// Rows are deleted explicitly so this does not depend on ON DELETE CASCADE
+// being present in the schema.
+func (r *userRepository) Delete(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Organizations the user is the last member of go away with them
+		var soleOrganizationIDs []uint
+		memberships := tx.Model(&models.OrganizationMember{}).Select("organization_id").Where("user_id = ?", user.ID)
+		if err := tx.Model(&models.OrganizationMember{}).
+			Where("organization_id IN (?)", memberships).
+			Group("organization_id").
+			Having("COUNT(*) = 1").
+			Pluck("organization_id", &soleOrganizationIDs).Error; err != nil {
+			return err
+		}
+
+		// Shared subscriptions the user created stay with their organization
+		// and pass to one of its owners
+		newOwner := tx.Model(&models.OrganizationMember{}).
+			Select("user_id").
+			Where("organization_id = subscriptions.organization_id AND role = ? AND user_id <> ?", models.OrganizationRoleOwner, user.ID).
+			Order("created_at ASC").
+			Limit(1)
+		if err := tx.Model(&models.Subscription{}).
+			Where("user_id = ? AND organization_id IS NOT NULL AND EXISTS (?)", user.ID, newOwner).
+			Update("user_id", newOwner).Error; err != nil {
+			return err
+		}
+
+		subscriptions := tx.Model(&models.Subscription{}).Where("user_id = ?", user.ID)
+		if len(soleOrganizationIDs) > 0 {
+			subscriptions = subscriptions.Or("organization_id IN ?", soleOrganizationIDs)
+		}
+		if err := deleteSubscriptionHistory(tx, subscriptions.Select("id")); err != nil {
+			return err
+		}
+
+		// Pending digests are not tied to a single subscription
+		if err := tx.Where("subscription_id IS NULL AND recipient = ?", user.Email).
+			Delete(&models.OutboxMessage{}).Error; err != nil {
+			return err
+		}
+
+		if len(soleOrganizationIDs) > 0 {
+			if err := tx.Where("organization_id IN ?", soleOrganizationIDs).Delete(&models.Subscription{}).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, model := range []interface{}{
+			&models.Subscription{},
+			&models.UserPreference{},
+			&models.RefreshToken{},
+			&models.UserToken{},
+			&models.APIKey{},
+			&models.RecoveryCode{},
+			&models.CalendarFeed{},
+			&models.OrganizationMember{},
+		} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// Invitations the user sent stay valid without them
+		if err := tx.Model(&models.OrganizationInvitation{}).
+			Where("invited_by_id = ?", user.ID).
+			Update("invited_by_id", nil).Error; err != nil {
+			return err
+		}
+
+		if len(soleOrganizationIDs) > 0 {
+			if err := tx.Where("organization_id IN ?", soleOrganizationIDs).Delete(&models.OrganizationInvitation{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&models.Organization{}, soleOrganizationIDs).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(user).Error
+	})
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"renew-guard/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UserTokenRepository interface {
+	Create(token *models.UserToken) error
+	FindByHash(purpose, tokenHash string) (*models.UserToken, error)
+	MarkUsed(token *models.UserToken, now time.Time) error
+	InvalidateForUser(userID uint, purpose string, now time.Time) error
+}
+
+type userTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) UserTokenRepository {
+	return &userTokenRepository{db: db}
+}
+
+func (r *userTokenRepository) Create(token *models.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *userTokenRepository) FindByHash(purpose, tokenHash string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.Where("purpose = ? AND token_hash = ?", purpose, tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consumes the token. It fails with gorm.ErrRecordNotFound if the
+// token was already used, so a token cannot be redeemed twice concurrently.
+func (r *userTokenRepository) MarkUsed(token *models.UserToken, now time.Time) error {
+	result := r.db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", now)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	token.UsedAt = &now
+	return nil
+}
+
+// InvalidateForUser consumes every outstanding token of the given purpose
+func (r *userTokenRepository) InvalidateForUser(userID uint, purpose string, now time.Time) error {
+	return r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
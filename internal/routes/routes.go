@@ -4,7 +4,9 @@ import (
 	"net/http"
 	"renew-guard/internal/controllers"
 	"renew-guard/internal/middleware"
+	"renew-guard/internal/models"
 	"renew-guard/pkg/jwt"
+	"renew-guard/pkg/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,20 +15,53 @@ type Router struct {
 	authController         *controllers.AuthController
 	subscriptionController *controllers.SubscriptionController
 	emailTestController    *controllers.EmailTestController
+	preferenceController   *controllers.PreferenceController
+	accountController      *controllers.AccountController
+	apiKeyController       *controllers.APIKeyController
+	twoFactorController    *controllers.TwoFactorController
+	adminController        *controllers.AdminController
+	organizationController *controllers.OrganizationController
+	calendarController     *controllers.CalendarController
 	jwtUtil                *jwt.JWTUtil
+	sessionValidator       middleware.SessionValidator
+	apiKeyValidator        middleware.APIKeyValidator
+	passwordResetLimiter   *ratelimit.Limiter
+	loginFailureLimiter    *ratelimit.Limiter
 }
 
 func NewRouter(
 	authController *controllers.AuthController,
 	subscriptionController *controllers.SubscriptionController,
 	emailTestController *controllers.EmailTestController,
+	preferenceController *controllers.PreferenceController,
+	accountController *controllers.AccountController,
+	apiKeyController *controllers.APIKeyController,
+	twoFactorController *controllers.TwoFactorController,
+	adminController *controllers.AdminController,
+	organizationController *controllers.OrganizationController,
+	calendarController *controllers.CalendarController,
 	jwtUtil *jwt.JWTUtil,
+	sessionValidator middleware.SessionValidator,
+	apiKeyValidator middleware.APIKeyValidator,
+	passwordResetLimiter *ratelimit.Limiter,
+	loginFailureLimiter *ratelimit.Limiter,
 ) *Router {
 	return &Router{
 		authController:         authController,
 		subscriptionController: subscriptionController,
 		emailTestController:    emailTestController,
+		preferenceController:   preferenceController,
+		accountController:      accountController,
+		apiKeyController:       apiKeyController,
+		twoFactorController:    twoFactorController,
+		adminController:        adminController,
+		organizationController: organizationController,
+		calendarController:     calendarController,
 		jwtUtil:                jwtUtil,
+		sessionValidator:       sessionValidator,
+		apiKeyValidator:        apiKeyValidator,
+		passwordResetLimiter:   passwordResetLimiter,
+		loginFailureLimiter:    loginFailureLimiter,
 	}
 }
 
@@ -39,11 +74,21 @@ func (r *Router) SetupRoutes(router *gin.Engine) {
 	// Health check endpoint
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
-			"status": "healthy",
+			"status":  "healthy",
 			"service": "renew-guard",
 		})
 	})
 
+	// Public keys for services that verify our access tokens
+	router.GET("/.well-known/jwks.json", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, r.jwtUtil.JWKS())
+	})
+
+	requireAuth := middleware.AuthMiddleware(r.jwtUtil, r.sessionValidator, r.apiKeyValidator)
+	requireSession := middleware.RequireSession()
+	preferenceScopes := middleware.RequireScopes(models.APIKeyScopePreferencesRead, models.APIKeyScopePreferencesWrite)
+
 	// API v1 routes
 	api := router.Group("/api/v1")
 	{
@@ -51,19 +96,95 @@ func (r *Router) SetupRoutes(router *gin.Engine) {
 		auth := api.Group("/auth")
 		{
 			auth.POST("/register", r.authController.Register)
-			auth.POST("/login", r.authController.Login)
+			auth.POST("/login", middleware.FailedAttemptLimitMiddleware(r.loginFailureLimiter), r.authController.Login)
+			auth.POST("/login/2fa", middleware.FailedAttemptLimitMiddleware(r.loginFailureLimiter), r.authController.LoginTwoFactor)
+			auth.POST("/refresh", r.authController.Refresh)
+			auth.POST("/logout", requireAuth, requireSession, r.authController.Logout)
+			auth.GET("/verify", r.authController.VerifyEmail)
+			auth.POST("/verify/resend", requireAuth, requireSession, r.authController.ResendVerification)
+			auth.POST("/forgot-password", middleware.RateLimitMiddleware(r.passwordResetLimiter), r.authController.ForgotPassword)
+			auth.POST("/reset-password", middleware.RateLimitMiddleware(r.passwordResetLimiter), r.authController.ResetPassword)
 		}
 
 		// Subscription routes (protected)
 		subscriptions := api.Group("/subscriptions")
-		subscriptions.Use(middleware.AuthMiddleware(r.jwtUtil))
+		subscriptions.Use(requireAuth)
+		subscriptions.Use(middleware.RequireScopes(models.APIKeyScopeSubscriptionsRead, models.APIKeyScopeSubscriptionsWrite))
 		{
 			subscriptions.POST("", r.subscriptionController.CreateSubscription)
 			subscriptions.GET("", r.subscriptionController.GetSubscriptions)
+			subscriptions.GET("/summary", r.subscriptionController.GetSpendSummary)
+			subscriptions.POST("/import", r.subscriptionController.ImportSubscriptions)
+			subscriptions.GET("/export", r.subscriptionController.ExportSubscriptions)
 			subscriptions.GET("/:id", r.subscriptionController.GetSubscription)
 			subscriptions.PUT("/:id", r.subscriptionController.UpdateSubscription)
 			subscriptions.DELETE("/:id", r.subscriptionController.DeleteSubscription)
 			subscriptions.PATCH("/:id/notifications", r.subscriptionController.ToggleNotification)
+			subscriptions.GET("/:id/renewals", r.subscriptionController.GetRenewals)
+			subscriptions.GET("/:id/reminders", r.subscriptionController.GetReminders)
+			subscriptions.PUT("/:id/reminders", r.subscriptionController.SetReminders)
+			subscriptions.GET("/:id/logs", r.subscriptionController.GetNotificationLogs)
+		}
+
+		// Current user routes (protected)
+		me := api.Group("/me")
+		me.Use(requireAuth)
+		{
+			me.GET("", requireSession, r.accountController.GetProfile)
+			me.PATCH("", requireSession, r.accountController.UpdateProfile)
+			me.DELETE("", requireSession, r.accountController.DeleteAccount)
+			me.POST("/password", requireSession, r.accountController.ChangePassword)
+			me.GET("/preferences", preferenceScopes, r.preferenceController.GetPreferences)
+			me.PUT("/preferences", preferenceScopes, r.preferenceController.UpdatePreferences)
+			me.GET("/api-keys", requireSession, r.apiKeyController.ListAPIKeys)
+			me.POST("/api-keys", requireSession, r.apiKeyController.CreateAPIKey)
+			me.DELETE("/api-keys/:id", requireSession, r.apiKeyController.RevokeAPIKey)
+			me.GET("/2fa", requireSession, r.twoFactorController.GetStatus)
+			me.POST("/2fa/setup", requireSession, r.twoFactorController.Setup)
+			me.POST("/2fa/confirm", requireSession, r.twoFactorController.Confirm)
+			me.DELETE("/2fa", requireSession, r.twoFactorController.Disable)
+			me.POST("/2fa/recovery-codes", requireSession, r.twoFactorController.RegenerateRecoveryCodes)
+			me.GET("/calendar", requireSession, r.calendarController.GetCalendarFeed)
+			me.POST("/calendar", requireSession, r.calendarController.RotateCalendarFeed)
+			me.DELETE("/calendar", requireSession, r.calendarController.DeleteCalendarFeed)
+		}
+
+		// Calendar feeds (public, the secret token in the file name authenticates)
+		api.GET("/calendar/:file", r.calendarController.GetCalendar)
+
+		// Organization routes (protected, interactive sessions only)
+		organizations := api.Group("/organizations")
+		organizations.Use(requireAuth, requireSession)
+		{
+			organizations.GET("", r.organizationController.ListOrganizations)
+			organizations.POST("", r.organizationController.CreateOrganization)
+			organizations.GET("/invitations", r.organizationController.ListMyInvitations)
+			organizations.POST("/invitations/:invitationId/accept", r.organizationController.AcceptInvitation)
+			organizations.DELETE("/invitations/:invitationId", r.organizationController.DeclineInvitation)
+			organizations.GET("/:id", r.organizationController.GetOrganization)
+			organizations.PUT("/:id", r.organizationController.UpdateOrganization)
+			organizations.DELETE("/:id", r.organizationController.DeleteOrganization)
+			organizations.GET("/:id/members", r.organizationController.ListMembers)
+			organizations.PUT("/:id/members/:userId", r.organizationController.UpdateMember)
+			organizations.DELETE("/:id/members/:userId", r.organizationController.RemoveMember)
+			organizations.GET("/:id/invitations", r.organizationController.ListInvitations)
+			organizations.POST("/:id/invitations", r.organizationController.InviteMember)
+			organizations.DELETE("/:id/invitations/:invitationId", r.organizationController.RevokeInvitation)
+		}
+
+		// Admin routes (interactive admin sessions only)
+		admin := api.Group("/admin")
+		admin.Use(requireAuth, requireSession, middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/users", r.adminController.ListUsers)
+			admin.GET("/users/:id", r.adminController.GetUser)
+			admin.POST("/users/:id/disable", r.adminController.DisableUser)
+			admin.POST("/users/:id/enable", r.adminController.EnableUser)
+			admin.PUT("/users/:id/role", r.adminController.UpdateRole)
+			admin.GET("/users/:id/subscriptions", r.adminController.GetUserSubscriptions)
+			admin.GET("/subscriptions/:id", r.adminController.GetSubscription)
+			admin.GET("/subscriptions/:id/logs", r.adminController.GetNotificationLogs)
+			admin.POST("/jobs/:job/run", r.adminController.RunJob)
 		}
 
 		// Email test routes (public - for testing SMTP)
@@ -1,27 +1,64 @@
 package scheduler
 
 import (
+	"errors"
 	"log"
 	"renew-guard/internal/config"
 	"renew-guard/internal/services"
+	"renew-guard/pkg/clock"
+	"sync"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
+// ErrJobRunning is returned when a job is started while it is still running
+var ErrJobRunning = errors.New("job is already running")
+
 type Scheduler struct {
 	cron                *cron.Cron
 	notificationService services.NotificationService
+	renewalService      services.RenewalService
+	outboxService       services.OutboxService
 	config              *config.SchedulerConfig
+	outboxConfig        *config.OutboxConfig
+	clock               clock.Clock
+
+	// Held while the matching job runs
+	notificationsMu sync.Mutex
+	renewalsMu      sync.Mutex
+	digestsMu       sync.Mutex
+	outboxMu        sync.Mutex
 }
 
-func NewScheduler(notificationService services.NotificationService, cfg *config.SchedulerConfig) *Scheduler {
+func NewScheduler(
+	notificationService services.NotificationService,
+	renewalService services.RenewalService,
+	outboxService services.OutboxService,
+	cfg *config.SchedulerConfig,
+	outboxCfg *config.OutboxConfig,
+	clk clock.Clock,
+) *Scheduler {
+	loc, err := time.LoadLocation(cfg.Timezone)
+	if err != nil {
+		log.Printf("Invalid scheduler timezone %q, using UTC: %v", cfg.Timezone, err)
+		loc = time.UTC
+	}
+
 	// Create cron with seconds support and logging
-	c := cron.New(cron.WithLogger(cron.VerbosePrintfLogger(log.Default())))
+	c := cron.New(
+		cron.WithLogger(cron.VerbosePrintfLogger(log.Default())),
+		cron.WithLocation(loc),
+	)
 
 	return &Scheduler{
 		cron:                c,
 		notificationService: notificationService,
+		renewalService:      renewalService,
+		outboxService:       outboxService,
 		config:              cfg,
+		outboxConfig:        outboxCfg,
+		clock:               clk,
 	}
 }
 
@@ -36,10 +73,40 @@ func (s *Scheduler) Start() error {
 
 	// Add notification check job
 	_, err := s.cron.AddFunc(s.config.CronExpression, func() {
-		log.Println("Running scheduled notification check...")
-		if err := s.notificationService.CheckAndSendNotifications(s.config.NotificationDaysBefore); err != nil {
-			log.Printf("Error running notification check: %v", err)
-		}
+		log.Printf("Running scheduled notification check at %s...", s.clock.Now().Format(time.RFC3339))
+		logJobError("notification check", s.checkNotifications())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Add auto-renewal rollover job
+	log.Printf("Scheduling renewal rollover with cron expression: %s", s.config.RenewalCronExpression)
+	_, err = s.cron.AddFunc(s.config.RenewalCronExpression, func() {
+		log.Printf("Running scheduled renewal rollover at %s...", s.clock.Now().Format(time.RFC3339))
+		logJobError("renewal rollover", s.processRenewals())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Add digest job for users who prefer one grouped reminder
+	log.Printf("Scheduling notification digests with cron expression: %s", s.config.DigestCronExpression)
+	_, err = s.cron.AddFunc(s.config.DigestCronExpression, func() {
+		log.Printf("Running scheduled notification digest at %s...", s.clock.Now().Format(time.RFC3339))
+		logJobError("notification digest", s.sendDigests())
+	})
+
+	if err != nil {
+		return err
+	}
+
+	// Add outbox delivery job; a tick is skipped if the previous run is still sending
+	log.Printf("Scheduling outbox delivery with cron expression: %s", s.outboxConfig.CronExpression)
+	_, err = s.cron.AddFunc(s.outboxConfig.CronExpression, func() {
+		logJobError("notification outbox", s.processOutbox())
 	})
 
 	if err != nil {
@@ -59,8 +126,68 @@ func (s *Scheduler) Stop() {
 	log.Println("Scheduler stopped")
 }
 
-// RunNow triggers the notification check immediately (useful for testing)
+// RunNow triggers the notification check immediately (useful for testing).
+// It fails with ErrJobRunning while the check is already running.
 func (s *Scheduler) RunNow() error {
-	log.Println("Running notification check manually...")
-	return s.notificationService.CheckAndSendNotifications(s.config.NotificationDaysBefore)
+	log.Printf("Running notification check manually at %s...", s.clock.Now().Format(time.RFC3339))
+	return s.checkNotifications()
+}
+
+// RunRenewalsNow triggers the renewal rollover immediately (useful for testing)
+func (s *Scheduler) RunRenewalsNow() error {
+	log.Printf("Running renewal rollover manually at %s...", s.clock.Now().Format(time.RFC3339))
+	return s.processRenewals()
+}
+
+// RunDigestsNow triggers the notification digest immediately (useful for testing)
+func (s *Scheduler) RunDigestsNow() error {
+	log.Printf("Running notification digest manually at %s...", s.clock.Now().Format(time.RFC3339))
+	return s.sendDigests()
+}
+
+// RunOutboxNow delivers due outbox messages immediately (useful for testing)
+func (s *Scheduler) RunOutboxNow() error {
+	log.Printf("Processing notification outbox manually at %s...", s.clock.Now().Format(time.RFC3339))
+	return s.processOutbox()
+}
+
+// Each job runs at most once at a time, whether started by cron or on demand
+
+func (s *Scheduler) checkNotifications() error {
+	return exclusive(&s.notificationsMu, func() error {
+		return s.notificationService.CheckAndSendNotifications(s.config.NotificationDaysBefore)
+	})
+}
+
+func (s *Scheduler) processRenewals() error {
+	return exclusive(&s.renewalsMu, s.renewalService.ProcessRenewals)
+}
+
+func (s *Scheduler) sendDigests() error {
+	return exclusive(&s.digestsMu, func() error {
+		return s.notificationService.SendDigests(s.config.NotificationDaysBefore)
+	})
+}
+
+func (s *Scheduler) processOutbox() error {
+	return exclusive(&s.outboxMu, s.outboxService.ProcessOutbox)
+}
+
+// exclusive runs job while holding mu, or fails with ErrJobRunning if
+// another run holds it
+func exclusive(mu *sync.Mutex, job func() error) error {
+	if !mu.TryLock() {
+		return ErrJobRunning
+	}
+	defer mu.Unlock()
+	return job()
+}
+
+// logJobError reports the outcome of a scheduled run
+func logJobError(name string, err error) {
+	if errors.Is(err, ErrJobRunning) {
+		log.Printf("Skipping scheduled %s: previous run still in progress", name)
+	} else if err != nil {
+		log.Printf("Error running %s: %v", name, err)
+	}
 }
@@ -0,0 +1,52 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"renew-guard/internal/config"
+	"renew-guard/internal/services"
+	"renew-guard/pkg/clock"
+)
+
+// blockingOutboxService holds ProcessOutbox until released
+type blockingOutboxService struct {
+	services.OutboxService
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingOutboxService) ProcessOutbox() error {
+	s.started <- struct{}{}
+	<-s.release
+	return nil
+}
+
+func TestRunOutboxNowRefusesOverlappingRuns(t *testing.T) {
+	outbox := &blockingOutboxService{started: make(chan struct{}), release: make(chan struct{})}
+	s := NewScheduler(nil, nil, outbox, &config.SchedulerConfig{Timezone: "UTC"}, &config.OutboxConfig{},
+		clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)))
+
+	done := make(chan error)
+	go func() { done <- s.RunOutboxNow() }()
+	<-outbox.started
+
+	// The scheduled job and a second manual run share the same guard
+	if err := s.RunOutboxNow(); err != ErrJobRunning {
+		t.Errorf("overlapping manual run: got %v, want ErrJobRunning", err)
+	}
+	if err := s.processOutbox(); err != ErrJobRunning {
+		t.Errorf("overlapping scheduled run: got %v, want ErrJobRunning", err)
+	}
+
+	close(outbox.release)
+	if err := <-done; err != nil {
+		t.Fatalf("first run: %v", err)
+	}
+
+	// Once finished the job can run again
+	go func() { <-outbox.started }()
+	if err := s.RunOutboxNow(); err != nil {
+		t.Errorf("run after the first finished: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+	"renew-guard/pkg/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUserNotFound    = errors.New("user not found")
+	ErrInvalidPassword = errors.New("current password is incorrect")
+	ErrEmailUnchanged  = errors.New("new email is the same as the current one")
+)
+
+// AccountService manages the authenticated user's own account
+type AccountService interface {
+	GetProfile(userID uint) (*models.User, error)
+	ChangeEmail(userID uint, password, newEmail string) (*models.User, error)
+	ChangePassword(userID uint, sessionID, currentPassword, newPassword string) error
+	DeleteAccount(userID uint, password string) error
+}
+
+type accountService struct {
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	organizationRepo repositories.OrganizationRepository
+	authService      AuthService
+	emailService     email.EmailService
+	clock            clock.Clock
+}
+
+func NewAccountService(
+	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	organizationRepo repositories.OrganizationRepository,
+	authService AuthService,
+	emailService email.EmailService,
+	clk clock.Clock,
+) AccountService {
+	return &accountService{
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		organizationRepo: organizationRepo,
+		authService:      authService,
+		emailService:     emailService,
+		clock:            clk,
+	}
+}
+
+func (s *accountService) GetProfile(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// ChangeEmail switches the account to a new address. The new address must be
+// verified before reminders are mailed to it, and the old address is told
+// about the change.
+func (s *accountService) ChangeEmail(userID uint, password, newEmail string) (*models.User, error) {
+	user, err := s.authenticate(userID, password)
+	if err != nil {
+		return nil, err
+	}
+
+	newEmail = strings.TrimSpace(newEmail)
+	if !utils.IsValidEmail(newEmail) {
+		return nil, ErrInvalidEmail
+	}
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrEmailUnchanged
+	}
+
+	existingUser, err := s.userRepo.FindByEmail(newEmail)
+	if err == nil && existingUser != nil {
+		return nil, ErrEmailAlreadyExists
+	}
+
+	oldEmail := user.Email
+	if err := s.userRepo.ChangeEmail(user.ID, oldEmail, newEmail); err != nil {
+		return nil, err
+	}
+	user.Email = newEmail
+	user.EmailVerifiedAt = nil
+
+	if err := s.authService.ResendVerification(user.ID); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+	go s.sendEmail(oldEmail, email.GetEmailChangedSubject(), email.GetEmailChangedTemplate(newEmail))
+
+	return user, nil
+}
+
+// ChangePassword sets a new password and signs out every other session. The
+// current session stays logged in but must refresh its access token.
+func (s *accountService) ChangePassword(userID uint, sessionID, currentPassword, newPassword string) error {
+	user, err := s.authenticate(userID, currentPassword)
+	if err != nil {
+		return err
+	}
+
+	if !utils.IsValidPassword(newPassword) {
+		return ErrWeakPassword
+	}
+
+	if err := user.HashPassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, user.PasswordHash); err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeOtherFamilies(user.ID, sessionID, s.clock.Now())
+}
+
+// DeleteAccount permanently removes the user, their subscriptions and notification history.
+// Sole owners of an organization with other members must hand it over first.
+func (s *accountService) DeleteAccount(userID uint, password string) error {
+	user, err := s.authenticate(userID, password)
+	if err != nil {
+		return err
+	}
+
+	soleOwnerships, err := s.organizationRepo.CountSoleOwnerships(userID)
+	if err != nil {
+		return err
+	}
+	if soleOwnerships > 0 {
+		return ErrSoleOrganizationOwner
+	}
+
+	return s.userRepo.Delete(user)
+}
+
+// authenticate loads the user and re-checks their password before a sensitive change
+func (s *accountService) authenticate(userID uint, password string) (*models.User, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.CheckPassword(password) {
+		return nil, ErrInvalidPassword
+	}
+	return user, nil
+}
+
+// sendEmail delivers a transactional account email, logging failures
+func (s *accountService) sendEmail(to, subject, htmlBody string) {
+	if err := s.emailService.SendHTML(to, subject, htmlBody); err != nil {
+		log.Printf("Failed to send %q email to %s: %v", subject, to, err)
+	}
+}
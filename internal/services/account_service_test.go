@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"renew-guard/internal/config"
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/jwt"
+)
+
+func (r *memoryUserRepository) UpdatePassword(id uint, passwordHash string) error {
+	user, err := r.FindByID(id)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = passwordHash
+	user.TokenVersion++
+	return nil
+}
+
+func (r *memoryUserRepository) ChangeEmail(id uint, oldEmail, newEmail string) error {
+	user, err := r.FindByID(id)
+	if err != nil {
+		return err
+	}
+	user.Email = newEmail
+	user.EmailVerifiedAt = nil
+	user.TokenVersion++
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(user *models.User) error {
+	for i, stored := range r.users {
+		if stored.ID == user.ID {
+			r.users = append(r.users[:i], r.users[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeOtherFamilies(userID uint, keepFamilyID string, now time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.FamilyID != keepFamilyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+// CountSoleOwnerships counts the organizations the user alone owns while
+// others are members, like the database query
+func (r *memoryOrganizationRepository) CountSoleOwnerships(userID uint) (int64, error) {
+	owners := make(map[uint][]uint)
+	memberCounts := make(map[uint]int)
+	for _, member := range r.members {
+		memberCounts[member.OrganizationID]++
+		if member.Role == models.OrganizationRoleOwner {
+			owners[member.OrganizationID] = append(owners[member.OrganizationID], member.UserID)
+		}
+	}
+
+	var count int64
+	for organizationID, ownerIDs := range owners {
+		if len(ownerIDs) == 1 && ownerIDs[0] == userID && memberCounts[organizationID] > 1 {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// accountHarness wires the account and auth services to the same stores
+type accountHarness struct {
+	users         *memoryUserRepository
+	userTokens    *memoryUserTokenRepository
+	organizations *memoryOrganizationRepository
+	jwtUtil       *jwt.JWTUtil
+	auth          AuthService
+	accounts      AccountService
+}
+
+func newAccountHarness(t *testing.T) (*accountHarness, *models.User, *TokenPair) {
+	t.Helper()
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	h := &accountHarness{
+		users:         &memoryUserRepository{},
+		userTokens:    &memoryUserTokenRepository{},
+		organizations: &memoryOrganizationRepository{},
+		jwtUtil:       newTestJWTUtil(clk),
+	}
+	refreshTokens := &memoryRefreshTokenRepository{}
+	cfg := &config.AuthConfig{PasswordResetEmailLimit: 3, EmailVerificationTTL: 48 * time.Hour}
+	h.auth = NewAuthService(h.users, refreshTokens, h.userTokens, &memoryLoginFailureRepository{}, nil, discardEmailService{}, h.jwtUtil, 24*time.Hour, cfg, clk)
+	h.accounts = NewAccountService(h.users, refreshTokens, h.organizations, h.auth, discardEmailService{}, clk)
+
+	user, tokens, err := h.auth.Register("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h, user, tokens
+}
+
+func TestChangeEmail(t *testing.T) {
+	h, user, _ := newAccountHarness(t)
+	if _, _, err := h.auth.Register("taken@example.com", "secret123"); err != nil {
+		t.Fatal(err)
+	}
+	verifiedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	user.EmailVerifiedAt = &verifiedAt
+
+	tests := []struct {
+		name     string
+		password string
+		email    string
+		want     error
+	}{
+		{"wrong password", "wrong", "new@example.com", ErrInvalidPassword},
+		{"invalid address", "secret123", "not-an-email", ErrInvalidEmail},
+		{"same address", "secret123", " OWNER@example.com ", ErrEmailUnchanged},
+		{"taken address", "secret123", "taken@example.com", ErrEmailAlreadyExists},
+	}
+	for _, tt := range tests {
+		if _, err := h.accounts.ChangeEmail(user.ID, tt.password, tt.email); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if user.Email != "owner@example.com" || user.EmailVerifiedAt == nil {
+		t.Fatalf("rejected changes touched the account: %+v", user)
+	}
+
+	changed, err := h.accounts.ChangeEmail(user.ID, "secret123", " new@example.com ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed.Email != "new@example.com" || changed.EmailVerifiedAt != nil {
+		t.Errorf("changed to %q, verified %v; want new@example.com, unverified", changed.Email, changed.EmailVerifiedAt)
+	}
+	if user.TokenVersion != 1 {
+		t.Errorf("token version = %d, want access tokens with the old address invalidated", user.TokenVersion)
+	}
+
+	// A verification link went out for the new address only
+	var pending []string
+	for _, token := range h.userTokens.tokens {
+		if token.UserID == user.ID && token.Purpose == models.TokenPurposeEmailVerification && token.UsedAt == nil {
+			pending = append(pending, token.Payload)
+		}
+	}
+	if len(pending) != 1 || pending[0] != "new@example.com" {
+		t.Errorf("pending verifications for %v, want [new@example.com]", pending)
+	}
+}
+
+func TestChangePasswordKeepsOnlyCurrentSession(t *testing.T) {
+	h, user, current := newAccountHarness(t)
+	_, other, _, err := h.auth.Login("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := h.jwtUtil.ValidateToken(current.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := h.accounts.ChangePassword(user.ID, claims.SessionID, "wrong", "newsecret"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("wrong current password: error = %v, want %v", err, ErrInvalidPassword)
+	}
+	if err := h.accounts.ChangePassword(user.ID, claims.SessionID, "secret123", "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("weak password: error = %v, want %v", err, ErrWeakPassword)
+	}
+	if err := h.accounts.ChangePassword(user.ID, claims.SessionID, "secret123", "newsecret"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The current session's access token is stale but its refresh token works
+	if err := h.auth.ValidateSession(claims); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("old access token: error = %v, want %v", err, ErrSessionRevoked)
+	}
+	if _, _, err := h.auth.Refresh(current.RefreshToken); err != nil {
+		t.Errorf("refresh of the current session: %v", err)
+	}
+	if _, _, err := h.auth.Refresh(other.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh of another session: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	if _, _, _, err := h.auth.Login("owner@example.com", "secret123"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("login with the old password: error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, _, _, err := h.auth.Login("owner@example.com", "newsecret"); err != nil {
+		t.Errorf("login with the new password: %v", err)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	h, user, _ := newAccountHarness(t)
+
+	if err := h.accounts.DeleteAccount(user.ID, "wrong"); !errors.Is(err, ErrInvalidPassword) {
+		t.Errorf("wrong password: error = %v, want %v", err, ErrInvalidPassword)
+	}
+	if _, err := h.accounts.GetProfile(user.ID); err != nil {
+		t.Fatalf("account gone after a rejected delete: %v", err)
+	}
+
+	// An organization must not be left with members but no owner
+	h.organizations.members = []models.OrganizationMember{
+		{OrganizationID: 1, UserID: user.ID, Role: models.OrganizationRoleOwner},
+		{OrganizationID: 1, UserID: 2, Role: models.OrganizationRoleMember},
+		{OrganizationID: 2, UserID: user.ID, Role: models.OrganizationRoleOwner},
+	}
+	if err := h.accounts.DeleteAccount(user.ID, "secret123"); !errors.Is(err, ErrSoleOrganizationOwner) {
+		t.Errorf("sole owner of a shared organization: error = %v, want %v", err, ErrSoleOrganizationOwner)
+	}
+	h.organizations.members[1].Role = models.OrganizationRoleOwner
+
+	if err := h.accounts.DeleteAccount(user.ID, "secret123"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.accounts.GetProfile(user.ID); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("profile after delete: error = %v, want %v", err, ErrUserNotFound)
+	}
+	if err := h.accounts.DeleteAccount(user.ID, "secret123"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("second delete: error = %v, want %v", err, ErrUserNotFound)
+	}
+}
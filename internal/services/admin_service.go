@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRole      = errors.New("invalid role")
+	ErrCannotModifySelf = errors.New("administrators cannot disable or demote themselves")
+)
+
+// AdminService backs the administrator API. Unlike the user-facing services
+// it does not scope lookups to the caller.
+type AdminService interface {
+	ListUsers() ([]models.User, error)
+	GetUser(id uint) (*models.User, error)
+	SetDisabled(actorID, userID uint, disabled bool) (*models.User, error)
+	SetRole(actorID, userID uint, role string) (*models.User, error)
+	GetUserSubscriptions(userID uint) ([]models.Subscription, error)
+	GetSubscription(id uint) (*models.Subscription, error)
+}
+
+type adminService struct {
+	userRepo         repositories.UserRepository
+	subscriptionRepo repositories.SubscriptionRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	clock            clock.Clock
+}
+
+func NewAdminService(
+	userRepo repositories.UserRepository,
+	subscriptionRepo repositories.SubscriptionRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	clk clock.Clock,
+) AdminService {
+	return &adminService{
+		userRepo:         userRepo,
+		subscriptionRepo: subscriptionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		clock:            clk,
+	}
+}
+
+func (s *adminService) ListUsers() ([]models.User, error) {
+	return s.userRepo.FindAll()
+}
+
+func (s *adminService) GetUser(id uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetDisabled disables or re-enables an account. Disabling signs the user
+// out everywhere; their API keys stop working while the account is disabled.
+func (s *adminService) SetDisabled(actorID, userID uint, disabled bool) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !disabled {
+		user.DisabledAt = nil
+	} else if user.DisabledAt == nil {
+		now := s.clock.Now()
+		user.DisabledAt = &now
+	}
+
+	if err := s.userRepo.SetDisabled(user.ID, user.DisabledAt); err != nil {
+		return nil, err
+	}
+	if disabled {
+		if err := s.refreshTokenRepo.RevokeAllForUser(user.ID, s.clock.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return user, nil
+}
+
+func (s *adminService) SetRole(actorID, userID uint, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID && role != models.RoleAdmin {
+		return nil, ErrCannotModifySelf
+	}
+
+	user, err := s.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.userRepo.UpdateRole(user.ID, role); err != nil {
+		return nil, err
+	}
+	user.Role = role
+
+	return user, nil
+}
+
+func (s *adminService) GetUserSubscriptions(userID uint) ([]models.Subscription, error) {
+	if _, err := s.GetUser(userID); err != nil {
+		return nil, err
+	}
+	return s.subscriptionRepo.FindByUserID(userID)
+}
+
+// GetSubscription looks up any user's subscription without an ownership check
+func (s *adminService) GetSubscription(id uint) (*models.Subscription, error) {
+	subscription, err := s.subscriptionRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+	return subscription, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyNotFound   = errors.New("api key not found")
+	ErrInvalidAPIKey    = errors.New("invalid or revoked api key")
+	ErrInvalidScopes    = errors.New("invalid api key scopes")
+	ErrInvalidKeyName   = errors.New("api key name is required")
+	ErrInvalidKeyExpiry = errors.New("api key expiry must be in the future")
+)
+
+const (
+	// apiKeyPrefix marks RenewGuard keys so they are easy to spot in secret scanners
+	apiKeyPrefix = "rg_"
+	apiKeyBytes  = 32
+	// apiKeyDisplayLength is how much of the key is kept for display
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	// lastUsedResolution limits last-used writes to one per key per interval
+	lastUsedResolution = time.Minute
+)
+
+type APIKeyService interface {
+	Create(userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error)
+	List(userID uint) ([]models.APIKey, error)
+	Revoke(id, userID uint) error
+	ValidateAPIKey(rawKey string) (uint, string, []string, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	clock      clock.Clock
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, clk clock.Clock) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		clock:      clk,
+	}
+}
+
+// Create issues a new key and returns it together with the raw key, which is
+// never retrievable again
+func (s *apiKeyService) Create(userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", ErrInvalidKeyName
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidScopes
+	}
+	for _, scope := range scopes {
+		if !models.IsValidAPIKeyScope(scope) {
+			return nil, "", ErrInvalidScopes
+		}
+	}
+
+	if expiresAt != nil && !expiresAt.After(s.clock.Now()) {
+		return nil, "", ErrInvalidKeyExpiry
+	}
+
+	token, err := utils.GenerateRandomToken(apiKeyBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	rawKey := apiKeyPrefix + token
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawKey[:apiKeyDisplayLength],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.apiKeyRepo.Create(key); err != nil {
+		return nil, "", err
+	}
+
+	return key, rawKey, nil
+}
+
+func (s *apiKeyService) List(userID uint) ([]models.APIKey, error) {
+	return s.apiKeyRepo.FindByUserID(userID)
+}
+
+func (s *apiKeyService) Revoke(id, userID uint) error {
+	if err := s.apiKeyRepo.Revoke(id, userID, s.clock.Now()); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ValidateAPIKey resolves a raw key to its owner's ID, email and granted
+// scopes, recording when it was last used
+func (s *apiKeyService) ValidateAPIKey(rawKey string) (uint, string, []string, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return 0, "", nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.FindByHash(utils.HashToken(rawKey))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, "", nil, ErrInvalidAPIKey
+		}
+		return 0, "", nil, err
+	}
+
+	now := s.clock.Now()
+	if !key.IsActive(now) || key.User.IsDisabled() {
+		return 0, "", nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.apiKeyRepo.UpdateLastUsed(key.ID, now); err != nil {
+			log.Printf("Failed to update last use of api key %d: %v", key.ID, err)
+		}
+	}
+
+	return key.UserID, key.User.Email, key.Scopes, nil
+}
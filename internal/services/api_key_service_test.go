@@ -0,0 +1,160 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+
+	"gorm.io/gorm"
+)
+
+type memoryAPIKeyRepository struct {
+	keys     []*models.APIKey
+	lastUses int
+}
+
+func (r *memoryAPIKeyRepository) Create(key *models.APIKey) error {
+	key.ID = uint(len(r.keys) + 1)
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *memoryAPIKeyRepository) FindByHash(keyHash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			found := *key
+			found.User = models.User{ID: key.UserID, Email: "owner@example.com"}
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryAPIKeyRepository) FindByUserID(userID uint) ([]models.APIKey, error) {
+	var found []models.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			found = append(found, *key)
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryAPIKeyRepository) Revoke(id, userID uint, now time.Time) error {
+	for _, key := range r.keys {
+		if key.ID == id && key.UserID == userID && key.RevokedAt == nil {
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryAPIKeyRepository) UpdateLastUsed(id uint, usedAt time.Time) error {
+	r.keys[id-1].LastUsedAt = &usedAt
+	r.lastUses++
+	return nil
+}
+
+func TestCreateAPIKeyValidatesInput(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	service := NewAPIKeyService(&memoryAPIKeyRepository{}, clock.NewFake(now))
+	past := now.Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		keyName   string
+		scopes    []string
+		expiresAt *time.Time
+		want      error
+	}{
+		{"blank name", "  ", []string{models.APIKeyScopeSubscriptionsRead}, nil, ErrInvalidKeyName},
+		{"no scopes", "CI", nil, nil, ErrInvalidScopes},
+		{"unknown scope", "CI", []string{models.APIKeyScopeSubscriptionsRead, "admin"}, nil, ErrInvalidScopes},
+		{"expired", "CI", []string{models.APIKeyScopeSubscriptionsRead}, &past, ErrInvalidKeyExpiry},
+	}
+	for _, tt := range tests {
+		if _, _, err := service.Create(1, tt.keyName, tt.scopes, tt.expiresAt); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	key, rawKey, err := service.Create(1, " CI ", []string{models.APIKeyScopeSubscriptionsRead}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Name != "CI" || !strings.HasPrefix(rawKey, apiKeyPrefix) || !strings.HasPrefix(rawKey, key.Prefix) {
+		t.Errorf("created %q with prefix %q for raw key %q", key.Name, key.Prefix, rawKey)
+	}
+	if strings.Contains(key.KeyHash, rawKey) {
+		t.Error("raw key stored with the key")
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	repo := &memoryAPIKeyRepository{}
+	service := NewAPIKeyService(repo, clk)
+
+	scopes := []string{models.APIKeyScopeSubscriptionsRead, models.APIKeyScopePreferencesWrite}
+	expiresAt := clk.Now().Add(24 * time.Hour)
+	key, rawKey, err := service.Create(7, "CI", scopes, &expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	userID, email, granted, err := service.ValidateAPIKey(rawKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if userID != 7 || email != "owner@example.com" || !reflect.DeepEqual(granted, scopes) {
+		t.Errorf("resolved to user %d (%s) with scopes %v", userID, email, granted)
+	}
+
+	// Last use is written at most once a minute
+	clk.Advance(30 * time.Second)
+	service.ValidateAPIKey(rawKey)
+	clk.Advance(30 * time.Second)
+	service.ValidateAPIKey(rawKey)
+	if repo.lastUses != 2 {
+		t.Errorf("last use written %d times, want 2", repo.lastUses)
+	}
+
+	for _, raw := range []string{"", "rg_unknown", strings.TrimPrefix(rawKey, apiKeyPrefix)} {
+		if _, _, _, err := service.ValidateAPIKey(raw); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("key %q: error = %v, want %v", raw, err, ErrInvalidAPIKey)
+		}
+	}
+
+	// Keys stop working once they expire
+	clk.Set(expiresAt)
+	if _, _, _, err := service.ValidateAPIKey(rawKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expired key: error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+
+	// ...or are revoked, which only their owner can do
+	_, other, err := service.Create(7, "Backup", scopes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.Revoke(2, 8); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("revoke by another user: error = %v, want %v", err, ErrAPIKeyNotFound)
+	}
+	if _, _, _, err := service.ValidateAPIKey(other); err != nil {
+		t.Errorf("key revoked by another user: %v", err)
+	}
+	if err := service.Revoke(2, 7); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := service.ValidateAPIKey(other); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("revoked key: error = %v, want %v", err, ErrInvalidAPIKey)
+	}
+	if err := service.Revoke(key.ID, 7); err != nil {
+		t.Errorf("revoking an expired key: %v", err)
+	}
+}
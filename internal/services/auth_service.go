@@ -2,53 +2,148 @@ package services
 
 import (
 	"errors"
+	"log"
+	"net/url"
+	"renew-guard/internal/config"
 	"renew-guard/internal/models"
 	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
 	"renew-guard/pkg/jwt"
+	"renew-guard/pkg/ratelimit"
 	"renew-guard/pkg/utils"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 var (
-	ErrEmailAlreadyExists = errors.New("email already exists")
-	ErrInvalidCredentials = errors.New("invalid email or password")
-	ErrInvalidEmail       = errors.New("invalid email format")
-	ErrWeakPassword       = errors.New("password must be at least 6 characters")
+	ErrEmailAlreadyExists  = errors.New("email already exists")
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidEmail        = errors.New("invalid email format")
+	ErrWeakPassword        = errors.New("password must be at least 6 characters")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrSessionRevoked      = errors.New("session has been revoked")
+	ErrInvalidResetToken   = errors.New("invalid or expired password reset token")
+	ErrInvalidVerifyToken  = errors.New("invalid or expired verification token")
+	ErrAlreadyVerified     = errors.New("email address is already verified")
+	ErrTooManyEmails       = errors.New("too many emails requested, please try again later")
+	ErrInvalidChallenge    = errors.New("invalid or expired login challenge")
+	ErrAccountLocked       = errors.New("too many failed login attempts, please try again later")
+	ErrAccountDisabled     = errors.New("account has been disabled")
 )
 
+// LockoutError is returned by Login while an account is locked after failed
+// attempts. It matches ErrAccountLocked with errors.Is.
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+func (e *LockoutError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// refreshTokenBytes and userTokenBytes are the entropy of opaque tokens
+const (
+	refreshTokenBytes = 32
+	userTokenBytes    = 32
+)
+
+const (
+	// loginFreeAttempts failed logins are allowed before delays kick in
+	loginFreeAttempts = 3
+	// loginFailureWindow is how long a failed login counts towards a lockout
+	loginFailureWindow = 24 * time.Hour
+)
+
+// LoginChallenge is returned instead of tokens when the account has
+// two-factor login enabled; the token is exchanged with a code via LoginTwoFactor
+type LoginChallenge struct {
+	Token     string
+	ExpiresIn int // Challenge lifetime in seconds
+}
+
+// TokenPair is the credentials handed to a client after authentication
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresIn    int // Access token lifetime in seconds
+}
+
 type AuthService interface {
-	Register(email, password string) (*models.User, string, error)
-	Login(email, password string) (*models.User, string, error)
+	Register(email, password string) (*models.User, *TokenPair, error)
+	Login(email, password string) (*models.User, *TokenPair, *LoginChallenge, error)
+	LoginTwoFactor(challengeToken, code string) (*models.User, *TokenPair, error)
+	Refresh(refreshToken string) (*models.User, *TokenPair, error)
+	Logout(userID uint, sessionID string, allSessions bool) error
+	ValidateSession(claims *jwt.Claims) error
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+	VerifyEmail(token string) (*models.User, error)
+	ResendVerification(userID uint) error
 }
 
 type authService struct {
-	userRepo repositories.UserRepository
-	jwtUtil  *jwt.JWTUtil
+	userRepo         repositories.UserRepository
+	refreshTokenRepo repositories.RefreshTokenRepository
+	userTokenRepo    repositories.UserTokenRepository
+	loginFailureRepo repositories.LoginFailureRepository
+	twoFactorService TwoFactorService
+	emailService     email.EmailService
+	jwtUtil          *jwt.JWTUtil
+	refreshTokenTTL  time.Duration
+	config           *config.AuthConfig
+	mailLimiter      *ratelimit.Limiter // Account emails per address per hour
+	clock            clock.Clock
 }
 
-func NewAuthService(userRepo repositories.UserRepository, jwtUtil *jwt.JWTUtil) AuthService {
+func NewAuthService(
+	userRepo repositories.UserRepository,
+	refreshTokenRepo repositories.RefreshTokenRepository,
+	userTokenRepo repositories.UserTokenRepository,
+	loginFailureRepo repositories.LoginFailureRepository,
+	twoFactorService TwoFactorService,
+	emailService email.EmailService,
+	jwtUtil *jwt.JWTUtil,
+	refreshTokenTTL time.Duration,
+	cfg *config.AuthConfig,
+	clk clock.Clock,
+) AuthService {
 	return &authService{
-		userRepo: userRepo,
-		jwtUtil:  jwtUtil,
+		userRepo:         userRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		userTokenRepo:    userTokenRepo,
+		loginFailureRepo: loginFailureRepo,
+		twoFactorService: twoFactorService,
+		emailService:     emailService,
+		jwtUtil:          jwtUtil,
+		refreshTokenTTL:  refreshTokenTTL,
+		config:           cfg,
+		mailLimiter:      ratelimit.New(cfg.PasswordResetEmailLimit, time.Hour, clk),
+		clock:            clk,
 	}
 }
 
-func (s *authService) Register(email, password string) (*models.User, string, error) {
+func (s *authService) Register(email, password string) (*models.User, *TokenPair, error) {
 	// Validate email
 	if !utils.IsValidEmail(email) {
-		return nil, "", ErrInvalidEmail
+		return nil, nil, ErrInvalidEmail
 	}
 
 	// Validate password
 	if !utils.IsValidPassword(password) {
-		return nil, "", ErrWeakPassword
+		return nil, nil, ErrWeakPassword
 	}
 
 	// Check if user already exists
 	existingUser, err := s.userRepo.FindByEmail(email)
 	if err == nil && existingUser != nil {
-		return nil, "", ErrEmailAlreadyExists
+		return nil, nil, ErrEmailAlreadyExists
 	}
 
 	// Create new user
@@ -57,42 +152,532 @@ func (s *authService) Register(email, password string) (*models.User, string, er
 	}
 
 	if err := user.HashPassword(password); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	if err := s.userRepo.Create(user); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Generate JWT token
-	token, err := s.jwtUtil.GenerateToken(user.ID, user.Email)
+	// Reminders are only mailed once the address is confirmed
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+
+	// Start a session
+	tokens, err := s.startSession(user)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *authService) Login(email, password string) (*models.User, string, error) {
+// Login checks the password. Accounts with two-factor login enabled get a
+// short-lived challenge instead of tokens, to be completed with LoginTwoFactor.
+// Failures are counted per address whether or not it has an account, so
+// unknown and registered addresses get the same answers.
+func (s *authService) Login(email, password string) (*models.User, *TokenPair, *LoginChallenge, error) {
+	now := s.clock.Now()
+	address := strings.ToLower(strings.TrimSpace(email))
+
+	// Refuse locked addresses before looking up the account or spending time on bcrypt
+	failure, err := s.loginFailureRepo.FindByEmail(address)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil, err
+	}
+	if failure != nil {
+		if locked, retryAfter := failure.IsLocked(now); locked {
+			return nil, nil, nil, &LockoutError{RetryAfter: retryAfter}
+		}
+	}
+
 	// Find user by email
 	user, err := s.userRepo.FindByEmail(email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", ErrInvalidCredentials
+			if err := s.recordFailedLogin(address, nil, now); err != nil {
+				return nil, nil, nil, err
+			}
+			return nil, nil, nil, ErrInvalidCredentials
 		}
-		return nil, "", err
+		return nil, nil, nil, err
 	}
 
 	// Check password
 	if !user.CheckPassword(password) {
-		return nil, "", ErrInvalidCredentials
+		if err := s.recordFailedLogin(address, user, now); err != nil {
+			return nil, nil, nil, err
+		}
+		return nil, nil, nil, ErrInvalidCredentials
+	}
+
+	if failure != nil {
+		if err := s.loginFailureRepo.Reset(address); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+
+	// Only reveal that the account is disabled to someone who knows the password
+	if user.IsDisabled() {
+		return nil, nil, nil, ErrAccountDisabled
+	}
+
+	if user.TwoFactorEnabled() {
+		rawToken, err := s.createUserToken(user.ID, models.TokenPurposeLoginChallenge, "", s.config.LoginChallengeTTL, now)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return user, nil, &LoginChallenge{
+			Token:     rawToken,
+			ExpiresIn: int(s.config.LoginChallengeTTL.Seconds()),
+		}, nil
+	}
+
+	// Start a session
+	tokens, err := s.startSession(user)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return user, tokens, nil, nil
+}
+
+// recordFailedLogin counts a failed login for the address and locks it for a
+// growing delay once loginFreeAttempts is exceeded. Reaching the configured
+// maximum locks it for the full lockout duration and, if the address belongs
+// to an account, warns the owner by email.
+func (s *authService) recordFailedLogin(address string, user *models.User, now time.Time) error {
+	failures, err := s.loginFailureRepo.RecordFailure(address, now, now.Add(-loginFailureWindow))
+	if err != nil {
+		return err
+	}
+
+	delay := s.loginDelay(failures)
+	if delay == 0 {
+		return nil
+	}
+	if err := s.loginFailureRepo.LockUntil(address, now.Add(delay)); err != nil {
+		return err
+	}
+
+	if failures == s.config.LoginMaxFailedAttempts && user != nil {
+		log.Printf("Locked user %d for %s after %d failed logins", user.ID, delay, failures)
+		go s.sendEmail(user.Email, email.GetAccountLockedSubject(),
+			email.GetAccountLockedTemplate(failures, int(delay.Minutes())))
+	}
+
+	return nil
+}
+
+// loginDelay is how long to refuse logins after the given number of
+// consecutive failures: nothing at first, then 1s, 2s, 4s, ... and the full
+// lockout duration from the configured maximum on
+func (s *authService) loginDelay(failures int) time.Duration {
+	if failures >= s.config.LoginMaxFailedAttempts {
+		return s.config.LoginLockoutDuration
+	}
+	if failures <= loginFreeAttempts {
+		return 0
+	}
+
+	// Cap the shift so a large configured maximum cannot overflow
+	delay := s.config.LoginLockoutDuration
+	if shift := failures - loginFreeAttempts - 1; shift < 30 && time.Second<<shift < delay {
+		delay = time.Second << shift
+	}
+	return delay
+}
+
+// LoginTwoFactor completes a two-factor login with a TOTP or recovery code.
+// A wrong code leaves the challenge usable until it expires; attempts are
+// rate limited per user.
+func (s *authService) LoginTwoFactor(challengeToken, code string) (*models.User, *TokenPair, error) {
+	now := s.clock.Now()
+
+	challenge, err := s.userTokenRepo.FindByHash(models.TokenPurposeLoginChallenge, utils.HashToken(challengeToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+	if !challenge.IsUsable(now) {
+		return nil, nil, ErrInvalidChallenge
+	}
+
+	user, err := s.userRepo.FindByID(challenge.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+
+	if user.IsDisabled() {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	if err := s.twoFactorService.VerifyCode(user, code); err != nil {
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			// Disabled since the password step; make them start over
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+
+	if err := s.userTokenRepo.MarkUsed(challenge, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidChallenge
+		}
+		return nil, nil, err
+	}
+
+	// Start a session
+	tokens, err := s.startSession(user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new token pair, rotating the
+// refresh token. Reusing an already rotated token revokes the whole session.
+func (s *authService) Refresh(refreshToken string) (*models.User, *TokenPair, error) {
+	now := s.clock.Now()
+
+	current, err := s.refreshTokenRepo.FindByHash(utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	if current.RotatedAt != nil && current.RevokedAt == nil {
+		// A rotated token came back: assume it was stolen and end the session
+		if err := s.refreshTokenRepo.RevokeFamily(current.FamilyID, now); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if !current.IsActive(now) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.FindByID(current.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+	if user.IsDisabled() {
+		return nil, nil, ErrAccountDisabled
+	}
+
+	rawToken, next, err := s.newRefreshToken(user.ID, current.FamilyID, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.refreshTokenRepo.Rotate(current, next, now); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Lost a race with a concurrent refresh of the same token
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	tokens, err := s.issueTokens(user, current.FamilyID, rawToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Logout revokes the given session, or every session of the user when
+// allSessions is set. Revoking all sessions also bumps the user's token
+// version so access tokens without a session stop working too.
+func (s *authService) Logout(userID uint, sessionID string, allSessions bool) error {
+	now := s.clock.Now()
+
+	if allSessions {
+		if err := s.refreshTokenRepo.RevokeAllForUser(userID, now); err != nil {
+			return err
+		}
+		return s.userRepo.IncrementTokenVersion(userID)
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(sessionID, now)
+}
+
+// ValidateSession checks that the session behind an access token has not been
+// logged out and that the user's tokens have not been invalidated since
+func (s *authService) ValidateSession(claims *jwt.Claims) error {
+	if claims.SessionID == "" {
+		return ErrSessionRevoked
 	}
 
-	// Generate JWT token
-	token, err := s.jwtUtil.GenerateToken(user.ID, user.Email)
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrSessionRevoked
+		}
+		return err
+	}
+	if user.TokenVersion != claims.TokenVersion || user.IsDisabled() {
+		return ErrSessionRevoked
+	}
+
+	active, err := s.refreshTokenRepo.IsFamilyActive(claims.SessionID, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	if !active {
+		return ErrSessionRevoked
+	}
+
+	return nil
+}
+
+// ForgotPassword mails a one-time reset link if the address belongs to an
+// account. It reports success either way so callers cannot probe for
+// registered addresses, and quietly drops requests beyond the per-address limit.
+func (s *authService) ForgotPassword(emailAddress string) error {
+	emailAddress = strings.TrimSpace(emailAddress)
+	if !utils.IsValidEmail(emailAddress) {
+		return ErrInvalidEmail
+	}
+
+	if allowed, _ := s.mailLimiter.Allow(strings.ToLower(emailAddress)); !allowed {
+		log.Printf("Password reset rate limit reached for %s", emailAddress)
+		return nil
+	}
+
+	user, err := s.userRepo.FindByEmail(emailAddress)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	now := s.clock.Now()
+
+	// Only the most recent link works
+	if err := s.userTokenRepo.InvalidateForUser(user.ID, models.TokenPurposePasswordReset, now); err != nil {
+		return err
+	}
+
+	rawToken, err := s.createUserToken(user.ID, models.TokenPurposePasswordReset, "", s.config.PasswordResetTTL, now)
+	if err != nil {
+		return err
+	}
+
+	resetURL := s.config.BaseURL + "/reset-password?token=" + url.QueryEscape(rawToken)
+	go s.sendEmail(user.Email, email.GetPasswordResetSubject(),
+		email.GetPasswordResetTemplate(resetURL, int(s.config.PasswordResetTTL.Minutes())))
+
+	return nil
+}
+
+// ResetPassword redeems a reset token, sets the new password and signs the
+// user out everywhere
+func (s *authService) ResetPassword(token, newPassword string) error {
+	if !utils.IsValidPassword(newPassword) {
+		return ErrWeakPassword
+	}
+
+	now := s.clock.Now()
+
+	userToken, err := s.redeemUserToken(models.TokenPurposePasswordReset, token, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(userToken.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+	if err := user.HashPassword(newPassword); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdatePassword(user.ID, user.PasswordHash); err != nil {
+		return err
+	}
+
+	// Proving control of the address lifts any login lockout on it
+	if err := s.loginFailureRepo.Reset(strings.ToLower(user.Email)); err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeAllForUser(user.ID, now)
+}
+
+// VerifyEmail redeems a verification token and marks the address it was
+// issued for as verified. Tokens for an address the user no longer has are rejected.
+func (s *authService) VerifyEmail(token string) (*models.User, error) {
+	now := s.clock.Now()
+
+	userToken, err := s.redeemUserToken(models.TokenPurposeEmailVerification, token, now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerifyToken
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(userToken.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidVerifyToken
+		}
+		return nil, err
+	}
+	if !strings.EqualFold(user.Email, userToken.Payload) {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	if err := s.userRepo.MarkEmailVerified(user.ID, now); err != nil {
+		return nil, err
+	}
+	user.EmailVerifiedAt = &now
+
+	return user, nil
+}
+
+// ResendVerification mails a fresh verification link to an unverified user
+func (s *authService) ResendVerification(userID uint) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return ErrAlreadyVerified
+	}
+	if allowed, _ := s.mailLimiter.Allow(strings.ToLower(user.Email)); !allowed {
+		return ErrTooManyEmails
+	}
+
+	return s.sendVerificationEmail(user)
+}
+
+// sendVerificationEmail issues a verification token for the user's current
+// address, invalidating earlier ones, and mails the link
+func (s *authService) sendVerificationEmail(user *models.User) error {
+	now := s.clock.Now()
+
+	if err := s.userTokenRepo.InvalidateForUser(user.ID, models.TokenPurposeEmailVerification, now); err != nil {
+		return err
+	}
+
+	rawToken, err := s.createUserToken(user.ID, models.TokenPurposeEmailVerification, user.Email, s.config.EmailVerificationTTL, now)
+	if err != nil {
+		return err
+	}
+
+	verifyURL := s.config.BaseURL + "/api/v1/auth/verify?token=" + url.QueryEscape(rawToken)
+	go s.sendEmail(user.Email, email.GetEmailVerificationSubject(),
+		email.GetEmailVerificationTemplate(verifyURL, int(s.config.EmailVerificationTTL.Hours())))
+
+	return nil
+}
+
+// createUserToken stores a hashed single-use token and returns the raw value to mail
+func (s *authService) createUserToken(userID uint, purpose, payload string, ttl time.Duration, now time.Time) (string, error) {
+	rawToken, err := utils.GenerateRandomToken(userTokenBytes)
+	if err != nil {
+		return "", err
+	}
+
+	userToken := &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: utils.HashToken(rawToken),
+		Payload:   payload,
+		ExpiresAt: now.Add(ttl),
+	}
+	if err := s.userTokenRepo.Create(userToken); err != nil {
+		return "", err
+	}
+
+	return rawToken, nil
+}
+
+// redeemUserToken looks up and consumes a single-use token. Unknown, expired
+// and already used tokens all yield gorm.ErrRecordNotFound.
+func (s *authService) redeemUserToken(purpose, rawToken string, now time.Time) (*models.UserToken, error) {
+	userToken, err := s.userTokenRepo.FindByHash(purpose, utils.HashToken(rawToken))
+	if err != nil {
+		return nil, err
+	}
+	if !userToken.IsUsable(now) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if err := s.userTokenRepo.MarkUsed(userToken, now); err != nil {
+		return nil, err
+	}
+	return userToken, nil
+}
+
+// sendEmail delivers a transactional account email, logging failures
+func (s *authService) sendEmail(to, subject, htmlBody string) {
+	if err := s.emailService.SendHTML(to, subject, htmlBody); err != nil {
+		log.Printf("Failed to send %q email to %s: %v", subject, to, err)
+	}
+}
+
+// startSession opens a new refresh token family for the user
+func (s *authService) startSession(user *models.User) (*TokenPair, error) {
+	familyID, err := utils.GenerateRandomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	rawToken, refreshToken, err := s.newRefreshToken(user.ID, familyID, s.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Create(refreshToken); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(user, familyID, rawToken)
+}
+
+// newRefreshToken generates a refresh token and the hashed record to store for it
+func (s *authService) newRefreshToken(userID uint, familyID string, now time.Time) (string, *models.RefreshToken, error) {
+	rawToken, err := utils.GenerateRandomToken(refreshTokenBytes)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rawToken, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: now.Add(s.refreshTokenTTL),
+	}, nil
+}
+
+// issueTokens signs an access token for the session and pairs it with rawRefreshToken
+func (s *authService) issueTokens(user *models.User, sessionID, rawRefreshToken string) (*TokenPair, error) {
+	accessToken, err := s.jwtUtil.GenerateToken(user.ID, user.Email, user.Role, sessionID, user.TokenVersion)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	return user, token, nil
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: rawRefreshToken,
+		ExpiresIn:    int(s.jwtUtil.Duration().Seconds()),
+	}, nil
 }
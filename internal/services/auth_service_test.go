@@ -0,0 +1,394 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"renew-guard/internal/config"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+	"renew-guard/pkg/jwt"
+
+	"gorm.io/gorm"
+)
+
+type memoryUserRepository struct {
+	repositories.UserRepository
+	users []*models.User
+}
+
+func (r *memoryUserRepository) Create(user *models.User) error {
+	user.ID = uint(len(r.users) + 1)
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r *memoryUserRepository) FindByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserRepository) FindByID(id uint) (*models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserRepository) IncrementTokenVersion(id uint) error {
+	user, err := r.FindByID(id)
+	if err != nil {
+		return err
+	}
+	user.TokenVersion++
+	return nil
+}
+
+type memoryRefreshTokenRepository struct {
+	repositories.RefreshTokenRepository
+	tokens []*models.RefreshToken
+}
+
+func (r *memoryRefreshTokenRepository) Create(token *models.RefreshToken) error {
+	token.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) FindByHash(tokenHash string) (*models.RefreshToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			stored := *token
+			return &stored, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryRefreshTokenRepository) Rotate(current *models.RefreshToken, next *models.RefreshToken, now time.Time) error {
+	stored := r.tokens[current.ID-1]
+	if stored.RotatedAt != nil || stored.RevokedAt != nil {
+		return gorm.ErrRecordNotFound
+	}
+	stored.RotatedAt = &now
+	current.RotatedAt = &now
+	return r.Create(next)
+}
+
+func (r *memoryRefreshTokenRepository) RevokeFamily(familyID string, now time.Time) error {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) RevokeAllForUser(userID uint, now time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memoryRefreshTokenRepository) IsFamilyActive(familyID string, now time.Time) (bool, error) {
+	for _, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil && token.ExpiresAt.After(now) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryUserTokenRepository struct {
+	tokens []*models.UserToken
+}
+
+func (r *memoryUserTokenRepository) Create(token *models.UserToken) error {
+	token.ID = uint(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memoryUserTokenRepository) FindByHash(purpose, tokenHash string) (*models.UserToken, error) {
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryUserTokenRepository) MarkUsed(token *models.UserToken, now time.Time) error {
+	token.UsedAt = &now
+	return nil
+}
+
+func (r *memoryUserTokenRepository) InvalidateForUser(userID uint, purpose string, now time.Time) error {
+	for _, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose && token.UsedAt == nil {
+			token.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+type memoryLoginFailureRepository struct {
+	failures map[string]*models.LoginFailure
+}
+
+func (r *memoryLoginFailureRepository) FindByEmail(email string) (*models.LoginFailure, error) {
+	if failure, ok := r.failures[email]; ok {
+		stored := *failure
+		return &stored, nil
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryLoginFailureRepository) RecordFailure(email string, now, windowStart time.Time) (int, error) {
+	if r.failures == nil {
+		r.failures = make(map[string]*models.LoginFailure)
+	}
+	failure, ok := r.failures[email]
+	if !ok {
+		failure = &models.LoginFailure{Email: email}
+		r.failures[email] = failure
+	}
+	if failure.LastFailedAt.Before(windowStart) {
+		failure.FailedLogins = 0
+	}
+	failure.FailedLogins++
+	failure.LastFailedAt = now
+	return failure.FailedLogins, nil
+}
+
+func (r *memoryLoginFailureRepository) LockUntil(email string, until time.Time) error {
+	r.failures[email].LockedUntil = &until
+	return nil
+}
+
+func (r *memoryLoginFailureRepository) Reset(email string) error {
+	delete(r.failures, email)
+	return nil
+}
+
+// discardEmailService accepts and drops every email
+type discardEmailService struct{}
+
+func (discardEmailService) Send(to, subject, body string) error {
+	return nil
+}
+
+func (discardEmailService) SendHTML(to, subject, htmlBody string) error {
+	return nil
+}
+
+func (discardEmailService) SendHTMLWithAttachments(to, subject, htmlBody string, attachments []email.Attachment) error {
+	return nil
+}
+
+// newTestJWTUtil signs with an HS256 secret, which NewJWTUtil always accepts
+func newTestJWTUtil(clk clock.Clock) *jwt.JWTUtil {
+	jwtUtil, _ := jwt.NewJWTUtil([]*jwt.Key{jwt.NewHMACKey("test-secret")}, 15*time.Minute, clk)
+	return jwtUtil
+}
+
+func newAuthTestService(clk clock.Clock) (AuthService, *jwt.JWTUtil) {
+	jwtUtil := newTestJWTUtil(clk)
+	cfg := &config.AuthConfig{
+		PasswordResetEmailLimit: 3,
+		EmailVerificationTTL:    48 * time.Hour,
+		LoginMaxFailedAttempts:  10,
+		LoginLockoutDuration:    15 * time.Minute,
+	}
+	service := NewAuthService(&memoryUserRepository{}, &memoryRefreshTokenRepository{}, &memoryUserTokenRepository{}, &memoryLoginFailureRepository{}, nil, discardEmailService{}, jwtUtil, 24*time.Hour, cfg, clk)
+	return service, jwtUtil
+}
+
+func TestRefreshRotatesToken(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	service, jwtUtil := newAuthTestService(clk)
+
+	_, tokens, err := service.Register("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := jwtUtil.ValidateToken(tokens.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Well after the access token expired
+	clk.Advance(time.Hour)
+	_, rotated, err := service.Refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.RefreshToken == tokens.RefreshToken {
+		t.Error("refresh returned the same refresh token")
+	}
+
+	// The new access token belongs to the same session
+	after, err := jwtUtil.ValidateToken(rotated.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.SessionID != before.SessionID {
+		t.Errorf("session changed from %q to %q", before.SessionID, after.SessionID)
+	}
+	if err := service.ValidateSession(after); err != nil {
+		t.Errorf("rotated session rejected: %v", err)
+	}
+
+	// The successor can be rotated in turn
+	if _, _, err := service.Refresh(rotated.RefreshToken); err != nil {
+		t.Errorf("refresh with the rotated token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	service, jwtUtil := newAuthTestService(clk)
+
+	_, tokens, err := service.Register("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, rotated, err := service.Refresh(tokens.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Presenting the already rotated token again looks like theft
+	if _, _, err := service.Refresh(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("reused token: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	// ...so the legitimate successor and its access token stop working too
+	if _, _, err := service.Refresh(rotated.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("successor after reuse: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+	claims, err := jwtUtil.ValidateToken(rotated.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ValidateSession(claims); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("session after reuse: error = %v, want %v", err, ErrSessionRevoked)
+	}
+
+	// Other sessions of the user are untouched
+	_, other, _, err := service.Login("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := service.Refresh(other.RefreshToken); err != nil {
+		t.Errorf("refresh of another session: %v", err)
+	}
+}
+
+func TestRefreshRejectsExpiredAndUnknownTokens(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	service, _ := newAuthTestService(clk)
+
+	_, tokens, err := service.Register("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := service.Refresh("not-a-token"); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("unknown token: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+
+	clk.Advance(24 * time.Hour)
+	if _, _, err := service.Refresh(tokens.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("expired token: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestLogoutAllSessionsInvalidatesAccessTokens(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	service, jwtUtil := newAuthTestService(clk)
+
+	user, first, err := service.Register("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, second, _, err := service.Login("owner@example.com", "secret123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstClaims, _ := jwtUtil.ValidateToken(first.AccessToken)
+	secondClaims, _ := jwtUtil.ValidateToken(second.AccessToken)
+
+	// Logging out one session leaves the other alone
+	if err := service.Logout(user.ID, firstClaims.SessionID, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ValidateSession(firstClaims); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("logged out session: error = %v, want %v", err, ErrSessionRevoked)
+	}
+	if err := service.ValidateSession(secondClaims); err != nil {
+		t.Errorf("other session: %v", err)
+	}
+
+	if err := service.Logout(user.ID, secondClaims.SessionID, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := service.ValidateSession(secondClaims); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("after logging out everywhere: error = %v, want %v", err, ErrSessionRevoked)
+	}
+	if _, _, err := service.Refresh(second.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Errorf("refresh after logging out everywhere: error = %v, want %v", err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestLoginLockoutDoesNotRevealAccounts(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	service, _ := newAuthTestService(clk)
+
+	if _, _, err := service.Register("owner@example.com", "secret123"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown addresses are counted and locked exactly like wrong passwords
+	for _, address := range []string{"owner@example.com", "nobody@example.com"} {
+		for i := 0; i <= loginFreeAttempts; i++ {
+			if _, _, _, err := service.Login(address, "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+				t.Fatalf("%s attempt %d: error = %v, want %v", address, i+1, err, ErrInvalidCredentials)
+			}
+		}
+
+		// Locked before the password is even checked, whatever the case
+		_, _, _, err := service.Login(strings.ToUpper(address), "secret123")
+		var lockout *LockoutError
+		if !errors.As(err, &lockout) || lockout.RetryAfter != time.Second {
+			t.Errorf("%s after %d failures: error = %v, want a lockout of 1s", address, loginFreeAttempts+1, err)
+		}
+	}
+
+	// A successful login once the delay passed starts the count over
+	clk.Advance(time.Second)
+	if _, _, _, err := service.Login("owner@example.com", "secret123"); err != nil {
+		t.Fatalf("login after the lockout: %v", err)
+	}
+	if _, _, _, err := service.Login("owner@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("first failure after a successful login: error = %v, want %v", err, ErrInvalidCredentials)
+	}
+	if _, _, _, err := service.Login("owner@example.com", "secret123"); err != nil {
+		t.Errorf("login after a single failure: %v", err)
+	}
+}
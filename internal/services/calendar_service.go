@@ -0,0 +1,264 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+	"renew-guard/pkg/ical"
+	"renew-guard/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+	ErrInvalidCalendarToken = errors.New("invalid calendar token")
+)
+
+const (
+	calendarTokenBytes = 32
+	// calendarTokenDisplayLength is how much of the token is kept for display
+	calendarTokenDisplayLength = 8
+	// CalendarProdID identifies RenewGuard as the writer of calendar data
+	CalendarProdID = "-//RenewGuard//Renewals//EN"
+
+	inviteFilename    = "renewal.ics"
+	inviteContentType = "text/calendar; charset=utf-8; method=PUBLISH"
+)
+
+// CalendarService manages users' secret iCalendar feeds and renders them.
+// Feeds are built from the current subscriptions on every request, so any
+// change shows up the next time a calendar client refreshes.
+type CalendarService interface {
+	GetFeed(userID uint) (*models.CalendarFeed, error)
+	RotateFeed(userID uint) (*models.CalendarFeed, string, error)
+	DeleteFeed(userID uint) error
+	FeedURL(rawToken string) string
+	Render(rawToken string) (*ical.Calendar, error)
+	Invite(subscription *models.Subscription, preference *models.UserPreference) (*email.Attachment, error)
+}
+
+type calendarService struct {
+	calendarFeedRepo  repositories.CalendarFeedRepository
+	subscriptionRepo  repositories.SubscriptionRepository
+	preferenceRepo    repositories.UserPreferenceRepository
+	baseURL           string
+	defaultDaysBefore int
+	clock             clock.Clock
+}
+
+func NewCalendarService(
+	calendarFeedRepo repositories.CalendarFeedRepository,
+	subscriptionRepo repositories.SubscriptionRepository,
+	preferenceRepo repositories.UserPreferenceRepository,
+	baseURL string,
+	defaultDaysBefore int,
+	clk clock.Clock,
+) CalendarService {
+	return &calendarService{
+		calendarFeedRepo:  calendarFeedRepo,
+		subscriptionRepo:  subscriptionRepo,
+		preferenceRepo:    preferenceRepo,
+		baseURL:           baseURL,
+		defaultDaysBefore: defaultDaysBefore,
+		clock:             clk,
+	}
+}
+
+func (s *calendarService) GetFeed(userID uint) (*models.CalendarFeed, error) {
+	feed, err := s.calendarFeedRepo.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+	return feed, nil
+}
+
+// RotateFeed issues a new token for the user's feed, creating the feed if
+// needed, and returns it together with the raw token. Any previous URL
+// stops working.
+func (s *calendarService) RotateFeed(userID uint) (*models.CalendarFeed, string, error) {
+	rawToken, err := utils.GenerateRandomToken(calendarTokenBytes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	feed := &models.CalendarFeed{
+		UserID:    userID,
+		Prefix:    rawToken[:calendarTokenDisplayLength],
+		TokenHash: utils.HashToken(rawToken),
+	}
+	if err := s.calendarFeedRepo.Replace(feed); err != nil {
+		return nil, "", err
+	}
+
+	return feed, rawToken, nil
+}
+
+func (s *calendarService) DeleteFeed(userID uint) error {
+	if err := s.calendarFeedRepo.DeleteByUserID(userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCalendarFeedNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// FeedURL is the public address calendar clients subscribe to
+func (s *calendarService) FeedURL(rawToken string) string {
+	return s.baseURL + "/api/v1/calendar/" + url.PathEscape(rawToken) + ".ics"
+}
+
+// Render builds the calendar behind a feed token: one all-day event on the
+// end date of every subscription the owner can see, with an alarm for each
+// reminder the subscription would send
+func (s *calendarService) Render(rawToken string) (*ical.Calendar, error) {
+	feed, err := s.calendarFeedRepo.FindByHash(utils.HashToken(rawToken))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCalendarToken
+		}
+		return nil, err
+	}
+	if feed.User.IsDisabled() {
+		return nil, ErrInvalidCalendarToken
+	}
+
+	now := s.clock.Now()
+	if feed.LastAccessedAt == nil || now.Sub(*feed.LastAccessedAt) >= lastUsedResolution {
+		if err := s.calendarFeedRepo.UpdateLastAccessed(feed.ID, now); err != nil {
+			log.Printf("Failed to update last access of calendar feed %d: %v", feed.ID, err)
+		}
+	}
+
+	preference, err := s.findPreference(feed.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := &ical.Calendar{
+		ProdID: CalendarProdID,
+		Name:   "RenewGuard renewals",
+	}
+	err = s.subscriptionRepo.FindEach(feed.UserID, nil, exportBatchSize, func(batch []models.Subscription) error {
+		for i := range batch {
+			calendar.Events = append(calendar.Events, s.renewalEvent(&batch[i], preference))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return calendar, nil
+}
+
+// Invite builds a one-event calendar file for a subscription's end date, to
+// attach to emails. The event shares its UID with the feed, so adding it to
+// a calendar that also subscribes to the feed does not duplicate it. The
+// owner's preferences are loaded when preference is nil.
+func (s *calendarService) Invite(subscription *models.Subscription, preference *models.UserPreference) (*email.Attachment, error) {
+	if preference == nil {
+		var err error
+		if preference, err = s.findPreference(subscription.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	// With a METHOD, DTSTAMP is when the invite was generated
+	event := s.renewalEvent(subscription, preference)
+	event.Stamp = s.clock.Now()
+
+	calendar := &ical.Calendar{
+		ProdID: CalendarProdID,
+		Method: "PUBLISH",
+		Events: []ical.Event{event},
+	}
+	return &email.Attachment{
+		Filename:    inviteFilename,
+		ContentType: inviteContentType,
+		Data:        calendar.Bytes(),
+	}, nil
+}
+
+// findPreference returns the user's preferences, or the defaults for users
+// who never saved any
+func (s *calendarService) findPreference(userID uint) (*models.UserPreference, error) {
+	preference, err := s.preferenceRepo.FindByUserID(userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		return DefaultPreference(userID, s.defaultDaysBefore), nil
+	}
+	return preference, nil
+}
+
+// renewalEvent is the calendar event for a subscription's end date. Alarms
+// follow the subscription's reminders, else the lead time notifications use.
+// Without a METHOD, DTSTAMP means the time of the last change, so the feed
+// reads the same until a subscription does change.
+func (s *calendarService) renewalEvent(subscription *models.Subscription, preference *models.UserPreference) ical.Event {
+	loc := subscription.LocationFor(preference)
+
+	event := ical.Event{
+		UID:          RenewalEventUID(subscription.ID, s.baseURL),
+		Stamp:        subscription.UpdatedAt,
+		Date:         subscription.EndDate.In(loc),
+		Summary:      RenewalEventSummary(subscription),
+		LastModified: subscription.UpdatedAt,
+	}
+	if subscription.PriceMinor > 0 {
+		event.Description = "Price: " + utils.FormatMoney(subscription.PriceMinor, subscription.Currency)
+	}
+
+	if !subscription.NotificationEnabled {
+		return event
+	}
+
+	var offsets []int
+	for _, reminder := range subscription.Reminders {
+		offsets = append(offsets, reminder.DaysBefore)
+	}
+	if len(offsets) == 0 {
+		leadDays := preference.DefaultDaysBefore
+		if subscription.IsShared() {
+			leadDays = s.defaultDaysBefore
+		}
+		offsets = []int{leadDays}
+	}
+
+	for _, days := range offsets {
+		event.Alarms = append(event.Alarms, ical.Alarm{
+			DaysBefore:  days,
+			Description: RenewalEventSummary(subscription),
+		})
+	}
+	return event
+}
+
+// RenewalEventUID identifies a subscription's renewal event across feeds
+// and invites, so clients update the event instead of duplicating it
+func RenewalEventUID(subscriptionID uint, baseURL string) string {
+	host := "renew-guard"
+	if parsed, err := url.Parse(baseURL); err == nil && parsed.Hostname() != "" {
+		host = parsed.Hostname()
+	}
+	return fmt.Sprintf("subscription-%d@%s", subscriptionID, host)
+}
+
+// RenewalEventSummary titles a subscription's renewal event
+func RenewalEventSummary(subscription *models.Subscription) string {
+	if subscription.AutoRenew {
+		return subscription.Name + " renews"
+	}
+	return subscription.Name + " expires"
+}
@@ -0,0 +1,240 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/ical"
+
+	"gorm.io/gorm"
+)
+
+type memoryCalendarFeedRepository struct {
+	feeds    []models.CalendarFeed
+	users    map[uint]models.User
+	accesses int
+}
+
+func (r *memoryCalendarFeedRepository) FindByUserID(userID uint) (*models.CalendarFeed, error) {
+	for i := range r.feeds {
+		if r.feeds[i].UserID == userID {
+			return &r.feeds[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryCalendarFeedRepository) FindByHash(tokenHash string) (*models.CalendarFeed, error) {
+	for _, feed := range r.feeds {
+		if feed.TokenHash == tokenHash {
+			feed.User = r.users[feed.UserID]
+			return &feed, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryCalendarFeedRepository) Replace(feed *models.CalendarFeed) error {
+	r.DeleteByUserID(feed.UserID)
+	feed.ID = uint(len(r.feeds) + 1)
+	r.feeds = append(r.feeds, *feed)
+	return nil
+}
+
+func (r *memoryCalendarFeedRepository) DeleteByUserID(userID uint) error {
+	for i := range r.feeds {
+		if r.feeds[i].UserID == userID {
+			r.feeds = append(r.feeds[:i], r.feeds[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryCalendarFeedRepository) UpdateLastAccessed(id uint, accessedAt time.Time) error {
+	for i := range r.feeds {
+		if r.feeds[i].ID == id {
+			r.feeds[i].LastAccessedAt = &accessedAt
+			r.accesses++
+		}
+	}
+	return nil
+}
+
+// FindEach hands over the subscriptions the user can see in one batch
+func (r *memorySubscriptionRepository) FindEach(userID uint, organizationID *uint, batchSize int, fn func(batch []models.Subscription) error) error {
+	var batch []models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID || subscription.IsShared() {
+			batch = append(batch, subscription)
+		}
+	}
+	return fn(batch)
+}
+
+type calendarHarness struct {
+	clock   *clock.Fake
+	feeds   *memoryCalendarFeedRepository
+	service CalendarService
+}
+
+// newCalendarHarness serves user 1, who prefers New York time and a
+// three-day lead, and user 2, whose account is disabled
+func newCalendarHarness(subscriptions ...models.Subscription) *calendarHarness {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	disabledAt := clk.Now().Add(-time.Hour)
+	feeds := &memoryCalendarFeedRepository{users: map[uint]models.User{
+		1: {ID: 1, Email: "owner@example.com"},
+		2: {ID: 2, Email: "disabled@example.com", DisabledAt: &disabledAt},
+	}}
+	preference := *DefaultPreference(1, 3)
+	preference.Timezone = "America/New_York"
+
+	return &calendarHarness{
+		clock: clk,
+		feeds: feeds,
+		service: NewCalendarService(
+			feeds,
+			&memorySubscriptionRepository{subscriptions: subscriptions},
+			&memoryPreferenceRepository{preferences: []models.UserPreference{preference}},
+			"https://renewguard.example",
+			7,
+			clk,
+		),
+	}
+}
+
+func (h *calendarHarness) rotate(t *testing.T, userID uint) string {
+	t.Helper()
+	_, rawToken, err := h.service.RotateFeed(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rawToken
+}
+
+func alarmDays(event ical.Event) []int {
+	var days []int
+	for _, alarm := range event.Alarms {
+		days = append(days, alarm.DaysBefore)
+	}
+	return days
+}
+
+func TestRenderBuildsRenewalEvents(t *testing.T) {
+	organizationID := uint(10)
+	updatedAt := time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)
+	h := newCalendarHarness(
+		models.Subscription{
+			ID: 1, UserID: 1, Name: "Netflix", AutoRenew: true, NotificationEnabled: true,
+			EndDate: time.Date(2024, 4, 1, 2, 0, 0, 0, time.UTC), UpdatedAt: updatedAt,
+			PriceMinor: 1299, Currency: "USD",
+		},
+		models.Subscription{
+			ID: 2, UserID: 1, Name: "Gym", NotificationEnabled: true, Timezone: "Asia/Tokyo",
+			EndDate:   time.Date(2024, 4, 1, 20, 0, 0, 0, time.UTC),
+			Reminders: []models.SubscriptionReminder{{DaysBefore: 14}, {DaysBefore: 1}},
+		},
+		models.Subscription{
+			ID: 3, UserID: 1, Name: "Hosting", NotificationEnabled: false,
+			EndDate: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		models.Subscription{
+			ID: 4, UserID: 5, Name: "Team chat", NotificationEnabled: true, OrganizationID: &organizationID,
+			EndDate: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+		},
+	)
+
+	calendar, err := h.service.Render(h.rotate(t, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendar.Events) != 4 {
+		t.Fatalf("rendered %d events, want 4", len(calendar.Events))
+	}
+
+	tests := []struct {
+		summary string
+		day     string
+		alarms  []int
+	}{
+		// 02:00 UTC is still the previous evening in New York
+		{"Netflix renews", "2024-03-31", []int{3}},
+		// The subscription's own zone wins over the owner's
+		{"Gym expires", "2024-04-02", []int{14, 1}},
+		{"Hosting expires", "2024-05-01", nil},
+		// Shared subscriptions use the service-wide lead time
+		{"Team chat expires", "2024-06-01", []int{7}},
+	}
+	for i, tt := range tests {
+		event := calendar.Events[i]
+		if event.Summary != tt.summary || event.Date.Format("2006-01-02") != tt.day {
+			t.Errorf("event %d: %q on %s, want %q on %s", i, event.Summary, event.Date.Format("2006-01-02"), tt.summary, tt.day)
+		}
+		if got := alarmDays(event); !reflect.DeepEqual(got, tt.alarms) {
+			t.Errorf("%s: alarms %v days before, want %v", tt.summary, got, tt.alarms)
+		}
+	}
+
+	netflix := calendar.Events[0]
+	if netflix.UID != "subscription-1@renewguard.example" || netflix.Description != "Price: USD 12.99" {
+		t.Errorf("event carries UID %q and description %q", netflix.UID, netflix.Description)
+	}
+	// Feeds stamp events with their last change so unchanged feeds read the same
+	if !netflix.Stamp.Equal(updatedAt) || !netflix.LastModified.Equal(updatedAt) {
+		t.Errorf("stamped %s, modified %s; want %s", netflix.Stamp, netflix.LastModified, updatedAt)
+	}
+}
+
+func TestRenderRejectsUnknownAndDisabledFeeds(t *testing.T) {
+	h := newCalendarHarness()
+	first := h.rotate(t, 1)
+	current := h.rotate(t, 1)
+	disabled := h.rotate(t, 2)
+
+	for name, token := range map[string]string{
+		"unknown token":  "not-a-token",
+		"rotated token":  first,
+		"disabled owner": disabled,
+	} {
+		if _, err := h.service.Render(token); !errors.Is(err, ErrInvalidCalendarToken) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrInvalidCalendarToken)
+		}
+	}
+	if _, err := h.service.Render(current); err != nil {
+		t.Errorf("current token: %v", err)
+	}
+
+	if err := h.service.DeleteFeed(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.service.Render(current); !errors.Is(err, ErrInvalidCalendarToken) {
+		t.Errorf("deleted feed: error = %v, want %v", err, ErrInvalidCalendarToken)
+	}
+	if err := h.service.DeleteFeed(1); !errors.Is(err, ErrCalendarFeedNotFound) {
+		t.Errorf("second delete: error = %v, want %v", err, ErrCalendarFeedNotFound)
+	}
+}
+
+func TestRenderRecordsAccessOncePerInterval(t *testing.T) {
+	h := newCalendarHarness()
+	token := h.rotate(t, 1)
+
+	for i := 0; i < 3; i++ {
+		if _, err := h.service.Render(token); err != nil {
+			t.Fatal(err)
+		}
+		h.clock.Advance(20 * time.Second)
+	}
+	h.clock.Advance(lastUsedResolution)
+	if _, err := h.service.Render(token); err != nil {
+		t.Fatal(err)
+	}
+	if h.feeds.accesses != 2 {
+		t.Errorf("last access written %d times, want 2", h.feeds.accesses)
+	}
+}
@@ -1,108 +1,631 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"renew-guard/internal/models"
 	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
 	"renew-guard/pkg/email"
+	"renew-guard/pkg/utils"
+	"sort"
 	"time"
+
+	"gorm.io/gorm"
 )
 
+// ErrNotificationSkipped is returned when a notification was due but every
+// channel was skipped on purpose, e.g. the address is unverified
+var ErrNotificationSkipped = errors.New("notification skipped on every channel")
+
+// errUnverifiedRecipient is recorded when mail is withheld from an unverified address
+var errUnverifiedRecipient = errors.New("recipient email address is not verified")
+
 type NotificationService interface {
 	CheckAndSendNotifications(daysBefore int) error
+	SendDigests(daysBefore int) error
 	SendExpirationWarning(subscription *models.Subscription) error
+	GetLogs(subscriptionID uint, status string) ([]models.NotificationLog, error)
 }
 
 type notificationService struct {
 	subscriptionRepo repositories.SubscriptionRepository
 	notificationRepo repositories.NotificationLogRepository
-	emailService     email.EmailService
+	reminderRepo     repositories.SubscriptionReminderRepository
+	preferenceRepo   repositories.UserPreferenceRepository
+	organizationRepo repositories.OrganizationRepository
+	outboxService    OutboxService
+	calendarService  CalendarService
+	clock            clock.Clock
 }
 
 func NewNotificationService(
 	subscriptionRepo repositories.SubscriptionRepository,
 	notificationRepo repositories.NotificationLogRepository,
-	emailService email.EmailService,
+	reminderRepo repositories.SubscriptionReminderRepository,
+	preferenceRepo repositories.UserPreferenceRepository,
+	organizationRepo repositories.OrganizationRepository,
+	outboxService OutboxService,
+	calendarService CalendarService,
+	clk clock.Clock,
 ) NotificationService {
 	return &notificationService{
 		subscriptionRepo: subscriptionRepo,
 		notificationRepo: notificationRepo,
-		emailService:     emailService,
+		reminderRepo:     reminderRepo,
+		preferenceRepo:   preferenceRepo,
+		organizationRepo: organizationRepo,
+		outboxService:    outboxService,
+		calendarService:  calendarService,
+		clock:            clk,
 	}
 }
 
+// CheckAndSendNotifications sends reminders for subscriptions nearing expiration.
+// Subscriptions with their own reminder offsets get one mail per offset; the
+// rest fall back to a daily mail within the owner's preferred lead time, or
+// daysBefore for users without stored preferences. Users in digest mode are
+// left to SendDigests. Shared subscriptions use daysBefore as their lead time
+// and always go out immediately, to every member with a notified role.
 func (s *notificationService) CheckAndSendNotifications(daysBefore int) error {
-	log.Printf("Checking for subscriptions expiring within %d days...", daysBefore)
+	now := s.clock.Now()
 
-	// Find all subscriptions that need notification
-	subscriptions, err := s.subscriptionRepo.FindExpiringSubscriptions(daysBefore)
+	subscriptions, preferences, err := s.findExpiring(now, daysBefore)
 	if err != nil {
-		return fmt.Errorf("failed to find expiring subscriptions: %w", err)
+		return err
 	}
 
-	log.Printf("Found %d subscription(s) requiring notification", len(subscriptions))
-
-	sentCount := 0
+	queuedCount := 0
+	skippedCount := 0
 	failedCount := 0
 
 	for _, subscription := range subscriptions {
-		// Check if should notify (includes daily check)
-		if subscription.ShouldNotify(daysBefore) {
-			if err := s.SendExpirationWarning(&subscription); err != nil {
-				log.Printf("Failed to send notification for subscription %d: %v", subscription.ID, err)
-				failedCount++
-			} else {
-				sentCount++
+		preference := preferences[subscription.UserID]
+		leadDays := preference.DefaultDaysBefore
+		if subscription.IsShared() {
+			leadDays = daysBefore
+		} else {
+			if preference.NotificationMode == models.NotificationModeDigest {
+				continue // Covered by SendDigests
+			}
+			if len(subscription.NotificationChannels(preference)) == 0 {
+				continue
+			}
+		}
+
+		// Day counts follow the subscription's zone, else the owner's
+		loc := subscription.LocationFor(preference)
+
+		var err error
+		if len(subscription.Reminders) > 0 {
+			var due bool
+			if due, err = s.sendDueReminder(&subscription, preference, now, loc); !due {
+				continue
+			}
+		} else if subscription.ShouldNotify(now, leadDays, loc) {
+			err = s.sendWarning(&subscription, preference, nil, now, loc)
+		} else {
+			continue
+		}
+
+		switch {
+		case err == nil:
+			queuedCount++
+		case errors.Is(err, ErrNotificationSkipped):
+			skippedCount++
+		default:
+			log.Printf("Failed to queue notification for subscription %d: %v", subscription.ID, err)
+			failedCount++
+		}
+	}
+
+	log.Printf("Notification run complete: %d queued, %d skipped, %d failed", queuedCount, skippedCount, failedCount)
+	return nil
+}
+
+// digestKey identifies one digest: a user and the address their subscriptions mail to
+type digestKey struct {
+	userID uint
+	email  string
+}
+
+// digestEntry is a subscription due in a digest along with the reminders it fires
+type digestEntry struct {
+	subscription *models.Subscription
+	loc          *time.Location
+	due          []models.SubscriptionReminder
+}
+
+// SendDigests groups the reminders due for users in digest mode into a single
+// message per recipient and channel, honouring each user's daily or weekly
+// frequency. A subscription is included on the days it would have been
+// mailed on its own, on its own channels if it overrides the preference.
+// Shared subscriptions are never part of a personal digest.
+func (s *notificationService) SendDigests(daysBefore int) error {
+	now := s.clock.Now()
+
+	subscriptions, preferences, err := s.findExpiring(now, daysBefore)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[digestKey][]digestEntry)
+	var keys []digestKey
+
+	for i := range subscriptions {
+		subscription := &subscriptions[i]
+		preference := preferences[subscription.UserID]
+		if subscription.IsShared() {
+			continue
+		}
+		if preference.NotificationMode != models.NotificationModeDigest || len(subscription.NotificationChannels(preference)) == 0 {
+			continue
+		}
+		if !preference.DigestDue(now, models.ResolveLocation(preference.Timezone)) {
+			continue
+		}
+
+		loc := subscription.LocationFor(preference)
+		entry := digestEntry{subscription: subscription, loc: loc}
+
+		if len(subscription.Reminders) > 0 {
+			entry.due = subscription.DueReminders(now, loc)
+			if len(entry.due) == 0 {
+				continue
+			}
+		} else if !subscription.ShouldNotify(now, preference.DefaultDaysBefore, loc) {
+			continue
+		}
+
+		key := digestKey{userID: subscription.UserID, email: subscription.Email}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], entry)
+	}
+
+	queuedCount := 0
+	skippedCount := 0
+	failedCount := 0
+
+	for _, key := range keys {
+		err := s.sendDigest(key, preferences[key.userID], groups[key], now)
+		switch {
+		case err == nil:
+			queuedCount++
+		case errors.Is(err, ErrNotificationSkipped):
+			skippedCount++
+		default:
+			log.Printf("Failed to queue digest for user %d: %v", key.userID, err)
+			failedCount++
+		}
+	}
+
+	log.Printf("Digest run complete: %d queued, %d skipped, %d failed", queuedCount, skippedCount, failedCount)
+	return nil
+}
+
+// sendDigest queues one digest per channel, each listing the subscriptions
+// that notify on that channel, and marks every subscription as notified.
+// Skipped channels count as handled too, so a skip is logged once per digest
+// rather than on every run; ErrNotificationSkipped is returned if nothing was
+// queued.
+func (s *notificationService) sendDigest(key digestKey, preference *models.UserPreference, entries []digestEntry, now time.Time) error {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].subscription.EndDate.Before(entries[j].subscription.EndDate)
+	})
+
+	// Group entries by the channels they resolve to, keeping the order in
+	// which channels first appear
+	var channels []string
+	byChannel := make(map[string][]digestEntry)
+	for _, entry := range entries {
+		for _, channel := range entry.subscription.NotificationChannels(preference) {
+			if _, ok := byChannel[channel]; !ok {
+				channels = append(channels, channel)
+			}
+			byChannel[channel] = append(byChannel[channel], entry)
+		}
+	}
+
+	queued := 0
+	for _, channel := range channels {
+		group := byChannel[channel]
+		recipient := key.email
+		if channel != models.NotificationChannelEmail {
+			recipient = preference.WebhookURLFor(channel)
+		}
+		if recipient == "" {
+			log.Printf("Skipping %s digest for user %d: channel not configured", channel, key.userID)
+			continue
+		}
+		if channel == models.NotificationChannelEmail && !group[0].subscription.User.HasVerifiedEmail(recipient) {
+			for _, entry := range group {
+				s.logSkipped(entry.subscription.ID, channel, nil, true, errUnverifiedRecipient)
 			}
+			continue
 		}
+
+		if err := s.outboxService.Enqueue(digestMessage(channel, recipient, group, now)); err != nil {
+			return fmt.Errorf("failed to queue %s digest: %w", channel, err)
+		}
+		queued++
 	}
 
-	log.Printf("Notification run complete: %d sent, %d failed", sentCount, failedCount)
+	for _, entry := range entries {
+		subscription := entry.subscription
+		if len(entry.due) > 0 {
+			ids := make([]uint, 0, len(entry.due))
+			for _, reminder := range entry.due {
+				ids = append(ids, reminder.ID)
+			}
+			if err := s.reminderRepo.MarkSent(ids, subscription.EndDate); err != nil {
+				log.Printf("Failed to mark reminders sent for subscription %d: %v", subscription.ID, err)
+			}
+		}
+		if err := s.subscriptionRepo.UpdateLastNotificationSent(subscription.ID, now); err != nil {
+			log.Printf("Failed to update last notification sent: %v", err)
+		}
+	}
+
+	if err := s.preferenceRepo.UpdateLastDigestSent(key.userID, now); err != nil {
+		log.Printf("Failed to update last digest sent for user %d: %v", key.userID, err)
+	}
+
+	if queued == 0 {
+		return ErrNotificationSkipped
+	}
+
+	log.Printf("Digest of %d subscription(s) queued for user %d on %d channel(s)",
+		len(entries), key.userID, queued)
+
 	return nil
 }
 
+// digestMessage builds the outbox message of a digest covering entries,
+// which are already in end date order
+func digestMessage(channel, recipient string, entries []digestEntry, now time.Time) *models.OutboxMessage {
+	items := make([]email.DigestItem, 0, len(entries))
+	subscriptionIDs := make([]uint, 0, len(entries))
+	rows := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		subscription := entry.subscription
+		amount := ""
+		if subscription.PriceMinor > 0 {
+			amount = utils.FormatMoney(subscription.PriceMinor, subscription.Currency)
+		}
+		item := email.DigestItem{
+			Name:     subscription.Name,
+			EndDate:  subscription.EndDate.In(entry.loc),
+			DaysLeft: subscription.DaysUntilExpiration(now, entry.loc),
+			Amount:   amount,
+		}
+		items = append(items, item)
+		subscriptionIDs = append(subscriptionIDs, subscription.ID)
+		rows = append(rows, map[string]interface{}{
+			"subscription_id": subscription.ID,
+			"name":            item.Name,
+			"end_date":        item.EndDate.Format(time.RFC3339),
+			"days_left":       item.DaysLeft,
+			"amount":          amount,
+		})
+	}
+
+	return &models.OutboxMessage{
+		SubscriptionIDs: subscriptionIDs,
+		Channel:         channel,
+		Recipient:       recipient,
+		Subject:         email.GetDigestSubject(len(items)),
+		HTMLBody:        email.GetDigestTemplate(items),
+		TextBody:        email.GetDigestText(items),
+		Data: map[string]interface{}{
+			"event":         "subscriptions.digest",
+			"subscriptions": rows,
+		},
+	}
+}
+
+// findExpiring loads the subscriptions that may need a reminder at now along
+// with their owners' preferences. The search window is widened to cover the
+// furthest reminder offset or lead time.
+func (s *notificationService) findExpiring(now time.Time, daysBefore int) ([]models.Subscription, map[uint]*models.UserPreference, error) {
+	window := daysBefore
+	maxReminderDays, err := s.reminderRepo.MaxDaysBefore()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load reminder offsets: %w", err)
+	}
+	if maxReminderDays > window {
+		window = maxReminderDays
+	}
+	maxLeadDays, err := s.preferenceRepo.MaxDefaultDaysBefore()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	if maxLeadDays > window {
+		window = maxLeadDays
+	}
+
+	log.Printf("Checking for subscriptions expiring within %d days...", window)
+
+	subscriptions, err := s.subscriptionRepo.FindExpiringSubscriptions(now, window)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find expiring subscriptions: %w", err)
+	}
+
+	// Disabled accounts get no reminders for their own subscriptions; shared
+	// ones still reach the organization's other members
+	active := subscriptions[:0]
+	for _, subscription := range subscriptions {
+		if subscription.IsShared() || !subscription.User.IsDisabled() {
+			active = append(active, subscription)
+		}
+	}
+	subscriptions = active
+
+	log.Printf("Found %d subscription(s) requiring notification", len(subscriptions))
+
+	preferences, err := s.loadPreferences(subscriptions, daysBefore)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+
+	return subscriptions, preferences, nil
+}
+
+// loadPreferences returns the notification preferences of every subscription
+// owner, falling back to defaults for users who never saved any
+func (s *notificationService) loadPreferences(subscriptions []models.Subscription, daysBefore int) (map[uint]*models.UserPreference, error) {
+	userIDs := make([]uint, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		userIDs = append(userIDs, subscription.UserID)
+	}
+
+	stored, err := s.preferenceRepo.FindByUserIDs(userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[uint]*models.UserPreference, len(userIDs))
+	for i := range stored {
+		preferences[stored[i].UserID] = &stored[i]
+	}
+	for _, userID := range userIDs {
+		if _, ok := preferences[userID]; !ok {
+			preferences[userID] = DefaultPreference(userID, daysBefore)
+		}
+	}
+
+	return preferences, nil
+}
+
+// sendDueReminder fires the closest due reminder offset and reports whether
+// one was due. When several offsets are due at once (e.g. the subscription was
+// added late) only one mail is sent and the others are marked as sent for this
+// period. A skipped reminder is marked as sent too, so it is skipped once.
+func (s *notificationService) sendDueReminder(subscription *models.Subscription, preference *models.UserPreference, now time.Time, loc *time.Location) (bool, error) {
+	due := subscription.DueReminders(now, loc)
+	if len(due) == 0 {
+		return false, nil
+	}
+
+	daysBefore := due[0].DaysBefore
+	err := s.sendWarning(subscription, preference, &daysBefore, now, loc)
+	if err != nil && !errors.Is(err, ErrNotificationSkipped) {
+		return true, err
+	}
+
+	ids := make([]uint, 0, len(due))
+	for _, reminder := range due {
+		ids = append(ids, reminder.ID)
+	}
+	if err := s.reminderRepo.MarkSent(ids, subscription.EndDate); err != nil {
+		log.Printf("Failed to mark reminders sent for subscription %d: %v", subscription.ID, err)
+	}
+
+	return true, err
+}
+
 func (s *notificationService) SendExpirationWarning(subscription *models.Subscription) error {
-	daysLeft := subscription.DaysUntilExpiration()
+	preference, err := s.preferenceRepo.FindByUserID(subscription.UserID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load notification preferences: %w", err)
+		}
+		// Lead time is irrelevant for a one-off warning
+		preference = DefaultPreference(subscription.UserID, 0)
+	}
 
-	// Generate email content
-	subject := email.GetExpirationWarningSubject(subscription.Name, daysLeft)
-	htmlBody := email.GetExpirationWarningTemplate(subscription.Name, daysLeft, subscription.EndDate)
+	return s.sendWarning(subscription, preference, nil, s.clock.Now(), subscription.LocationFor(preference))
+}
 
-	// Send email using the email stored with the subscription
-	err := s.emailService.SendHTML(subscription.Email, subject, htmlBody)
+// sendWarning queues the expiration warning in the outbox once per channel,
+// recording which reminder offset fired if any. Dates are rendered in loc.
+// Emails carry a calendar invite. Delivery, retries and notification logs
+// are handled by the outbox worker. If every channel is skipped the warning
+// still counts as sent, so the skip is logged once, and ErrNotificationSkipped
+// is returned.
+func (s *notificationService) sendWarning(subscription *models.Subscription, preference *models.UserPreference, daysBefore *int, now time.Time, loc *time.Location) error {
+	daysLeft := subscription.DaysUntilExpiration(now, loc)
+	endDate := subscription.EndDate.In(loc)
 
-	// Create notification log
-	notificationLog := &models.NotificationLog{
-		SubscriptionID: subscription.ID,
-		Status:         "success",
+	// Generate message content
+	subject := email.GetExpirationWarningSubject(subscription.Name, daysLeft)
+	amount := ""
+	if subscription.PriceMinor > 0 {
+		amount = utils.FormatMoney(subscription.PriceMinor, subscription.Currency)
+	}
+	htmlBody := email.GetExpirationWarningTemplate(subscription.Name, daysLeft, endDate, amount)
+	textBody := email.GetExpirationWarningText(subscription.Name, daysLeft, endDate, amount)
+	data := map[string]interface{}{
+		"event":           "subscription.expiring",
+		"subscription_id": subscription.ID,
+		"name":            subscription.Name,
+		"end_date":        endDate.Format(time.RFC3339),
+		"days_left":       daysLeft,
+		"amount":          amount,
 	}
 
+	targets, err := s.warningTargets(subscription, preference)
 	if err != nil {
-		notificationLog.Status = "failed"
-		notificationLog.ErrorMessage = err.Error()
-		
-		// Still log the failed attempt
-		if logErr := s.notificationRepo.Create(notificationLog); logErr != nil {
-			log.Printf("Failed to create notification log: %v", logErr)
+		return err
+	}
+
+	// Emails carry an invite so the renewal can be added to a calendar
+	var attachments []models.OutboxAttachment
+	if invite, err := s.calendarService.Invite(subscription, preference); err != nil {
+		log.Printf("Failed to build calendar invite for subscription %d: %v", subscription.ID, err)
+	} else {
+		attachments = []models.OutboxAttachment{{
+			Filename:    invite.Filename,
+			ContentType: invite.ContentType,
+			Data:        invite.Data,
+		}}
+	}
+
+	queued := 0
+	for _, target := range targets {
+		channel := target.channel
+		if target.recipient == "" {
+			log.Printf("Skipping %s notification for subscription %d: channel not configured", channel, subscription.ID)
+			continue
 		}
-		
-		return fmt.Errorf("failed to send email to %s: %w", subscription.Email, err)
+		if channel == models.NotificationChannelEmail && !target.user.HasVerifiedEmail(target.recipient) {
+			s.logSkipped(subscription.ID, channel, daysBefore, false, errUnverifiedRecipient)
+			continue
+		}
+
+		message := &models.OutboxMessage{
+			SubscriptionID: &subscription.ID,
+			DaysBefore:     daysBefore,
+			Channel:        channel,
+			Recipient:      target.recipient,
+			Subject:        subject,
+			HTMLBody:       htmlBody,
+			TextBody:       textBody,
+			Data:           data,
+		}
+		if channel == models.NotificationChannelEmail {
+			message.Attachments = attachments
+		}
+		if err := s.outboxService.Enqueue(message); err != nil {
+			return fmt.Errorf("failed to queue %s notification: %w", channel, err)
+		}
+		queued++
 	}
 
-	// Update last notification sent timestamp
-	now := time.Now()
+	// Update last notification sent timestamp so the reminder is not queued twice
 	if err := s.subscriptionRepo.UpdateLastNotificationSent(subscription.ID, now); err != nil {
 		log.Printf("Failed to update last notification sent: %v", err)
 	}
 
-	// Create success log
-	if err := s.notificationRepo.Create(notificationLog); err != nil {
-		log.Printf("Failed to create notification log: %v", err)
+	if queued == 0 {
+		return ErrNotificationSkipped
 	}
 
-	log.Printf("Notification sent successfully for subscription %d (%s) to %s", 
-		subscription.ID, subscription.Name, subscription.Email)
+	log.Printf("Notification queued for subscription %d (%s) on %d channel(s)",
+		subscription.ID, subscription.Name, queued)
 
 	return nil
 }
+
+// warningTarget is one destination of an expiration warning
+type warningTarget struct {
+	channel   string
+	recipient string       // Email address or webhook URL; empty if the channel is not configured
+	user      *models.User // Whose verified address an email recipient must be
+}
+
+// warningTargets works out where a warning goes. Email goes to the address
+// stored with the subscription and webhooks to the owner's URLs; a shared
+// subscription instead reaches every member holding one of its notified
+// roles, on the subscription's channels or else each member's own. Disabled
+// accounts are never targeted.
+func (s *notificationService) warningTargets(subscription *models.Subscription, preference *models.UserPreference) ([]warningTarget, error) {
+	if !subscription.IsShared() {
+		if subscription.User.IsDisabled() {
+			return nil, nil
+		}
+		channels := subscription.NotificationChannels(preference)
+		targets := make([]warningTarget, 0, len(channels))
+		for _, channel := range channels {
+			recipient := subscription.Email
+			if channel != models.NotificationChannelEmail {
+				recipient = preference.WebhookURLFor(channel)
+			}
+			targets = append(targets, warningTarget{channel: channel, recipient: recipient, user: &subscription.User})
+		}
+		return targets, nil
+	}
+
+	members, err := s.organizationRepo.FindMembersByRoles(*subscription.OrganizationID, subscription.ReminderRoles())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load organization members: %w", err)
+	}
+
+	userIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		userIDs = append(userIDs, member.UserID)
+	}
+	stored, err := s.preferenceRepo.FindByUserIDs(userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+	preferences := make(map[uint]*models.UserPreference, len(stored))
+	for i := range stored {
+		preferences[stored[i].UserID] = &stored[i]
+	}
+
+	// Members sharing a webhook get a single delivery
+	seen := make(map[warningTarget]bool)
+	var targets []warningTarget
+	for i := range members {
+		member := &members[i]
+		if member.User.IsDisabled() {
+			continue
+		}
+		memberPreference, ok := preferences[member.UserID]
+		if !ok {
+			memberPreference = DefaultPreference(member.UserID, 0)
+		}
+
+		for _, channel := range subscription.NotificationChannels(memberPreference) {
+			recipient := member.User.Email
+			if channel != models.NotificationChannelEmail {
+				recipient = memberPreference.WebhookURLFor(channel)
+			}
+			key := warningTarget{channel: channel, recipient: recipient}
+			if recipient != "" && seen[key] {
+				continue
+			}
+			seen[key] = true
+			targets = append(targets, warningTarget{channel: channel, recipient: recipient, user: &member.User})
+		}
+	}
+
+	return targets, nil
+}
+
+// logSkipped records a notification that was deliberately not sent
+func (s *notificationService) logSkipped(subscriptionID uint, channel string, daysBefore *int, digest bool, reason error) {
+	log.Printf("Skipping %s notification for subscription %d: %v", channel, subscriptionID, reason)
+
+	notificationLog := &models.NotificationLog{
+		SubscriptionID: subscriptionID,
+		Channel:        channel,
+		Status:         models.NotificationStatusSkipped,
+		ErrorMessage:   reason.Error(),
+		DaysBefore:     daysBefore,
+		Digest:         digest,
+	}
+	if err := s.notificationRepo.Create(notificationLog); err != nil {
+		log.Printf("Failed to create notification log: %v", err)
+	}
+}
+
+func (s *notificationService) GetLogs(subscriptionID uint, status string) ([]models.NotificationLog, error) {
+	if status == "" {
+		return s.notificationRepo.FindBySubscriptionID(subscriptionID)
+	}
+	return s.notificationRepo.FindBySubscriptionIDAndStatus(subscriptionID, status)
+}
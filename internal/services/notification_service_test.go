@@ -0,0 +1,427 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+
+	"gorm.io/gorm"
+)
+
+// memoryReminderRepository keeps reminder offsets on the subscriptions of a
+// memorySubscriptionRepository
+type memoryReminderRepository struct {
+	repositories.SubscriptionReminderRepository
+	subscriptions *memorySubscriptionRepository
+}
+
+func (r *memoryReminderRepository) MaxDaysBefore() (int, error) {
+	max := 0
+	for _, subscription := range r.subscriptions.subscriptions {
+		for _, reminder := range subscription.Reminders {
+			if reminder.DaysBefore > max {
+				max = reminder.DaysBefore
+			}
+		}
+	}
+	return max, nil
+}
+
+func (r *memoryReminderRepository) MarkSent(ids []uint, endDate time.Time) error {
+	for i := range r.subscriptions.subscriptions {
+		reminders := r.subscriptions.subscriptions[i].Reminders
+		for j := range reminders {
+			for _, id := range ids {
+				if reminders[j].ID == id {
+					sentFor := endDate
+					reminders[j].LastSentForEndDate = &sentFor
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type memoryPreferenceRepository struct {
+	repositories.UserPreferenceRepository
+	preferences []models.UserPreference
+}
+
+func (r *memoryPreferenceRepository) FindByUserID(userID uint) (*models.UserPreference, error) {
+	for i := range r.preferences {
+		if r.preferences[i].UserID == userID {
+			return &r.preferences[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryPreferenceRepository) FindByUserIDs(userIDs []uint) ([]models.UserPreference, error) {
+	var found []models.UserPreference
+	for _, preference := range r.preferences {
+		for _, userID := range userIDs {
+			if preference.UserID == userID {
+				found = append(found, preference)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryPreferenceRepository) UpdateLastDigestSent(userID uint, sentAt time.Time) error {
+	for i := range r.preferences {
+		if r.preferences[i].UserID == userID {
+			r.preferences[i].LastDigestSentAt = &sentAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryPreferenceRepository) MaxDefaultDaysBefore() (int, error) {
+	max := 0
+	for _, preference := range r.preferences {
+		if preference.DefaultDaysBefore > max {
+			max = preference.DefaultDaysBefore
+		}
+	}
+	return max, nil
+}
+
+// queuedWarning is a message enqueued by the notification service and the
+// time it was queued at
+type queuedWarning struct {
+	message  *models.OutboxMessage
+	queuedAt time.Time
+}
+
+type recordingOutboxService struct {
+	OutboxService
+	clock  clock.Clock
+	queued []queuedWarning
+}
+
+func (s *recordingOutboxService) Enqueue(message *models.OutboxMessage) error {
+	s.queued = append(s.queued, queuedWarning{message: message, queuedAt: s.clock.Now()})
+	return nil
+}
+
+// queuedDays returns the local dates warnings were queued on, with the
+// reminder offset that fired if any
+func (s *recordingOutboxService) queuedDays(loc *time.Location) []string {
+	var days []string
+	for _, warning := range s.queued {
+		day := warning.queuedAt.In(loc).Format("2006-01-02")
+		if warning.message.DaysBefore != nil {
+			day += fmt.Sprintf(" (%d days)", *warning.message.DaysBefore)
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+type memoryOrganizationRepository struct {
+	repositories.OrganizationRepository
+	organizations []models.Organization
+	members       []models.OrganizationMember
+}
+
+func (r *memoryOrganizationRepository) FindMembersByRoles(organizationID uint, roles []string) ([]models.OrganizationMember, error) {
+	var found []models.OrganizationMember
+	for _, member := range r.members {
+		for _, role := range roles {
+			if member.OrganizationID == organizationID && member.Role == role {
+				found = append(found, member)
+				break
+			}
+		}
+	}
+	return found, nil
+}
+
+// memoryNotificationLogRepository keeps the logs written outside the outbox,
+// i.e. skipped notifications
+type memoryNotificationLogRepository struct {
+	repositories.NotificationLogRepository
+	logs []models.NotificationLog
+}
+
+func (r *memoryNotificationLogRepository) Create(log *models.NotificationLog) error {
+	r.logs = append(r.logs, *log)
+	return nil
+}
+
+type stubCalendarService struct {
+	CalendarService
+}
+
+func (stubCalendarService) Invite(subscription *models.Subscription, preference *models.UserPreference) (*email.Attachment, error) {
+	return &email.Attachment{Filename: "renewal.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR")}, nil
+}
+
+type notificationHarness struct {
+	clock         *clock.Fake
+	subscriptions *memorySubscriptionRepository
+	organizations *memoryOrganizationRepository
+	outbox        *recordingOutboxService
+	logs          *memoryNotificationLogRepository
+	notifications NotificationService
+	renewals      RenewalService
+}
+
+func newNotificationHarness(start time.Time, subscriptions []models.Subscription, preferences []models.UserPreference) *notificationHarness {
+	clk := clock.NewFake(start)
+	subscriptionRepo := &memorySubscriptionRepository{subscriptions: subscriptions}
+	organizationRepo := &memoryOrganizationRepository{}
+	outbox := &recordingOutboxService{clock: clk}
+	logs := &memoryNotificationLogRepository{}
+	return &notificationHarness{
+		clock:         clk,
+		subscriptions: subscriptionRepo,
+		organizations: organizationRepo,
+		outbox:        outbox,
+		logs:          logs,
+		notifications: NewNotificationService(
+			subscriptionRepo,
+			logs,
+			&memoryReminderRepository{subscriptions: subscriptionRepo},
+			&memoryPreferenceRepository{preferences: preferences},
+			organizationRepo,
+			outbox,
+			stubCalendarService{},
+			clk,
+		),
+		renewals: NewRenewalService(subscriptionRepo, clk),
+	}
+}
+
+// runHourly drives the renewal and notification jobs once an hour until end
+func (h *notificationHarness) runHourly(t *testing.T, end time.Time, daysBefore int) {
+	t.Helper()
+	for ; h.clock.Now().Before(end); h.clock.Advance(time.Hour) {
+		if err := h.renewals.ProcessRenewals(); err != nil {
+			t.Fatal(err)
+		}
+		if err := h.notifications.CheckAndSendNotifications(daysBefore); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func verifiedUser(id uint, address string) models.User {
+	verifiedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return models.User{ID: id, Email: address, EmailVerifiedAt: &verifiedAt}
+}
+
+func TestCheckAndSendNotificationsDailyInOwnerZone(t *testing.T) {
+	sydney, err := time.LoadLocation("Australia/Sydney")
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := time.Date(2024, 3, 5, 12, 0, 0, 0, sydney)
+	subscription := models.Subscription{
+		ID:                  1,
+		UserID:              1,
+		User:                verifiedUser(1, "owner@example.com"),
+		Email:               "owner@example.com",
+		Name:                "Streaming",
+		EndDate:             end.UTC(),
+		NotificationEnabled: true,
+	}
+	preference := *DefaultPreference(1, 3)
+	preference.Timezone = "Australia/Sydney"
+
+	h := newNotificationHarness(end.AddDate(0, 0, -10), []models.Subscription{subscription}, []models.UserPreference{preference})
+	h.runHourly(t, end.Add(24*time.Hour), 7)
+
+	// One warning per Sydney day within the owner's three day lead time,
+	// rather than per UTC day or within the scheduler's default of seven
+	want := []string{"2024-03-02", "2024-03-03", "2024-03-04", "2024-03-05"}
+	if got := h.outbox.queuedDays(sydney); !reflect.DeepEqual(got, want) {
+		t.Errorf("warnings queued on %v, want %v", got, want)
+	}
+	for _, warning := range h.outbox.queued {
+		if warning.message.Recipient != "owner@example.com" || len(warning.message.Attachments) != 1 {
+			t.Errorf("warning to %q with %d attachments", warning.message.Recipient, len(warning.message.Attachments))
+		}
+	}
+}
+
+func TestCheckAndSendNotificationsRemindersAcrossRenewals(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscription := models.Subscription{
+		ID:                  1,
+		UserID:              1,
+		User:                verifiedUser(1, "owner@example.com"),
+		Email:               "owner@example.com",
+		Name:                "Hosting",
+		StartDate:           time.Date(2024, 1, 31, 9, 0, 0, 0, newYork),
+		BillingCycle:        models.BillingCycleMonthly,
+		BillingInterval:     1,
+		BillingUnit:         models.BillingUnitMonth,
+		BillingAnchorDay:    31,
+		Timezone:            "America/New_York",
+		AutoRenew:           true,
+		NotificationEnabled: true,
+		Reminders: []models.SubscriptionReminder{
+			{ID: 1, SubscriptionID: 1, DaysBefore: 7},
+			{ID: 2, SubscriptionID: 1, DaysBefore: 1},
+		},
+	}
+	subscription.ComputeEndDate()
+
+	h := newNotificationHarness(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), []models.Subscription{subscription}, nil)
+	h.runHourly(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), 3)
+
+	// Each offset fires once per period, on the subscription's local dates:
+	// the February period ends on the 29th and the March one, after the
+	// switch to daylight saving, on the 31st
+	want := []string{
+		"2024-02-22 (7 days)", "2024-02-28 (1 days)",
+		"2024-03-24 (7 days)", "2024-03-30 (1 days)",
+	}
+	if got := h.outbox.queuedDays(newYork); !reflect.DeepEqual(got, want) {
+		t.Errorf("reminders queued on %v, want %v", got, want)
+	}
+	for _, warning := range h.outbox.queued {
+		if local := warning.queuedAt.In(newYork); local.Hour() != 0 {
+			t.Errorf("reminder queued at %s, want the first run of the local day", local)
+		}
+	}
+	if len(h.subscriptions.renewals) != 2 {
+		t.Errorf("%d renewals, want 2", len(h.subscriptions.renewals))
+	}
+}
+
+func TestUnverifiedOwnerIsSkippedOncePerPeriod(t *testing.T) {
+	end := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	daily := models.Subscription{
+		ID:                  1,
+		UserID:              1,
+		User:                models.User{ID: 1, Email: "owner@example.com"},
+		Email:               "owner@example.com",
+		Name:                "Streaming",
+		EndDate:             end,
+		NotificationEnabled: true,
+	}
+	reminded := daily
+	reminded.ID = 2
+	reminded.Name = "Hosting"
+	reminded.Reminders = []models.SubscriptionReminder{{ID: 1, SubscriptionID: 2, DaysBefore: 2}}
+
+	h := newNotificationHarness(end.AddDate(0, 0, -3), []models.Subscription{daily, reminded}, nil)
+	h.runHourly(t, end, 3)
+
+	if len(h.outbox.queued) != 0 {
+		t.Errorf("%d warnings queued to an unverified address", len(h.outbox.queued))
+	}
+
+	// One skip per day from March 2 to 5 for the daily warning and one for
+	// the reminder, rather than one on every hourly run
+	skipped := make(map[uint]int)
+	for _, entry := range h.logs.logs {
+		if entry.Status != models.NotificationStatusSkipped {
+			t.Errorf("log with status %q, want %q", entry.Status, models.NotificationStatusSkipped)
+		}
+		skipped[entry.SubscriptionID]++
+	}
+	if skipped[1] != 4 || skipped[2] != 1 {
+		t.Errorf("skips per subscription = %v, want 4 daily and 1 reminder", skipped)
+	}
+}
+
+func TestUnverifiedDigestIsSkippedOncePerDigest(t *testing.T) {
+	end := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	subscription := models.Subscription{
+		ID:                  1,
+		UserID:              1,
+		User:                models.User{ID: 1, Email: "owner@example.com"},
+		Email:               "owner@example.com",
+		Name:                "Streaming",
+		EndDate:             end,
+		NotificationEnabled: true,
+	}
+	preference := *DefaultPreference(1, 3)
+	preference.NotificationMode = models.NotificationModeDigest
+
+	h := newNotificationHarness(end.AddDate(0, 0, -1), []models.Subscription{subscription}, []models.UserPreference{preference})
+	for i := 0; i < 6; i++ {
+		if err := h.notifications.SendDigests(3); err != nil {
+			t.Fatal(err)
+		}
+		h.clock.Advance(time.Hour)
+	}
+
+	if len(h.outbox.queued) != 0 {
+		t.Errorf("%d digests queued to an unverified address", len(h.outbox.queued))
+	}
+	if len(h.logs.logs) != 1 || !h.logs.logs[0].Digest || h.logs.logs[0].Status != models.NotificationStatusSkipped {
+		t.Errorf("logs %+v, want a single skipped digest", h.logs.logs)
+	}
+}
+
+func TestCheckAndSendNotificationsSkipsDisabledAccounts(t *testing.T) {
+	end := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	disabledAt := end.AddDate(0, -1, 0)
+	organizationID := uint(10)
+
+	disabledOwner := verifiedUser(1, "gone@example.com")
+	disabledOwner.DisabledAt = &disabledAt
+	subscriptions := []models.Subscription{
+		{
+			ID:                  1,
+			UserID:              1,
+			User:                disabledOwner,
+			Email:               "gone@example.com",
+			Name:                "Disabled",
+			EndDate:             end,
+			NotificationEnabled: true,
+		},
+		{
+			ID:                  2,
+			UserID:              2,
+			User:                verifiedUser(2, "owner@example.com"),
+			Email:               "owner@example.com",
+			Name:                "Active",
+			EndDate:             end,
+			NotificationEnabled: true,
+		},
+		{
+			// Shared subscriptions created by the disabled account still
+			// reach the organization's active members
+			ID:                  3,
+			UserID:              1,
+			OrganizationID:      &organizationID,
+			User:                disabledOwner,
+			Name:                "Shared",
+			EndDate:             end,
+			NotificationEnabled: true,
+		},
+	}
+
+	h := newNotificationHarness(end.AddDate(0, 0, -1), subscriptions, nil)
+	h.organizations.members = []models.OrganizationMember{
+		{OrganizationID: organizationID, UserID: 1, Role: models.OrganizationRoleOwner, User: disabledOwner},
+		{OrganizationID: organizationID, UserID: 3, Role: models.OrganizationRoleAdmin, User: verifiedUser(3, "admin@example.com")},
+	}
+	if err := h.notifications.CheckAndSendNotifications(3); err != nil {
+		t.Fatal(err)
+	}
+
+	var recipients []string
+	for _, warning := range h.outbox.queued {
+		recipients = append(recipients, fmt.Sprintf("%d:%s", *warning.message.SubscriptionID, warning.message.Recipient))
+	}
+	if want := []string{"2:owner@example.com", "3:admin@example.com"}; !reflect.DeepEqual(recipients, want) {
+		t.Errorf("warnings queued for %v, want %v", recipients, want)
+	}
+}
@@ -0,0 +1,512 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+	"renew-guard/pkg/ratelimit"
+	"renew-guard/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrganizationNotFound         = errors.New("organization not found")
+	ErrInvalidOrganizationName      = errors.New("organization name is required")
+	ErrInvalidOrganizationRole      = errors.New("invalid organization role")
+	ErrInsufficientOrganizationRole = errors.New("organization role does not allow this action")
+	ErrAlreadyOrganizationMember    = errors.New("user is already a member of the organization")
+	ErrOrganizationMemberNotFound   = errors.New("organization member not found")
+	ErrLastOrganizationOwner        = errors.New("an organization must keep at least one owner")
+	ErrSoleOrganizationOwner        = errors.New("user is the only owner of an organization with other members")
+	ErrInvitationNotFound           = errors.New("invitation not found")
+	ErrEmailNotVerified             = errors.New("email address is not verified")
+	ErrTooManyInvitations           = errors.New("too many invitations sent, please try again later")
+)
+
+const (
+	// maxOrganizationNameLength bounds organization names
+	maxOrganizationNameLength = 100
+
+	// organizationInvitationTTL is how long an invitation can be accepted
+	organizationInvitationTTL = 7 * 24 * time.Hour
+
+	// Invitations are limited per inviter and per invited address within
+	// invitationLimitWindow, so organizations cannot be used to spam mailboxes
+	invitationLimitPerInviter = 20
+	invitationLimitPerAddress = 3
+	invitationLimitWindow     = 24 * time.Hour
+)
+
+// OrganizationService manages organizations and their memberships. Callers
+// who are not members get ErrOrganizationNotFound so organization IDs cannot
+// be probed. People join by accepting an invitation sent to their verified
+// email address.
+type OrganizationService interface {
+	Create(userID uint, name string) (*models.Organization, error)
+	ListForUser(userID uint) ([]models.OrganizationMember, error)
+	Get(id, userID uint) (*models.Organization, *models.OrganizationMember, error)
+	Rename(id, userID uint, name string) (*models.Organization, *models.OrganizationMember, error)
+	Delete(id, userID uint) error
+	ListMembers(id, userID uint) ([]models.OrganizationMember, error)
+	Invite(id, actorID uint, address, role string) (*models.OrganizationInvitation, error)
+	ListInvitations(id, userID uint) ([]models.OrganizationInvitation, error)
+	RevokeInvitation(id, actorID, invitationID uint) error
+	ListInvitationsForUser(userID uint) ([]models.OrganizationInvitation, error)
+	AcceptInvitation(invitationID, userID uint) (*models.OrganizationMember, error)
+	DeclineInvitation(invitationID, userID uint) error
+	UpdateMemberRole(id, actorID, userID uint, role string) (*models.OrganizationMember, error)
+	RemoveMember(id, actorID, userID uint) error
+	Authorize(id, userID uint, role string) (*models.OrganizationMember, error)
+}
+
+type organizationService struct {
+	organizationRepo repositories.OrganizationRepository
+	invitationRepo   repositories.OrganizationInvitationRepository
+	userRepo         repositories.UserRepository
+	emailService     email.EmailService
+	baseURL          string
+	inviterLimiter   *ratelimit.Limiter // Invitations per inviter
+	addressLimiter   *ratelimit.Limiter // Invitations per invited address
+	clock            clock.Clock
+}
+
+func NewOrganizationService(
+	organizationRepo repositories.OrganizationRepository,
+	invitationRepo repositories.OrganizationInvitationRepository,
+	userRepo repositories.UserRepository,
+	emailService email.EmailService,
+	baseURL string,
+	clk clock.Clock,
+) OrganizationService {
+	return &organizationService{
+		organizationRepo: organizationRepo,
+		invitationRepo:   invitationRepo,
+		userRepo:         userRepo,
+		emailService:     emailService,
+		baseURL:          baseURL,
+		inviterLimiter:   ratelimit.New(invitationLimitPerInviter, invitationLimitWindow, clk),
+		addressLimiter:   ratelimit.New(invitationLimitPerAddress, invitationLimitWindow, clk),
+		clock:            clk,
+	}
+}
+
+// Create sets up a new organization with the user as its owner
+func (s *organizationService) Create(userID uint, name string) (*models.Organization, error) {
+	name, err := normalizeOrganizationName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	organization := &models.Organization{Name: name}
+	if err := s.organizationRepo.CreateWithOwner(organization, userID); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+// ListForUser returns the user's memberships with their organizations loaded
+func (s *organizationService) ListForUser(userID uint) ([]models.OrganizationMember, error) {
+	return s.organizationRepo.FindMembershipsByUserID(userID)
+}
+
+// Get returns the organization along with the caller's membership
+func (s *organizationService) Get(id, userID uint) (*models.Organization, *models.OrganizationMember, error) {
+	member, err := s.Authorize(id, userID, models.OrganizationRoleViewer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	organization, err := s.findOrganization(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return organization, member, nil
+}
+
+func (s *organizationService) Rename(id, userID uint, name string) (*models.Organization, *models.OrganizationMember, error) {
+	member, err := s.Authorize(id, userID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name, err = normalizeOrganizationName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	organization, err := s.findOrganization(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	organization.Name = name
+	if err := s.organizationRepo.Update(organization); err != nil {
+		return nil, nil, err
+	}
+	return organization, member, nil
+}
+
+// Delete removes the organization and its shared subscriptions; owners only
+func (s *organizationService) Delete(id, userID uint) error {
+	if _, err := s.Authorize(id, userID, models.OrganizationRoleOwner); err != nil {
+		return err
+	}
+	return s.organizationRepo.Delete(id)
+}
+
+func (s *organizationService) ListMembers(id, userID uint) ([]models.OrganizationMember, error) {
+	if _, err := s.Authorize(id, userID, models.OrganizationRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.organizationRepo.FindMembers(id)
+}
+
+// Invite offers role to an email address and mails it an invitation.
+// Nothing is looked up about the address, so the outcome is the same whether
+// or not it belongs to an account. Inviting an address again renews its
+// invitation. Admins may invite to any role but owner, which only an owner
+// can offer.
+func (s *organizationService) Invite(id, actorID uint, address, role string) (*models.OrganizationInvitation, error) {
+	if !models.IsValidOrganizationRole(role) {
+		return nil, ErrInvalidOrganizationRole
+	}
+
+	actor, err := s.Authorize(id, actorID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if role == models.OrganizationRoleOwner && !actor.HasRole(models.OrganizationRoleOwner) {
+		return nil, ErrInsufficientOrganizationRole
+	}
+
+	address = strings.ToLower(strings.TrimSpace(address))
+	if !utils.IsValidEmail(address) {
+		return nil, ErrInvalidEmail
+	}
+
+	// Only people whose own address is confirmed may mail others
+	inviter, err := s.findVerifiedUser(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Admins can already see who the members are
+	members, err := s.organizationRepo.FindMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range members {
+		if strings.EqualFold(member.User.Email, address) {
+			return nil, ErrAlreadyOrganizationMember
+		}
+	}
+
+	organization, err := s.findOrganization(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the inviter first so a capped address does not use up their quota
+	inviterKey := strconv.FormatUint(uint64(actorID), 10)
+	if allowed, _ := s.inviterLimiter.Check(inviterKey); !allowed {
+		return nil, ErrTooManyInvitations
+	}
+	if allowed, _ := s.addressLimiter.Allow(address); !allowed {
+		return nil, ErrTooManyInvitations
+	}
+	s.inviterLimiter.Allow(inviterKey)
+
+	invitation := &models.OrganizationInvitation{
+		OrganizationID: id,
+		Email:          address,
+		Role:           role,
+		InvitedByID:    &actorID,
+		ExpiresAt:      s.clock.Now().Add(organizationInvitationTTL),
+	}
+	if err := s.invitationRepo.Upsert(invitation); err != nil {
+		return nil, err
+	}
+
+	subject := email.GetOrganizationInvitationSubject(organization.Name)
+	htmlBody := email.GetOrganizationInvitationTemplate(organization.Name, inviter.Email, role, s.baseURL,
+		int(organizationInvitationTTL/(24*time.Hour)))
+	if err := s.emailService.SendHTML(address, subject, htmlBody); err != nil {
+		log.Printf("Failed to send invitation to organization %d: %v", id, err)
+	}
+
+	invitation.Organization = *organization
+	return invitation, nil
+}
+
+// ListInvitations returns the organization's pending invitations; admins only
+func (s *organizationService) ListInvitations(id, userID uint) ([]models.OrganizationInvitation, error) {
+	if _, err := s.Authorize(id, userID, models.OrganizationRoleAdmin); err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.FindPendingByOrganization(id, s.clock.Now())
+}
+
+// RevokeInvitation withdraws a pending invitation. Invitations to the owner
+// role can only be withdrawn by an owner.
+func (s *organizationService) RevokeInvitation(id, actorID, invitationID uint) error {
+	actor, err := s.Authorize(id, actorID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return err
+	}
+
+	invitation, err := s.invitationRepo.FindByID(invitationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	if invitation.OrganizationID != id {
+		return ErrInvitationNotFound
+	}
+	if invitation.Role == models.OrganizationRoleOwner && !actor.HasRole(models.OrganizationRoleOwner) {
+		return ErrInsufficientOrganizationRole
+	}
+
+	return s.deleteInvitation(invitation.ID)
+}
+
+// ListInvitationsForUser returns the pending invitations sent to the user's
+// email address, which must be verified
+func (s *organizationService) ListInvitationsForUser(userID uint) ([]models.OrganizationInvitation, error) {
+	user, err := s.findVerifiedUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.invitationRepo.FindPendingByEmail(strings.ToLower(user.Email), s.clock.Now())
+}
+
+// AcceptInvitation makes the user a member with the invited role
+func (s *organizationService) AcceptInvitation(invitationID, userID uint) (*models.OrganizationMember, error) {
+	invitation, user, err := s.findInvitationFor(invitationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.organizationRepo.FindMembership(invitation.OrganizationID, userID); err == nil {
+		// Joined some other way meanwhile; the invitation has served its purpose
+		if err := s.deleteInvitation(invitation.ID); err != nil && err != ErrInvitationNotFound {
+			return nil, err
+		}
+		return nil, ErrAlreadyOrganizationMember
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	member := &models.OrganizationMember{
+		OrganizationID: invitation.OrganizationID,
+		UserID:         userID,
+		Role:           invitation.Role,
+	}
+	if err := s.invitationRepo.Accept(invitation, member); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	member.User = *user
+	member.Organization = invitation.Organization
+	return member, nil
+}
+
+// DeclineInvitation discards an invitation sent to the user
+func (s *organizationService) DeclineInvitation(invitationID, userID uint) error {
+	invitation, _, err := s.findInvitationFor(invitationID, userID)
+	if err != nil {
+		return err
+	}
+	return s.deleteInvitation(invitation.ID)
+}
+
+// UpdateMemberRole changes a member's role. Only owners may promote to or
+// demote from owner, and the last owner cannot step down.
+func (s *organizationService) UpdateMemberRole(id, actorID, userID uint, role string) (*models.OrganizationMember, error) {
+	if !models.IsValidOrganizationRole(role) {
+		return nil, ErrInvalidOrganizationRole
+	}
+
+	actor, err := s.Authorize(id, actorID, models.OrganizationRoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+
+	member, err := s.findMember(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	touchesOwner := role == models.OrganizationRoleOwner || member.Role == models.OrganizationRoleOwner
+	if touchesOwner && !actor.HasRole(models.OrganizationRoleOwner) {
+		return nil, ErrInsufficientOrganizationRole
+	}
+	if member.Role == models.OrganizationRoleOwner && role != models.OrganizationRoleOwner {
+		if err := s.ensureOtherOwner(id); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.organizationRepo.UpdateMemberRole(id, userID, role); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationMemberNotFound
+		}
+		return nil, err
+	}
+
+	member.Role = role
+	return member, nil
+}
+
+// RemoveMember ends a membership. Any member may leave; removing someone
+// else takes an admin, or an owner when the target is an owner.
+func (s *organizationService) RemoveMember(id, actorID, userID uint) error {
+	required := models.OrganizationRoleAdmin
+	if actorID == userID {
+		required = models.OrganizationRoleViewer
+	}
+
+	actor, err := s.Authorize(id, actorID, required)
+	if err != nil {
+		return err
+	}
+
+	member, err := s.findMember(id, userID)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == models.OrganizationRoleOwner {
+		if !actor.HasRole(models.OrganizationRoleOwner) {
+			return ErrInsufficientOrganizationRole
+		}
+		if err := s.ensureOtherOwner(id); err != nil {
+			return err
+		}
+	}
+
+	if err := s.organizationRepo.RemoveMember(id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrOrganizationMemberNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// Authorize returns the user's membership if their role is at least role
+func (s *organizationService) Authorize(id, userID uint, role string) (*models.OrganizationMember, error) {
+	member, err := s.organizationRepo.FindMembership(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+
+	if !member.HasRole(role) {
+		return nil, ErrInsufficientOrganizationRole
+	}
+	return member, nil
+}
+
+func (s *organizationService) findOrganization(id uint) (*models.Organization, error) {
+	organization, err := s.organizationRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationNotFound
+		}
+		return nil, err
+	}
+	return organization, nil
+}
+
+func (s *organizationService) findMember(id, userID uint) (*models.OrganizationMember, error) {
+	member, err := s.organizationRepo.FindMembership(id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrganizationMemberNotFound
+		}
+		return nil, err
+	}
+	return member, nil
+}
+
+// findVerifiedUser loads the user, who must have verified their email address
+// before invitations to it are theirs or they can send any
+func (s *organizationService) findVerifiedUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if !user.HasVerifiedEmail(user.Email) {
+		return nil, ErrEmailNotVerified
+	}
+	return user, nil
+}
+
+// findInvitationFor returns a pending invitation addressed to the user.
+// Invitations to anyone else look the same as missing ones.
+func (s *organizationService) findInvitationFor(invitationID, userID uint) (*models.OrganizationInvitation, *models.User, error) {
+	user, err := s.findVerifiedUser(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invitation, err := s.invitationRepo.FindByID(invitationID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrInvitationNotFound
+		}
+		return nil, nil, err
+	}
+	if !strings.EqualFold(invitation.Email, user.Email) || !invitation.IsPending(s.clock.Now()) {
+		return nil, nil, ErrInvitationNotFound
+	}
+	return invitation, user, nil
+}
+
+func (s *organizationService) deleteInvitation(id uint) error {
+	if err := s.invitationRepo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// ensureOtherOwner fails if removing one owner would leave the organization without any
+func (s *organizationService) ensureOtherOwner(id uint) error {
+	owners, err := s.organizationRepo.CountOwners(id)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOrganizationOwner
+	}
+	return nil
+}
+
+// normalizeOrganizationName trims name and checks it is usable
+func normalizeOrganizationName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > maxOrganizationNameLength || utils.HasControlChars(name) {
+		return "", ErrInvalidOrganizationName
+	}
+	return name, nil
+}
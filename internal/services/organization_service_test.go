@@ -0,0 +1,334 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+
+	"gorm.io/gorm"
+)
+
+func (r *memoryOrganizationRepository) FindByID(id uint) (*models.Organization, error) {
+	for i := range r.organizations {
+		if r.organizations[i].ID == id {
+			organization := r.organizations[i]
+			return &organization, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryOrganizationRepository) FindMembership(organizationID, userID uint) (*models.OrganizationMember, error) {
+	for _, member := range r.members {
+		if member.OrganizationID == organizationID && member.UserID == userID {
+			return &member, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryOrganizationRepository) FindMembers(organizationID uint) ([]models.OrganizationMember, error) {
+	var found []models.OrganizationMember
+	for _, member := range r.members {
+		if member.OrganizationID == organizationID {
+			found = append(found, member)
+		}
+	}
+	return found, nil
+}
+
+// memoryInvitationRepository stores invitations in memory and adds accepted
+// ones to a memoryOrganizationRepository. Found invitations come with their
+// organization, as the SQL queries preload it.
+type memoryInvitationRepository struct {
+	organizations *memoryOrganizationRepository
+	invitations   []models.OrganizationInvitation
+}
+
+func (r *memoryInvitationRepository) Upsert(invitation *models.OrganizationInvitation) error {
+	for i := range r.invitations {
+		if r.invitations[i].OrganizationID == invitation.OrganizationID && r.invitations[i].Email == invitation.Email {
+			invitation.ID = r.invitations[i].ID
+			r.invitations[i] = *invitation
+			return nil
+		}
+	}
+	invitation.ID = uint(len(r.invitations) + 1)
+	r.invitations = append(r.invitations, *invitation)
+	return nil
+}
+
+func (r *memoryInvitationRepository) withOrganization(invitation models.OrganizationInvitation) models.OrganizationInvitation {
+	if organization, err := r.organizations.FindByID(invitation.OrganizationID); err == nil {
+		invitation.Organization = *organization
+	}
+	return invitation
+}
+
+func (r *memoryInvitationRepository) FindByID(id uint) (*models.OrganizationInvitation, error) {
+	for _, invitation := range r.invitations {
+		if invitation.ID == id {
+			invitation = r.withOrganization(invitation)
+			return &invitation, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *memoryInvitationRepository) FindPendingByOrganization(organizationID uint, now time.Time) ([]models.OrganizationInvitation, error) {
+	var found []models.OrganizationInvitation
+	for _, invitation := range r.invitations {
+		if invitation.OrganizationID == organizationID && invitation.IsPending(now) {
+			found = append(found, r.withOrganization(invitation))
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryInvitationRepository) FindPendingByEmail(address string, now time.Time) ([]models.OrganizationInvitation, error) {
+	var found []models.OrganizationInvitation
+	for _, invitation := range r.invitations {
+		if invitation.Email == address && invitation.IsPending(now) {
+			found = append(found, r.withOrganization(invitation))
+		}
+	}
+	return found, nil
+}
+
+func (r *memoryInvitationRepository) Delete(id uint) error {
+	for i, invitation := range r.invitations {
+		if invitation.ID == id {
+			r.invitations = append(r.invitations[:i], r.invitations[i+1:]...)
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *memoryInvitationRepository) Accept(invitation *models.OrganizationInvitation, member *models.OrganizationMember) error {
+	if err := r.Delete(invitation.ID); err != nil {
+		return err
+	}
+	r.organizations.members = append(r.organizations.members, *member)
+	return nil
+}
+
+type sentEmailService struct {
+	email.EmailService
+	recipients []string
+}
+
+func (s *sentEmailService) SendHTML(to, subject, htmlBody string) error {
+	s.recipients = append(s.recipients, to)
+	return nil
+}
+
+type organizationHarness struct {
+	clock         *clock.Fake
+	organizations *memoryOrganizationRepository
+	invitations   *memoryInvitationRepository
+	mail          *sentEmailService
+	service       OrganizationService
+}
+
+// newOrganizationHarness sets up organization 1 with user 1 as its owner and
+// user 2 as an admin. Users 3 and 4 are outsiders, and only 3 is verified.
+func newOrganizationHarness() *organizationHarness {
+	owner := verifiedUser(1, "owner@example.com")
+	admin := verifiedUser(2, "admin@example.com")
+	organizations := &memoryOrganizationRepository{
+		organizations: []models.Organization{{ID: 1, Name: "Acme"}},
+		members: []models.OrganizationMember{
+			{OrganizationID: 1, UserID: 1, Role: models.OrganizationRoleOwner, User: owner},
+			{OrganizationID: 1, UserID: 2, Role: models.OrganizationRoleAdmin, User: admin},
+		},
+	}
+	invitee := verifiedUser(3, "Invitee@Example.com")
+	users := &memoryUserRepository{users: []*models.User{
+		&owner,
+		&admin,
+		&invitee,
+		{ID: 4, Email: "unverified@example.com"},
+	}}
+	invitations := &memoryInvitationRepository{organizations: organizations}
+	mail := &sentEmailService{}
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+
+	return &organizationHarness{
+		clock:         clk,
+		organizations: organizations,
+		invitations:   invitations,
+		mail:          mail,
+		service:       NewOrganizationService(organizations, invitations, users, mail, "https://renewguard.example", clk),
+	}
+}
+
+func TestInviteTreatsKnownAndUnknownAddressesAlike(t *testing.T) {
+	h := newOrganizationHarness()
+
+	for _, address := range []string{" Invitee@Example.com ", "nobody@example.com"} {
+		invitation, err := h.service.Invite(1, 2, address, models.OrganizationRoleMember)
+		if err != nil {
+			t.Fatalf("invite %q: %v", address, err)
+		}
+		if invitation.Organization.Name != "Acme" || invitation.Role != models.OrganizationRoleMember {
+			t.Errorf("invite %q returned %+v", address, invitation)
+		}
+	}
+
+	if want := []string{"invitee@example.com", "nobody@example.com"}; !reflect.DeepEqual(h.mail.recipients, want) {
+		t.Errorf("invitations mailed to %v, want %v", h.mail.recipients, want)
+	}
+	if len(h.organizations.members) != 2 {
+		t.Errorf("inviting added members: %d, want 2", len(h.organizations.members))
+	}
+
+	if _, err := h.service.Invite(1, 2, "admin@example.com", models.OrganizationRoleMember); err != ErrAlreadyOrganizationMember {
+		t.Errorf("inviting a member: got %v, want ErrAlreadyOrganizationMember", err)
+	}
+	if _, err := h.service.Invite(1, 2, "boss@example.com", models.OrganizationRoleOwner); err != ErrInsufficientOrganizationRole {
+		t.Errorf("admin inviting an owner: got %v, want ErrInsufficientOrganizationRole", err)
+	}
+	if _, err := h.service.Invite(1, 3, "friend@example.com", models.OrganizationRoleMember); err != ErrOrganizationNotFound {
+		t.Errorf("outsider inviting: got %v, want ErrOrganizationNotFound", err)
+	}
+}
+
+func TestInviteRejectsUnsafeRequests(t *testing.T) {
+	h := newOrganizationHarness()
+	// An admin who never confirmed their address cannot send mail as the organization
+	h.organizations.members = append(h.organizations.members,
+		models.OrganizationMember{OrganizationID: 1, UserID: 4, Role: models.OrganizationRoleAdmin})
+
+	tests := []struct {
+		name    string
+		actorID uint
+		address string
+		want    error
+	}{
+		{"invalid address", 2, "not-an-email", ErrInvalidEmail},
+		{"header injection", 2, "victim@example.com\r\nBcc: everyone@example.com", ErrInvalidEmail},
+		{"unverified inviter", 4, "friend@example.com", ErrEmailNotVerified},
+	}
+	for _, tt := range tests {
+		if _, err := h.service.Invite(1, tt.actorID, tt.address, models.OrganizationRoleMember); err != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if len(h.mail.recipients) != 0 {
+		t.Errorf("rejected invitations mailed to %v", h.mail.recipients)
+	}
+
+	if _, err := h.service.Create(1, "Acme\r\nBcc: everyone@example.com"); err != ErrInvalidOrganizationName {
+		t.Errorf("name with a line break: got %v, want ErrInvalidOrganizationName", err)
+	}
+}
+
+func TestInvitationsAreRateLimited(t *testing.T) {
+	h := newOrganizationHarness()
+
+	// The same address can only be invited a few times a day
+	for i := 0; i < invitationLimitPerAddress; i++ {
+		if _, err := h.service.Invite(1, 2, "nobody@example.com", models.OrganizationRoleMember); err != nil {
+			t.Fatalf("invitation %d: %v", i+1, err)
+		}
+	}
+	if _, err := h.service.Invite(1, 1, "Nobody@Example.com", models.OrganizationRoleMember); err != ErrTooManyInvitations {
+		t.Errorf("address over its limit: got %v, want ErrTooManyInvitations", err)
+	}
+
+	// ...and each inviter only so many addresses
+	for i := invitationLimitPerAddress; i < invitationLimitPerInviter; i++ {
+		address := fmt.Sprintf("person%d@example.com", i)
+		if _, err := h.service.Invite(1, 2, address, models.OrganizationRoleMember); err != nil {
+			t.Fatalf("invite %s: %v", address, err)
+		}
+	}
+	if _, err := h.service.Invite(1, 2, "late@example.com", models.OrganizationRoleMember); err != ErrTooManyInvitations {
+		t.Errorf("inviter over their limit: got %v, want ErrTooManyInvitations", err)
+	}
+	if _, err := h.service.Invite(1, 1, "late@example.com", models.OrganizationRoleMember); err != nil {
+		t.Errorf("another inviter: %v", err)
+	}
+
+	h.clock.Advance(invitationLimitWindow)
+	if _, err := h.service.Invite(1, 2, "nobody@example.com", models.OrganizationRoleMember); err != nil {
+		t.Errorf("after the window: %v", err)
+	}
+}
+
+func TestAcceptInvitationOnlyByVerifiedInvitee(t *testing.T) {
+	h := newOrganizationHarness()
+
+	invitation, err := h.service.Invite(1, 1, "invitee@example.com", models.OrganizationRoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unverified, err := h.service.Invite(1, 1, "unverified@example.com", models.OrganizationRoleViewer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.service.AcceptInvitation(invitation.ID, 4); err != ErrEmailNotVerified {
+		t.Errorf("unverified user accepting: got %v, want ErrEmailNotVerified", err)
+	}
+	if _, err := h.service.AcceptInvitation(unverified.ID, 4); err != ErrEmailNotVerified {
+		t.Errorf("unverified invitee accepting: got %v, want ErrEmailNotVerified", err)
+	}
+	if err := h.service.DeclineInvitation(invitation.ID, 2); err != ErrInvitationNotFound {
+		t.Errorf("someone else declining: got %v, want ErrInvitationNotFound", err)
+	}
+
+	pending, err := h.service.ListInvitationsForUser(3)
+	if err != nil || len(pending) != 1 || pending[0].ID != invitation.ID {
+		t.Fatalf("invitee's invitations = %+v, %v", pending, err)
+	}
+
+	member, err := h.service.AcceptInvitation(invitation.ID, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if member.Role != models.OrganizationRoleViewer || member.Organization.Name != "Acme" {
+		t.Errorf("joined as %s of %q", member.Role, member.Organization.Name)
+	}
+	if _, err := h.service.Authorize(1, 3, models.OrganizationRoleViewer); err != nil {
+		t.Errorf("invitee is not a member after accepting: %v", err)
+	}
+	if _, err := h.service.AcceptInvitation(invitation.ID, 3); err != ErrInvitationNotFound {
+		t.Errorf("accepting twice: got %v, want ErrInvitationNotFound", err)
+	}
+}
+
+func TestExpiredInvitationCannotBeAccepted(t *testing.T) {
+	h := newOrganizationHarness()
+
+	invitation, err := h.service.Invite(1, 1, "invitee@example.com", models.OrganizationRoleMember)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.clock.Advance(organizationInvitationTTL)
+	if _, err := h.service.AcceptInvitation(invitation.ID, 3); err != ErrInvitationNotFound {
+		t.Errorf("accepting an expired invitation: got %v, want ErrInvitationNotFound", err)
+	}
+	if pending, _ := h.service.ListInvitations(1, 1); len(pending) != 0 {
+		t.Errorf("expired invitation still listed: %+v", pending)
+	}
+
+	// Inviting again renews the same invitation
+	renewed, err := h.service.Invite(1, 1, "invitee@example.com", models.OrganizationRoleMember)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renewed.ID != invitation.ID {
+		t.Errorf("re-invite created invitation %d, want %d renewed", renewed.ID, invitation.ID)
+	}
+	if _, err := h.service.AcceptInvitation(renewed.ID, 3); err != nil {
+		t.Errorf("accepting the renewed invitation: %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"renew-guard/internal/config"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/email"
+	"renew-guard/pkg/notifier"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// outboxLease is how long a claimed message is reserved for the worker
+// sending it. It comfortably exceeds the SMTP and webhook timeouts.
+const outboxLease = 5 * time.Minute
+
+// OutboxService queues notifications durably and delivers them with retries
+type OutboxService interface {
+	Enqueue(message *models.OutboxMessage) error
+	ProcessOutbox() error
+}
+
+type outboxService struct {
+	outboxRepo       repositories.OutboxRepository
+	notificationRepo repositories.NotificationLogRepository
+	notifiers        *notifier.Registry
+	config           *config.OutboxConfig
+	clock            clock.Clock
+}
+
+func NewOutboxService(
+	outboxRepo repositories.OutboxRepository,
+	notificationRepo repositories.NotificationLogRepository,
+	notifiers *notifier.Registry,
+	cfg *config.OutboxConfig,
+	clk clock.Clock,
+) OutboxService {
+	return &outboxService{
+		outboxRepo:       outboxRepo,
+		notificationRepo: notificationRepo,
+		notifiers:        notifiers,
+		config:           cfg,
+		clock:            clk,
+	}
+}
+
+// Enqueue stores a message for immediate delivery by the next outbox run
+func (s *outboxService) Enqueue(message *models.OutboxMessage) error {
+	message.Status = models.OutboxStatusPending
+	message.Attempts = 0
+	message.MaxAttempts = s.config.MaxAttempts
+	message.NextAttemptAt = s.clock.Now()
+
+	if err := s.outboxRepo.Create(message); err != nil {
+		return fmt.Errorf("failed to enqueue notification: %w", err)
+	}
+	return nil
+}
+
+// ProcessOutbox delivers up to a batch of due messages once each. Messages
+// are claimed one at a time before sending, so workers in other processes,
+// or a manual run overlapping the scheduled one, never send the same message.
+func (s *outboxService) ProcessOutbox() error {
+	now := s.clock.Now()
+
+	sentCount := 0
+	retryCount := 0
+	deadCount := 0
+
+	for processed := 0; processed < s.config.BatchSize; processed++ {
+		message, err := s.outboxRepo.ClaimNext(now, s.clock.Now().Add(outboxLease))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				break
+			}
+			return fmt.Errorf("failed to claim outbox message: %w", err)
+		}
+
+		switch s.deliver(message) {
+		case models.OutboxStatusSent:
+			sentCount++
+		case models.OutboxStatusDead:
+			deadCount++
+		default:
+			retryCount++
+		}
+	}
+
+	if sentCount+retryCount+deadCount == 0 {
+		return nil
+	}
+
+	log.Printf("Outbox run complete: %d sent, %d scheduled for retry, %d dead-lettered",
+		sentCount, retryCount, deadCount)
+	return nil
+}
+
+// deliver attempts one send, records the outcome and returns the new status
+func (s *outboxService) deliver(message *models.OutboxMessage) string {
+	message.Attempts++
+	sendErr := s.notifiers.Send(message.Channel, notifier.Message{
+		Recipient:   message.Recipient,
+		Subject:     message.Subject,
+		Text:        message.TextBody,
+		HTMLBody:    message.HTMLBody,
+		Data:        message.Data,
+		Attachments: outboxAttachments(message.Attachments),
+	})
+	now := s.clock.Now()
+
+	status := models.NotificationStatusSuccess
+	errorMessage := ""
+
+	if sendErr == nil {
+		message.Status = models.OutboxStatusSent
+		message.SentAt = &now
+		message.LastError = ""
+		log.Printf("Notification %d sent successfully via %s (attempt %d)",
+			message.ID, message.Channel, message.Attempts)
+	} else {
+		message.LastError = sendErr.Error()
+		errorMessage = sendErr.Error()
+
+		if message.Attempts >= message.MaxAttempts {
+			message.Status = models.OutboxStatusDead
+			status = models.NotificationStatusDeadLetter
+			log.Printf("Notification %d dead-lettered after %d attempts: %v",
+				message.ID, message.Attempts, sendErr)
+		} else {
+			message.NextAttemptAt = now.Add(s.backoff(message.Attempts))
+			status = models.NotificationStatusFailed
+			log.Printf("Notification %d failed (attempt %d), retrying at %s: %v",
+				message.ID, message.Attempts, message.NextAttemptAt.Format(time.RFC3339), sendErr)
+		}
+	}
+
+	if err := s.outboxRepo.Update(message); err != nil {
+		log.Printf("Failed to update outbox message %d: %v", message.ID, err)
+	}
+
+	// Log the attempt against every subscription the message covers
+	for _, subscriptionID := range message.CoveredSubscriptionIDs() {
+		notificationLog := &models.NotificationLog{
+			SubscriptionID: subscriptionID,
+			SentAt:         now,
+			Channel:        message.Channel,
+			Status:         status,
+			ErrorMessage:   errorMessage,
+			DaysBefore:     message.DaysBefore,
+			OutboxID:       &message.ID,
+			Attempt:        message.Attempts,
+			Digest:         len(message.SubscriptionIDs) > 0,
+		}
+		if err := s.notificationRepo.Create(notificationLog); err != nil {
+			log.Printf("Failed to create notification log: %v", err)
+		}
+	}
+
+	return message.Status
+}
+
+// backoff returns the delay before the next attempt: base * 2^(attempts-1), capped
+func (s *outboxService) backoff(attempts int) time.Duration {
+	delay := s.config.BackoffBase
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= s.config.BackoffMax {
+			return s.config.BackoffMax
+		}
+	}
+	if delay > s.config.BackoffMax {
+		return s.config.BackoffMax
+	}
+	return delay
+}
+
+// outboxAttachments converts queued attachments back into email attachments
+func outboxAttachments(queued []models.OutboxAttachment) []email.Attachment {
+	if len(queued) == 0 {
+		return nil
+	}
+	attachments := make([]email.Attachment, len(queued))
+	for i, attachment := range queued {
+		attachments[i] = email.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Data:        attachment.Data,
+		}
+	}
+	return attachments
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"renew-guard/internal/config"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/notifier"
+
+	"gorm.io/gorm"
+)
+
+// memoryOutboxRepository claims messages under a lock, as the database does
+// with SELECT ... FOR UPDATE SKIP LOCKED
+type memoryOutboxRepository struct {
+	mu       sync.Mutex
+	messages []*models.OutboxMessage
+}
+
+func (r *memoryOutboxRepository) Create(message *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	message.ID = uint(len(r.messages) + 1)
+	r.messages = append(r.messages, message)
+	return nil
+}
+
+func (r *memoryOutboxRepository) ClaimNext(now, leaseUntil time.Time) (*models.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var next *models.OutboxMessage
+	for _, message := range r.messages {
+		if message.Status != models.OutboxStatusPending || message.NextAttemptAt.After(now) {
+			continue
+		}
+		if next == nil || message.NextAttemptAt.Before(next.NextAttemptAt) {
+			next = message
+		}
+	}
+	if next == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	next.NextAttemptAt = leaseUntil
+	claimed := *next
+	return &claimed, nil
+}
+
+func (r *memoryOutboxRepository) Update(message *models.OutboxMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *message
+	r.messages[message.ID-1] = &stored
+	return nil
+}
+
+type discardNotificationLogRepository struct {
+	repositories.NotificationLogRepository
+}
+
+func (discardNotificationLogRepository) Create(log *models.NotificationLog) error {
+	return nil
+}
+
+// countingNotifier counts sends per recipient and fails while failures remain
+type countingNotifier struct {
+	mu       sync.Mutex
+	sends    map[string]int
+	failures int
+}
+
+func (n *countingNotifier) Channel() string {
+	return notifier.ChannelWebhook
+}
+
+func (n *countingNotifier) Send(msg notifier.Message) error {
+	// Hold the message for a moment so concurrent runs overlap
+	time.Sleep(time.Millisecond)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sends[msg.Recipient]++
+	if n.failures > 0 {
+		n.failures--
+		return errors.New("endpoint unavailable")
+	}
+	return nil
+}
+
+func newOutboxTestService(clk clock.Clock, failures int) (OutboxService, *memoryOutboxRepository, *countingNotifier) {
+	repo := &memoryOutboxRepository{}
+	counter := &countingNotifier{sends: make(map[string]int), failures: failures}
+	cfg := &config.OutboxConfig{
+		BatchSize:   100,
+		MaxAttempts: 3,
+		BackoffBase: time.Minute,
+		BackoffMax:  time.Hour,
+	}
+	service := NewOutboxService(repo, discardNotificationLogRepository{}, notifier.NewRegistry(counter), cfg, clk)
+	return service, repo, counter
+}
+
+func TestProcessOutboxConcurrentRunsSendOnce(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	service, repo, counter := newOutboxTestService(clk, 0)
+
+	const total = 20
+	for i := 0; i < total; i++ {
+		service.Enqueue(&models.OutboxMessage{
+			Channel:   notifier.ChannelWebhook,
+			Recipient: string(rune('a' + i)),
+			Subject:   "Reminder",
+		})
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := service.ProcessOutbox(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(counter.sends) != total {
+		t.Errorf("%d messages sent, want %d", len(counter.sends), total)
+	}
+	for recipient, sends := range counter.sends {
+		if sends != 1 {
+			t.Errorf("message to %q sent %d times", recipient, sends)
+		}
+	}
+	for _, message := range repo.messages {
+		if message.Status != models.OutboxStatusSent {
+			t.Errorf("message %d is %s, want sent", message.ID, message.Status)
+		}
+	}
+}
+
+func TestProcessOutboxRetriesThenDeadLetters(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	clk := clock.NewFake(start)
+	service, repo, counter := newOutboxTestService(clk, 10)
+
+	service.Enqueue(&models.OutboxMessage{Channel: notifier.ChannelWebhook, Recipient: "a", Subject: "Reminder"})
+
+	service.ProcessOutbox()
+	message := repo.messages[0]
+	if message.Status != models.OutboxStatusPending || message.Attempts != 1 {
+		t.Fatalf("after first failure: status %s, attempts %d", message.Status, message.Attempts)
+	}
+	if want := start.Add(time.Minute); !message.NextAttemptAt.Equal(want) {
+		t.Errorf("next attempt at %s, want %s", message.NextAttemptAt, want)
+	}
+
+	// Not due yet, so a run before the backoff ends sends nothing
+	service.ProcessOutbox()
+	if counter.sends["a"] != 1 {
+		t.Fatalf("message retried before its backoff ended")
+	}
+
+	clk.Advance(time.Minute)
+	service.ProcessOutbox()
+	clk.Advance(2 * time.Minute)
+	service.ProcessOutbox()
+
+	message = repo.messages[0]
+	if message.Status != models.OutboxStatusDead || message.Attempts != 3 {
+		t.Errorf("after max attempts: status %s, attempts %d", message.Status, message.Attempts)
+	}
+	if counter.sends["a"] != 3 {
+		t.Errorf("sent %d times, want 3", counter.sends["a"])
+	}
+}
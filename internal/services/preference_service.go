@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/notifier"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLeadTime         = errors.New("default lead time must be between 0 and 365 days")
+	ErrInvalidChannel          = errors.New("unsupported notification channel")
+	ErrInvalidWebhookURL       = errors.New("webhook URL must be an absolute http(s) URL on a public host")
+	ErrChannelNotConfigured    = errors.New("selected channel requires a webhook URL")
+	ErrInvalidTimezone         = errors.New("invalid timezone")
+	ErrInvalidLocale           = errors.New("invalid locale")
+	ErrInvalidNotificationMode = errors.New("invalid notification mode")
+	ErrInvalidDigestFrequency  = errors.New("invalid digest frequency")
+)
+
+var localeRegex = regexp.MustCompile(`^[a-z]{2}(-[A-Z]{2})?$`)
+
+// PreferenceInput carries a partial preferences update; nil fields are left unchanged
+type PreferenceInput struct {
+	DefaultDaysBefore *int
+	Channels          []string
+	WebhookURL        *string
+	SlackWebhookURL   *string
+	DiscordWebhookURL *string
+	Timezone          *string
+	Locale            *string
+	NotificationMode  *string
+	DigestFrequency   *string
+}
+
+type PreferenceService interface {
+	Get(userID uint) (*models.UserPreference, error)
+	Update(userID uint, input PreferenceInput) (*models.UserPreference, error)
+}
+
+type preferenceService struct {
+	preferenceRepo    repositories.UserPreferenceRepository
+	defaultDaysBefore int
+}
+
+func NewPreferenceService(preferenceRepo repositories.UserPreferenceRepository, defaultDaysBefore int) PreferenceService {
+	return &preferenceService{
+		preferenceRepo:    preferenceRepo,
+		defaultDaysBefore: defaultDaysBefore,
+	}
+}
+
+// Get returns the user's stored preferences, or the application defaults if none are stored
+func (s *preferenceService) Get(userID uint) (*models.UserPreference, error) {
+	preference, err := s.preferenceRepo.FindByUserID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return DefaultPreference(userID, s.defaultDaysBefore), nil
+		}
+		return nil, err
+	}
+	return preference, nil
+}
+
+func (s *preferenceService) Update(userID uint, input PreferenceInput) (*models.UserPreference, error) {
+	preference, err := s.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DefaultDaysBefore != nil {
+		if *input.DefaultDaysBefore < 0 || *input.DefaultDaysBefore > maxReminderDaysBefore {
+			return nil, ErrInvalidLeadTime
+		}
+		preference.DefaultDaysBefore = *input.DefaultDaysBefore
+	}
+
+	if input.Channels != nil {
+		for _, channel := range input.Channels {
+			if !isSupportedChannel(channel) {
+				return nil, ErrInvalidChannel
+			}
+		}
+		preference.Channels = input.Channels
+	}
+
+	for _, field := range []struct {
+		input  *string
+		target *string
+	}{
+		{input.WebhookURL, &preference.WebhookURL},
+		{input.SlackWebhookURL, &preference.SlackWebhookURL},
+		{input.DiscordWebhookURL, &preference.DiscordWebhookURL},
+	} {
+		if field.input == nil {
+			continue
+		}
+		if *field.input != "" && !isValidWebhookURL(*field.input) {
+			return nil, ErrInvalidWebhookURL
+		}
+		*field.target = *field.input
+	}
+
+	// Every webhook-based channel needs somewhere to post to
+	for _, channel := range preference.Channels {
+		if channel != models.NotificationChannelEmail && preference.WebhookURLFor(channel) == "" {
+			return nil, ErrChannelNotConfigured
+		}
+	}
+
+	if input.Timezone != nil {
+		if _, err := time.LoadLocation(*input.Timezone); err != nil || *input.Timezone == "" {
+			return nil, ErrInvalidTimezone
+		}
+		preference.Timezone = *input.Timezone
+	}
+
+	if input.Locale != nil {
+		if !localeRegex.MatchString(*input.Locale) {
+			return nil, ErrInvalidLocale
+		}
+		preference.Locale = *input.Locale
+	}
+
+	if input.NotificationMode != nil {
+		switch *input.NotificationMode {
+		case models.NotificationModeDaily, models.NotificationModeDigest:
+			preference.NotificationMode = *input.NotificationMode
+		default:
+			return nil, ErrInvalidNotificationMode
+		}
+	}
+
+	if input.DigestFrequency != nil {
+		switch *input.DigestFrequency {
+		case models.DigestFrequencyDaily, models.DigestFrequencyWeekly:
+			preference.DigestFrequency = *input.DigestFrequency
+		default:
+			return nil, ErrInvalidDigestFrequency
+		}
+	}
+
+	if err := s.preferenceRepo.Save(preference); err != nil {
+		return nil, err
+	}
+
+	return preference, nil
+}
+
+// DefaultPreference builds the preferences used for users who never saved any
+func DefaultPreference(userID uint, defaultDaysBefore int) *models.UserPreference {
+	return &models.UserPreference{
+		UserID:            userID,
+		DefaultDaysBefore: defaultDaysBefore,
+		Channels:          []string{models.NotificationChannelEmail},
+		Timezone:          "UTC",
+		Locale:            "en",
+		NotificationMode:  models.NotificationModeDaily,
+		DigestFrequency:   models.DigestFrequencyDaily,
+	}
+}
+
+// isSupportedChannel reports whether a notification channel can be delivered
+func isSupportedChannel(channel string) bool {
+	switch channel {
+	case models.NotificationChannelEmail, models.NotificationChannelWebhook,
+		models.NotificationChannelSlack, models.NotificationChannelDiscord:
+		return true
+	}
+	return false
+}
+
+// isValidWebhookURL reports whether raw is an absolute http or https URL
+// whose host resolves only to public addresses, so webhooks cannot be aimed
+// at the server's own network
+func isValidWebhookURL(raw string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return notifier.ValidateWebhookURL(ctx, raw) == nil
+}
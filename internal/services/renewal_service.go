@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// maxRenewalCatchUp bounds how many periods a single run may roll forward,
+// protecting against runaway loops on very short or malformed cycles
+const maxRenewalCatchUp = 1000
+
+type RenewalService interface {
+	ProcessRenewals() error
+}
+
+type renewalService struct {
+	subscriptionRepo repositories.SubscriptionRepository
+	clock            clock.Clock
+}
+
+func NewRenewalService(subscriptionRepo repositories.SubscriptionRepository, clk clock.Clock) RenewalService {
+	return &renewalService{
+		subscriptionRepo: subscriptionRepo,
+		clock:            clk,
+	}
+}
+
+// ProcessRenewals rolls every expired auto-renewing subscription into its current period
+func (s *renewalService) ProcessRenewals() error {
+	now := s.clock.Now()
+
+	subscriptions, err := s.subscriptionRepo.FindDueForRenewal(now)
+	if err != nil {
+		return fmt.Errorf("failed to find subscriptions due for renewal: %w", err)
+	}
+
+	log.Printf("Found %d subscription(s) due for renewal", len(subscriptions))
+
+	renewedCount := 0
+	failedCount := 0
+
+	for _, subscription := range subscriptions {
+		periods, err := s.renewSubscription(&subscription, now)
+		if err != nil {
+			log.Printf("Failed to renew subscription %d: %v", subscription.ID, err)
+			failedCount++
+			continue
+		}
+		if periods > 0 {
+			renewedCount++
+		}
+	}
+
+	log.Printf("Renewal run complete: %d renewed, %d failed", renewedCount, failedCount)
+	return nil
+}
+
+// renewSubscription advances the subscription until its end date is in the
+// future, recording one history entry per period, and returns the number of
+// periods rolled
+func (s *renewalService) renewSubscription(subscription *models.Subscription, now time.Time) (int, error) {
+	var renewals []models.SubscriptionRenewal
+
+	for !subscription.EndDate.After(now) && len(renewals) < maxRenewalCatchUp {
+		previousStart := subscription.StartDate
+		previousEnd := subscription.EndDate
+
+		subscription.AdvancePeriod()
+		if !subscription.EndDate.After(previousEnd) {
+			return 0, fmt.Errorf("subscription %d has a non-advancing billing period", subscription.ID)
+		}
+
+		renewals = append(renewals, models.SubscriptionRenewal{
+			SubscriptionID:    subscription.ID,
+			PreviousStartDate: previousStart,
+			PreviousEndDate:   previousEnd,
+			NewStartDate:      subscription.StartDate,
+			NewEndDate:        subscription.EndDate,
+		})
+	}
+
+	if len(renewals) == 0 {
+		return 0, nil
+	}
+
+	if err := s.subscriptionRepo.Renew(subscription, renewals); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Renewed by another run, or its dates were edited, since it was loaded
+			log.Printf("Subscription %d changed since it was loaded, skipping renewal", subscription.ID)
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	log.Printf("Subscription %d (%s) renewed %d period(s), now ends %s",
+		subscription.ID, subscription.Name, len(renewals), subscription.EndDate.Format("2006-01-02"))
+
+	return len(renewals), nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+)
+
+// memorySubscriptionRepository answers the scheduler's queries from a slice,
+// applying the same filters as the SQL ones
+type memorySubscriptionRepository struct {
+	repositories.SubscriptionRepository
+	subscriptions []models.Subscription
+	renewals      []models.SubscriptionRenewal
+}
+
+func (r *memorySubscriptionRepository) find(id uint) *models.Subscription {
+	for i := range r.subscriptions {
+		if r.subscriptions[i].ID == id {
+			return &r.subscriptions[i]
+		}
+	}
+	return nil
+}
+
+func (r *memorySubscriptionRepository) FindExpiringSubscriptions(now time.Time, daysBefore int) ([]models.Subscription, error) {
+	targetDate := now.AddDate(0, 0, daysBefore+1)
+	var found []models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.NotificationEnabled && !subscription.EndDate.Before(now) && !subscription.EndDate.After(targetDate) {
+			subscription.Reminders = append([]models.SubscriptionReminder(nil), subscription.Reminders...)
+			found = append(found, subscription)
+		}
+	}
+	return found, nil
+}
+
+func (r *memorySubscriptionRepository) UpdateLastNotificationSent(id uint, sentAt time.Time) error {
+	r.find(id).LastNotificationSent = &sentAt
+	return nil
+}
+
+func (r *memorySubscriptionRepository) FindDueForRenewal(now time.Time) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.AutoRenew && !subscription.EndDate.After(now) {
+			found = append(found, subscription)
+		}
+	}
+	return found, nil
+}
+
+func (r *memorySubscriptionRepository) Renew(subscription *models.Subscription, renewals []models.SubscriptionRenewal) error {
+	*r.find(subscription.ID) = *subscription
+	r.renewals = append(r.renewals, renewals...)
+	return nil
+}
+
+func TestProcessRenewalsCatchesUpMissedPeriods(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewing := models.Subscription{
+		ID:               1,
+		Name:             "Monthly",
+		StartDate:        time.Date(2024, 1, 31, 9, 0, 0, 0, newYork),
+		BillingCycle:     models.BillingCycleMonthly,
+		BillingInterval:  1,
+		BillingUnit:      models.BillingUnitMonth,
+		BillingAnchorDay: 31,
+		Timezone:         "America/New_York",
+		AutoRenew:        true,
+	}
+	renewing.ComputeEndDate()
+	lapsed := models.Subscription{ID: 2, Name: "One-off", StartDate: renewing.StartDate, DurationDays: 30}
+	lapsed.ComputeEndDate()
+
+	repo := &memorySubscriptionRepository{subscriptions: []models.Subscription{renewing, lapsed}}
+	// The scheduler was down from Feb 29 until mid April
+	clk := clock.NewFake(time.Date(2024, 4, 15, 12, 0, 0, 0, time.UTC))
+	service := NewRenewalService(repo, clk)
+
+	if err := service.ProcessRenewals(); err != nil {
+		t.Fatal(err)
+	}
+
+	wantEnds := []time.Time{
+		time.Date(2024, 3, 31, 9, 0, 0, 0, newYork),
+		time.Date(2024, 4, 30, 9, 0, 0, 0, newYork),
+	}
+	if len(repo.renewals) != len(wantEnds) {
+		t.Fatalf("%d renewals recorded, want %d", len(repo.renewals), len(wantEnds))
+	}
+	previousEnd := renewing.EndDate
+	for i, renewal := range repo.renewals {
+		if !renewal.PreviousEndDate.Equal(previousEnd) || !renewal.NewStartDate.Equal(previousEnd) {
+			t.Errorf("renewal %d starts at %s, want %s", i+1, renewal.NewStartDate, previousEnd)
+		}
+		if !renewal.NewEndDate.Equal(wantEnds[i]) {
+			t.Errorf("renewal %d ends %s, want %s", i+1, renewal.NewEndDate, wantEnds[i])
+		}
+		previousEnd = renewal.NewEndDate
+	}
+	if end := repo.find(1).EndDate; !end.Equal(wantEnds[1]) {
+		t.Errorf("subscription ends %s, want %s", end, wantEnds[1])
+	}
+	if end := repo.find(2).EndDate; !end.Equal(lapsed.EndDate) {
+		t.Errorf("subscription without auto-renew moved to %s", end)
+	}
+
+	// Nothing is due again until the new period ends
+	service.ProcessRenewals()
+	clk.Set(wantEnds[1].Add(-time.Second))
+	service.ProcessRenewals()
+	if len(repo.renewals) != 2 {
+		t.Errorf("%d renewals recorded before the period ended, want 2", len(repo.renewals))
+	}
+	clk.Set(wantEnds[1])
+	service.ProcessRenewals()
+	if len(repo.renewals) != 3 {
+		t.Errorf("%d renewals recorded once the period ended, want 3", len(repo.renewals))
+	}
+}
@@ -0,0 +1,234 @@
+package services
+
+import (
+	"renew-guard/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats an export can be written in
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatJSON   = "json"
+	ExportFormatNDJSON = "ndjson"
+)
+
+// exportBatchSize is how many subscriptions an export holds in memory at once
+const exportBatchSize = 200
+
+// csvFormulaPrefixes start cells that spreadsheets evaluate as formulas
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// SubscriptionExportColumns are the CSV columns of an export. The import
+// fields come first and in import order so a file can be imported as it
+// is; the read-only columns that follow are ignored by imports.
+var SubscriptionExportColumns = append([]string{"id"}, append(SubscriptionImportFields,
+	"organization_id",
+	"end_date",
+	"last_notification_sent",
+	"created_at",
+	"updated_at",
+)...)
+
+// SubscriptionExport is a subscription as written by an export. Its JSON
+// keys follow SubscriptionExportColumns.
+type SubscriptionExport struct {
+	ID                   uint                    `json:"id"`
+	Name                 string                  `json:"name"`
+	StartDate            time.Time               `json:"start_date"`
+	DurationDays         int                     `json:"duration_days"`
+	BillingCycle         string                  `json:"billing_cycle"`
+	BillingInterval      int                     `json:"billing_interval"`
+	BillingUnit          string                  `json:"billing_unit"`
+	PriceMinor           int64                   `json:"price_minor"`
+	Currency             string                  `json:"currency"`
+	AutoRenew            bool                    `json:"auto_renew"`
+	NotificationEnabled  bool                    `json:"notification_enabled"`
+	ReminderDays         []int                   `json:"reminder_days"`
+	Timezone             string                  `json:"timezone"`
+	Channels             []string                `json:"channels"`
+	NotifyRoles          []string                `json:"notify_roles"`
+	Tags                 []string                `json:"tags"`
+	OrganizationID       *uint                   `json:"organization_id"`
+	EndDate              time.Time               `json:"end_date"`
+	LastNotificationSent *time.Time              `json:"last_notification_sent"`
+	CreatedAt            time.Time               `json:"created_at"`
+	UpdatedAt            time.Time               `json:"updated_at"`
+	NotificationLogs     []NotificationLogExport `json:"notification_logs,omitempty"`
+}
+
+// NotificationLogExport is one delivery attempt as written by an export
+type NotificationLogExport struct {
+	SentAt       time.Time `json:"sent_at"`
+	Channel      string    `json:"channel"`
+	Status       string    `json:"status"`
+	ErrorMessage string    `json:"error_message,omitempty"`
+	DaysBefore   *int      `json:"days_before,omitempty"`
+	Attempt      int       `json:"attempt"`
+	Digest       bool      `json:"digest"`
+}
+
+// newSubscriptionExport flattens a subscription, with its reminders and any
+// loaded logs, for export
+func newSubscriptionExport(subscription *models.Subscription, logs []models.NotificationLog) SubscriptionExport {
+	export := SubscriptionExport{
+		ID:                   subscription.ID,
+		Name:                 subscription.Name,
+		StartDate:            subscription.StartDate,
+		DurationDays:         subscription.DurationDays,
+		BillingCycle:         subscription.BillingCycle,
+		BillingInterval:      subscription.BillingInterval,
+		BillingUnit:          subscription.BillingUnit,
+		PriceMinor:           subscription.PriceMinor,
+		Currency:             subscription.Currency,
+		AutoRenew:            subscription.AutoRenew,
+		NotificationEnabled:  subscription.NotificationEnabled,
+		Timezone:             subscription.Timezone,
+		Channels:             subscription.Channels,
+		NotifyRoles:          subscription.NotifyRoles,
+		Tags:                 subscription.Tags,
+		OrganizationID:       subscription.OrganizationID,
+		EndDate:              subscription.EndDate,
+		LastNotificationSent: subscription.LastNotificationSent,
+		CreatedAt:            subscription.CreatedAt,
+		UpdatedAt:            subscription.UpdatedAt,
+	}
+
+	for _, reminder := range subscription.Reminders {
+		export.ReminderDays = append(export.ReminderDays, reminder.DaysBefore)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(export.ReminderDays)))
+
+	for _, log := range logs {
+		export.NotificationLogs = append(export.NotificationLogs, NotificationLogExport{
+			SentAt:       log.SentAt,
+			Channel:      log.Channel,
+			Status:       log.Status,
+			ErrorMessage: log.ErrorMessage,
+			DaysBefore:   log.DaysBefore,
+			Attempt:      log.Attempt,
+			Digest:       log.Digest,
+		})
+	}
+
+	return export
+}
+
+// CSVRecord returns the export's cells in SubscriptionExportColumns order.
+// Times are RFC 3339 in UTC and lists are joined with ";". Cells that would
+// read as spreadsheet formulas are prefixed with a single quote.
+func (e SubscriptionExport) CSVRecord() []string {
+	reminderDays := make([]string, len(e.ReminderDays))
+	for i, days := range e.ReminderDays {
+		reminderDays[i] = strconv.Itoa(days)
+	}
+
+	organizationID := ""
+	if e.OrganizationID != nil {
+		organizationID = strconv.FormatUint(uint64(*e.OrganizationID), 10)
+	}
+
+	lastNotificationSent := ""
+	if e.LastNotificationSent != nil {
+		lastNotificationSent = formatExportTime(*e.LastNotificationSent)
+	}
+
+	billingInterval := ""
+	if e.BillingInterval > 0 {
+		billingInterval = strconv.Itoa(e.BillingInterval)
+	}
+
+	record := []string{
+		strconv.FormatUint(uint64(e.ID), 10),
+		e.Name,
+		formatExportTime(e.StartDate),
+		strconv.Itoa(e.DurationDays),
+		e.BillingCycle,
+		billingInterval,
+		e.BillingUnit,
+		strconv.FormatInt(e.PriceMinor, 10),
+		e.Currency,
+		strconv.FormatBool(e.AutoRenew),
+		strconv.FormatBool(e.NotificationEnabled),
+		strings.Join(reminderDays, ";"),
+		e.Timezone,
+		strings.Join(e.Channels, ";"),
+		strings.Join(e.NotifyRoles, ";"),
+		strings.Join(e.Tags, ";"),
+		organizationID,
+		formatExportTime(e.EndDate),
+		lastNotificationSent,
+		formatExportTime(e.CreatedAt),
+		formatExportTime(e.UpdatedAt),
+	}
+	for i, cell := range record {
+		record[i] = neutralizeCSVFormula(cell)
+	}
+	return record
+}
+
+// neutralizeCSVFormula prefixes a cell that a spreadsheet would evaluate as a
+// formula with a single quote, so names and tags from other organization
+// members cannot run in whoever opens the export. Imports strip the quote.
+func neutralizeCSVFormula(cell string) string {
+	if cell != "" && strings.IndexByte(csvFormulaPrefixes, cell[0]) >= 0 {
+		return "'" + cell
+	}
+	return cell
+}
+
+// restoreCSVFormula undoes neutralizeCSVFormula
+func restoreCSVFormula(cell string) string {
+	if len(cell) > 1 && cell[0] == '\'' && strings.IndexByte(csvFormulaPrefixes, cell[1]) >= 0 {
+		return cell[1:]
+	}
+	return cell
+}
+
+// ExportFilename names an export file after today's date
+func (s *subscriptionService) ExportFilename(format string) string {
+	return "subscriptions-" + s.clock.Now().UTC().Format("2006-01-02") + "." + format
+}
+
+func formatExportTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Export passes every subscription the user can see, or only those of
+// organizationID when given, to fn in ID order, optionally with their
+// notification history. Subscriptions are read in batches so large exports
+// can be streamed.
+func (s *subscriptionService) Export(userID uint, organizationID *uint, includeLogs bool, fn func(SubscriptionExport) error) error {
+	if organizationID != nil {
+		if _, err := s.organizationService.Authorize(*organizationID, userID, models.OrganizationRoleViewer); err != nil {
+			return err
+		}
+	}
+
+	return s.subscriptionRepo.FindEach(userID, organizationID, exportBatchSize, func(batch []models.Subscription) error {
+		logsBySubscription := make(map[uint][]models.NotificationLog)
+		if includeLogs {
+			ids := make([]uint, len(batch))
+			for i, subscription := range batch {
+				ids[i] = subscription.ID
+			}
+
+			logs, err := s.notificationLogRepo.FindBySubscriptionIDs(ids)
+			if err != nil {
+				return err
+			}
+			for _, log := range logs {
+				logsBySubscription[log.SubscriptionID] = append(logsBySubscription[log.SubscriptionID], log)
+			}
+		}
+
+		for i := range batch {
+			if err := fn(newSubscriptionExport(&batch[i], logsBySubscription[batch[i].ID])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
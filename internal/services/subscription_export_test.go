@@ -0,0 +1,67 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCSVRecordNeutralizesFormulas(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	export := SubscriptionExport{
+		ID:           7,
+		Name:         `=HYPERLINK("http://evil.example","Netflix")`,
+		StartDate:    start,
+		DurationDays: 30,
+		Currency:     "USD",
+		Tags:         []string{"@admin", "work"},
+		EndDate:      start.AddDate(0, 0, 30),
+	}
+
+	record := export.CSVRecord()
+	cells := make(map[string]string, len(record))
+	for i, column := range SubscriptionExportColumns {
+		cells[column] = record[i]
+	}
+
+	if cells["name"] != `'=HYPERLINK("http://evil.example","Netflix")` {
+		t.Errorf("name cell = %q, want it prefixed with a quote", cells["name"])
+	}
+	if cells["tags"] != "'@admin;work" {
+		t.Errorf("tags cell = %q, want it prefixed with a quote", cells["tags"])
+	}
+	if cells["currency"] != "USD" {
+		t.Errorf("harmless cell changed to %q", cells["currency"])
+	}
+
+	// The quote is stripped again on import, so the file round-trips
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(SubscriptionExportColumns)
+	writer.Write(record)
+	writer.Flush()
+
+	rows, err := ParseSubscriptionImport(&buf, ImportFormatCSV, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].Err != nil {
+		t.Fatalf("import = %+v", rows)
+	}
+	if rows[0].Input.Name != export.Name {
+		t.Errorf("imported name = %q, want %q", rows[0].Input.Name, export.Name)
+	}
+	if !reflect.DeepEqual(rows[0].Input.Tags, export.Tags) {
+		t.Errorf("imported tags = %q, want %q", rows[0].Input.Tags, export.Tags)
+	}
+}
+
+func TestRestoreCSVFormulaKeepsOtherQuotes(t *testing.T) {
+	for _, cell := range []string{"'", "'quoted'", "it's", ""} {
+		if got := restoreCSVFormula(cell); got != cell {
+			t.Errorf("restoreCSVFormula(%q) = %q", cell, got)
+		}
+	}
+}
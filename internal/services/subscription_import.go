@@ -0,0 +1,516 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"renew-guard/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Formats an import can be read from
+const (
+	ImportFormatCSV  = "csv"
+	ImportFormatJSON = "json"
+)
+
+// MaxImportRows bounds the number of subscriptions a single import may hold
+const MaxImportRows = 1000
+
+// Outcomes of a single import row
+const (
+	ImportRowCreated   = "created"
+	ImportRowValid     = "valid"     // Would be created; dry runs and rejected atomic imports
+	ImportRowDuplicate = "duplicate" // A subscription with the same name already exists
+	ImportRowInvalid   = "invalid"
+)
+
+var (
+	ErrUnsupportedImportFormat = errors.New("import format must be csv or json")
+	ErrInvalidImportFile       = errors.New("import file could not be read")
+	ErrImportMissingName       = errors.New("import has no name column")
+	ErrTooManyImportRows       = fmt.Errorf("an import may hold at most %d subscriptions", MaxImportRows)
+	ErrInvalidImportMapping    = errors.New("column mapping must be source:field pairs naming import fields")
+	ErrImportRejected          = errors.New("import rejected because some rows are invalid")
+)
+
+// SubscriptionImportFields are the columns an import understands, in the
+// order exports write them. List fields hold values separated by ";".
+var SubscriptionImportFields = []string{
+	"name",
+	"start_date",
+	"duration_days",
+	"billing_cycle",
+	"billing_interval",
+	"billing_unit",
+	"price_minor",
+	"currency",
+	"auto_renew",
+	"notification_enabled",
+	"reminder_days",
+	"timezone",
+	"channels",
+	"notify_roles",
+	"tags",
+}
+
+// ImportRow is one parsed subscription of an import
+type ImportRow struct {
+	Row                 int // Line of a CSV file, position in a JSON array; both from 1
+	Input               SubscriptionInput
+	NotificationEnabled *bool // Unset keeps notifications on
+	Err                 error // Why the row could not be parsed
+}
+
+// ImportOptions controls how Import treats the parsed rows
+type ImportOptions struct {
+	OrganizationID  *uint // Share every imported subscription with this organization
+	DryRun          bool  // Validate only; nothing is created
+	Atomic          bool  // Create nothing unless every row is valid
+	AllowDuplicates bool  // Create rows whose name is already taken instead of skipping them
+}
+
+// ImportRowResult reports what happened to one row
+type ImportRowResult struct {
+	Row            int    `json:"row"`
+	Name           string `json:"name,omitempty"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	SubscriptionID uint   `json:"subscription_id,omitempty"`
+}
+
+// ImportReport summarises an import row by row
+type ImportReport struct {
+	DryRun     bool              `json:"dry_run"`
+	Atomic     bool              `json:"atomic"`
+	Total      int               `json:"total"`
+	Created    int               `json:"created"`
+	Valid      int               `json:"valid"`
+	Duplicates int               `json:"duplicates"`
+	Invalid    int               `json:"invalid"`
+	Rows       []ImportRowResult `json:"rows"`
+}
+
+// Import validates rows and creates the valid ones for the user. Rows named
+// like an existing subscription in the same scope, or like an earlier row,
+// are skipped unless duplicates are allowed. Atomic imports with invalid
+// rows create nothing and return the report along with ErrImportRejected.
+func (s *subscriptionService) Import(userID uint, email string, rows []ImportRow, options ImportOptions) (*ImportReport, error) {
+	if len(rows) > MaxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	// Importing into an organization takes the same role as creating in it
+	existing, err := s.findImportScope(userID, options.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(existing)+len(rows))
+	for _, subscription := range existing {
+		seen[importNameKey(subscription.Name)] = true
+	}
+
+	report := &ImportReport{
+		DryRun: options.DryRun,
+		Atomic: options.Atomic,
+		Total:  len(rows),
+		Rows:   make([]ImportRowResult, len(rows)),
+	}
+	timezone := s.defaultTimezone(userID)
+
+	var pending []*models.Subscription
+	var pendingRows []int
+
+	for i, row := range rows {
+		result := &report.Rows[i]
+		result.Row = row.Row
+		result.Name = strings.TrimSpace(row.Input.Name)
+
+		subscription, err := buildImportedSubscription(userID, email, timezone, row, options.OrganizationID)
+		if err == nil {
+			err = s.checkChannelsConfigured(subscription, userID)
+		}
+		if err != nil {
+			result.Status = ImportRowInvalid
+			result.Error = err.Error()
+			report.Invalid++
+			continue
+		}
+
+		key := importNameKey(subscription.Name)
+		if seen[key] && !options.AllowDuplicates {
+			result.Status = ImportRowDuplicate
+			report.Duplicates++
+			continue
+		}
+		seen[key] = true
+
+		result.Status = ImportRowValid
+		report.Valid++
+		pending = append(pending, subscription)
+		pendingRows = append(pendingRows, i)
+	}
+
+	if options.Atomic && report.Invalid > 0 {
+		return report, ErrImportRejected
+	}
+	if options.DryRun || len(pending) == 0 {
+		return report, nil
+	}
+
+	if options.Atomic {
+		if err := s.subscriptionRepo.CreateAll(pending); err != nil {
+			return nil, err
+		}
+		for i, subscription := range pending {
+			markImported(report, pendingRows[i], subscription)
+		}
+		return report, nil
+	}
+
+	for i, subscription := range pending {
+		if err := s.subscriptionRepo.Create(subscription); err != nil {
+			result := &report.Rows[pendingRows[i]]
+			result.Status = ImportRowInvalid
+			result.Error = "subscription could not be saved"
+			report.Valid--
+			report.Invalid++
+			continue
+		}
+		markImported(report, pendingRows[i], subscription)
+	}
+	return report, nil
+}
+
+// findImportScope returns the subscriptions whose names an import into the
+// user's personal subscriptions, or into organizationID, must not repeat
+func (s *subscriptionService) findImportScope(userID uint, organizationID *uint) ([]models.Subscription, error) {
+	if organizationID != nil {
+		if _, err := s.organizationService.Authorize(*organizationID, userID, models.OrganizationRoleMember); err != nil {
+			return nil, err
+		}
+		return s.subscriptionRepo.FindByOrganizationID(*organizationID)
+	}
+
+	visible, err := s.subscriptionRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	personal := make([]models.Subscription, 0, len(visible))
+	for _, subscription := range visible {
+		if !subscription.IsShared() {
+			personal = append(personal, subscription)
+		}
+	}
+	return personal, nil
+}
+
+// buildImportedSubscription validates a parsed row and builds its unsaved subscription
+func buildImportedSubscription(userID uint, email, timezone string, row ImportRow, organizationID *uint) (*models.Subscription, error) {
+	if row.Err != nil {
+		return nil, row.Err
+	}
+
+	input := row.Input
+	input.Name = strings.TrimSpace(input.Name)
+	input.OrganizationID = organizationID
+
+	// Rows exported from an organization keep their roles only when imported into one
+	if organizationID == nil {
+		input.NotifyRoles = nil
+	}
+
+	// Name the missing field rather than report "invalid subscription data"
+	if input.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if input.StartDate.IsZero() {
+		return nil, errors.New("start_date is required")
+	}
+	if input.BillingCycle == "" && input.DurationDays <= 0 {
+		return nil, errors.New("duration_days or billing_cycle is required")
+	}
+
+	subscription, err := newSubscription(userID, email, timezone, input)
+	if err != nil {
+		return nil, err
+	}
+	if row.NotificationEnabled != nil {
+		subscription.NotificationEnabled = *row.NotificationEnabled
+	}
+	return subscription, nil
+}
+
+func markImported(report *ImportReport, index int, subscription *models.Subscription) {
+	result := &report.Rows[index]
+	result.Status = ImportRowCreated
+	result.SubscriptionID = subscription.ID
+	report.Valid--
+	report.Created++
+}
+
+// importNameKey is the form under which names are compared for duplicates
+func importNameKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ParseImportMapping reads a column mapping of the form "Source:field,...",
+// keyed by the lower-cased source column
+func ParseImportMapping(raw string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		source, field, ok := strings.Cut(pair, ":")
+		source = strings.ToLower(strings.TrimSpace(source))
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || source == "" || !isImportField(field) {
+			return nil, ErrInvalidImportMapping
+		}
+		mapping[source] = field
+	}
+	return mapping, nil
+}
+
+// ParseSubscriptionImport reads the rows of a CSV file with a header line,
+// or of a JSON array of objects. Columns and keys are matched to import
+// fields through mapping first, then by name; others are ignored. Values
+// that cannot be parsed are recorded on their row rather than failing the
+// whole import.
+func ParseSubscriptionImport(r io.Reader, format string, mapping map[string]string) ([]ImportRow, error) {
+	switch format {
+	case ImportFormatCSV:
+		return parseCSVImport(r, mapping)
+	case ImportFormatJSON:
+		return parseJSONImport(r, mapping)
+	default:
+		return nil, ErrUnsupportedImportFormat
+	}
+}
+
+func parseCSVImport(r io.Reader, mapping map[string]string) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, ErrInvalidImportFile
+	}
+
+	// Spreadsheet exports often start with a byte order mark
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	fields := make([]string, len(header))
+	hasName := false
+	for i, column := range header {
+		fields[i] = resolveImportField(column, mapping)
+		hasName = hasName || fields[i] == "name"
+	}
+	if !hasName {
+		return nil, ErrImportMissingName
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, ErrInvalidImportFile
+		}
+		if len(rows) == MaxImportRows {
+			return nil, ErrTooManyImportRows
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := ImportRow{Row: line}
+		for i, value := range record {
+			if i < len(fields) && fields[i] != "" && row.Err == nil {
+				row.Err = applyImportField(&row, fields[i], restoreCSVFormula(value))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseJSONImport(r io.Reader, mapping map[string]string) ([]ImportRow, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var records []map[string]interface{}
+	if err := decoder.Decode(&records); err != nil {
+		return nil, ErrInvalidImportFile
+	}
+	if len(records) > MaxImportRows {
+		return nil, ErrTooManyImportRows
+	}
+
+	rows := make([]ImportRow, 0, len(records))
+	for i, record := range records {
+		row := ImportRow{Row: i + 1}
+
+		// Visit keys in a fixed order so the same file reports the same error
+		keys := make([]string, 0, len(record))
+		for key := range record {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			raw := record[key]
+			field := resolveImportField(key, mapping)
+			if field == "" {
+				continue
+			}
+			value, err := importValueString(raw)
+			if err != nil {
+				row.Err = fmt.Errorf("%s: %w", field, err)
+				break
+			}
+			if row.Err = applyImportField(&row, field, value); row.Err != nil {
+				break
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// resolveImportField returns the import field a column feeds, or "" if none
+func resolveImportField(column string, mapping map[string]string) string {
+	column = strings.ToLower(strings.TrimSpace(column))
+	if field, ok := mapping[column]; ok {
+		return field
+	}
+	if isImportField(column) {
+		return column
+	}
+	return ""
+}
+
+func isImportField(field string) bool {
+	for _, known := range SubscriptionImportFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// importValueString flattens a JSON value into the text form CSV cells use
+func importValueString(raw interface{}) (string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case []interface{}:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			text, err := importValueString(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, text)
+		}
+		return strings.Join(items, ";"), nil
+	default:
+		return "", errors.New("unsupported value")
+	}
+}
+
+// applyImportField parses value into field of the row. Empty values leave
+// the field unset.
+func applyImportField(row *ImportRow, field, value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+
+	input := &row.Input
+	var err error
+
+	switch field {
+	case "name":
+		input.Name = value
+	case "start_date":
+		input.StartDate, err = parseImportDate(value)
+	case "duration_days":
+		input.DurationDays, err = strconv.Atoi(value)
+	case "billing_cycle":
+		input.BillingCycle = strings.ToLower(value)
+	case "billing_interval":
+		input.BillingInterval, err = strconv.Atoi(value)
+	case "billing_unit":
+		input.BillingUnit = strings.ToLower(value)
+	case "price_minor":
+		input.PriceMinor, err = strconv.ParseInt(value, 10, 64)
+	case "currency":
+		input.Currency = value
+	case "auto_renew":
+		input.AutoRenew, err = strconv.ParseBool(value)
+	case "notification_enabled":
+		var enabled bool
+		if enabled, err = strconv.ParseBool(value); err == nil {
+			row.NotificationEnabled = &enabled
+		}
+	case "reminder_days":
+		for _, item := range splitImportList(value) {
+			days, convErr := strconv.Atoi(item)
+			if convErr != nil {
+				err = convErr
+				break
+			}
+			input.ReminderDays = append(input.ReminderDays, days)
+		}
+	case "timezone":
+		input.Timezone = value
+	case "channels":
+		input.Channels = splitImportList(value)
+	case "notify_roles":
+		input.NotifyRoles = splitImportList(value)
+	case "tags":
+		input.Tags = splitImportList(value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q", field, value)
+	}
+	return nil
+}
+
+// parseImportDate accepts RFC 3339 timestamps and plain dates, the latter
+// read as midnight UTC
+func parseImportDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// splitImportList splits a list cell on ";" or ","
+func splitImportList(value string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,308 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+)
+
+func (r *memorySubscriptionRepository) CreateAll(subscriptions []*models.Subscription) error {
+	for _, subscription := range subscriptions {
+		r.Create(subscription)
+	}
+	return nil
+}
+
+// unsavableSubscriptionRepository fails to save subscriptions with one name
+type unsavableSubscriptionRepository struct {
+	*memorySubscriptionRepository
+	name string
+}
+
+func (r *unsavableSubscriptionRepository) Create(subscription *models.Subscription) error {
+	if subscription.Name == r.name {
+		return errors.New("connection reset")
+	}
+	return r.memorySubscriptionRepository.Create(subscription)
+}
+
+func newImportTestService(repo *memorySubscriptionRepository) SubscriptionService {
+	return NewSubscriptionService(repo, nil, nil, nil, &memoryPreferenceRepository{}, memberOrganizationService{},
+		clock.NewFake(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+// importRows builds valid rows for the names, numbered from line 2
+func importRows(names ...string) []ImportRow {
+	rows := make([]ImportRow, len(names))
+	for i, name := range names {
+		rows[i] = ImportRow{Row: i + 2, Input: SubscriptionInput{
+			Name:         name,
+			StartDate:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			DurationDays: 30,
+		}}
+	}
+	return rows
+}
+
+func rowStatuses(report *ImportReport) []string {
+	statuses := make([]string, len(report.Rows))
+	for i, row := range report.Rows {
+		statuses[i] = row.Status
+	}
+	return statuses
+}
+
+func TestParseCSVImport(t *testing.T) {
+	file := "\ufeffService,Start,duration_days,tags,reminder_days,Notes\n" +
+		"Netflix,2024-03-01,30,video;family,\"7,1\",shared with the kids\n" +
+		"Gym,2024-03-01,monthly,,,\n" +
+		"Hosting,2024-03-01T10:00:00+02:00,365,,,\n"
+	mapping, err := ParseImportMapping("Service:name, start:start_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ParseSubscriptionImport(strings.NewReader(file), ImportFormatCSV, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("parsed %d rows, want 3", len(rows))
+	}
+
+	netflix := rows[0]
+	if netflix.Row != 2 || netflix.Err != nil || netflix.Input.Name != "Netflix" || netflix.Input.DurationDays != 30 {
+		t.Errorf("first row = %+v", netflix)
+	}
+	if !netflix.Input.StartDate.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("plain date read as %s, want midnight UTC", netflix.Input.StartDate)
+	}
+	if !reflect.DeepEqual(netflix.Input.Tags, []string{"video", "family"}) || !reflect.DeepEqual(netflix.Input.ReminderDays, []int{7, 1}) {
+		t.Errorf("lists read as tags %v, reminders %v", netflix.Input.Tags, netflix.Input.ReminderDays)
+	}
+
+	// A bad cell fails its own row only
+	if rows[1].Row != 3 || rows[1].Err == nil || !strings.Contains(rows[1].Err.Error(), "duration_days") {
+		t.Errorf("second row error = %v, want one naming duration_days", rows[1].Err)
+	}
+	if rows[2].Err != nil || !rows[2].Input.StartDate.Equal(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("third row = %+v", rows[2])
+	}
+}
+
+func TestParseImportRejectsFiles(t *testing.T) {
+	tooMany := func(prefix, row, sep, suffix string) string {
+		rows := make([]string, MaxImportRows+1)
+		for i := range rows {
+			rows[i] = row
+		}
+		return prefix + strings.Join(rows, sep) + suffix
+	}
+
+	tests := []struct {
+		name   string
+		format string
+		file   string
+		want   error
+	}{
+		{"no name column", ImportFormatCSV, "title,duration_days\nNetflix,30\n", ErrImportMissingName},
+		{"empty csv", ImportFormatCSV, "", ErrInvalidImportFile},
+		{"too many csv rows", ImportFormatCSV, tooMany("name\n", "Netflix", "\n", "\n"), ErrTooManyImportRows},
+		{"json object", ImportFormatJSON, `{"name": "Netflix"}`, ErrInvalidImportFile},
+		{"too many json rows", ImportFormatJSON, tooMany("[", `{"name": "Netflix"}`, ",", "]"), ErrTooManyImportRows},
+		{"unknown format", "xlsx", "", ErrUnsupportedImportFormat},
+	}
+	for _, tt := range tests {
+		if _, err := ParseSubscriptionImport(strings.NewReader(tt.file), tt.format, nil); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	// Exactly the limit is fine
+	file := tooMany("name\n", "Netflix", "\n", "\n")
+	file = file[:strings.LastIndex(strings.TrimSuffix(file, "\n"), "\n")+1]
+	if rows, err := ParseSubscriptionImport(strings.NewReader(file), ImportFormatCSV, nil); err != nil || len(rows) != MaxImportRows {
+		t.Errorf("import of %d rows: %d parsed, error %v", MaxImportRows, len(rows), err)
+	}
+}
+
+func TestParseImportMapping(t *testing.T) {
+	mapping, err := ParseImportMapping(" Service : name ,Renews:start_date")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"service": "name", "renews": "start_date"}; !reflect.DeepEqual(mapping, want) {
+		t.Errorf("mapping = %v, want %v", mapping, want)
+	}
+
+	for _, raw := range []string{"Service", "Service:title", ":name", "Service:name,"} {
+		if _, err := ParseImportMapping(raw); !errors.Is(err, ErrInvalidImportMapping) {
+			t.Errorf("mapping %q: error = %v, want %v", raw, err, ErrInvalidImportMapping)
+		}
+	}
+}
+
+func TestParseJSONImportFlattensValues(t *testing.T) {
+	file := `[
+		{"title": "Netflix", "start_date": "2024-03-01", "duration_days": 30, "auto_renew": true,
+		 "tags": ["video", "family"], "reminder_days": [7, 1], "notification_enabled": false, "id": 12},
+		{"name": "Gym", "tags": {"kind": "sport"}},
+		{"name": "Hosting", "channels": null}
+	]`
+	rows, err := ParseSubscriptionImport(strings.NewReader(file), ImportFormatJSON, map[string]string{"title": "name"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	netflix := rows[0]
+	if netflix.Err != nil || netflix.Row != 1 || netflix.Input.Name != "Netflix" || netflix.Input.DurationDays != 30 || !netflix.Input.AutoRenew {
+		t.Errorf("first row = %+v", netflix)
+	}
+	if !reflect.DeepEqual(netflix.Input.Tags, []string{"video", "family"}) || !reflect.DeepEqual(netflix.Input.ReminderDays, []int{7, 1}) {
+		t.Errorf("lists read as tags %v, reminders %v", netflix.Input.Tags, netflix.Input.ReminderDays)
+	}
+	if netflix.NotificationEnabled == nil || *netflix.NotificationEnabled {
+		t.Errorf("notification_enabled = %v, want false", netflix.NotificationEnabled)
+	}
+
+	if rows[1].Err == nil || !strings.Contains(rows[1].Err.Error(), "tags") {
+		t.Errorf("object value: error = %v, want one naming tags", rows[1].Err)
+	}
+	if rows[2].Err != nil || rows[2].Input.Channels != nil {
+		t.Errorf("null value: %+v, want it left unset", rows[2])
+	}
+}
+
+func TestImportModes(t *testing.T) {
+	invalid := ImportRow{Row: 4, Input: SubscriptionInput{Name: "Broken", StartDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}}
+	rows := append(importRows("Netflix", "Gym"), invalid)
+
+	tests := []struct {
+		name         string
+		options      ImportOptions
+		wantErr      error
+		wantStatuses []string
+		wantCreated  int
+	}{
+		{
+			name:         "partial",
+			wantStatuses: []string{ImportRowCreated, ImportRowCreated, ImportRowInvalid},
+			wantCreated:  2,
+		},
+		{
+			name:         "dry run",
+			options:      ImportOptions{DryRun: true},
+			wantStatuses: []string{ImportRowValid, ImportRowValid, ImportRowInvalid},
+		},
+		{
+			name:         "atomic",
+			options:      ImportOptions{Atomic: true},
+			wantErr:      ErrImportRejected,
+			wantStatuses: []string{ImportRowValid, ImportRowValid, ImportRowInvalid},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &memorySubscriptionRepository{}
+			report, err := newImportTestService(repo).Import(1, "owner@example.com", rows, tt.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got := rowStatuses(report); !reflect.DeepEqual(got, tt.wantStatuses) {
+				t.Errorf("statuses = %v, want %v", got, tt.wantStatuses)
+			}
+			if len(repo.subscriptions) != tt.wantCreated || report.Created != tt.wantCreated {
+				t.Errorf("created %d (reported %d), want %d", len(repo.subscriptions), report.Created, tt.wantCreated)
+			}
+			if report.Rows[2].Error == "" {
+				t.Error("invalid row has no error")
+			}
+		})
+	}
+
+	// Atomic imports of valid rows create them all
+	repo := &memorySubscriptionRepository{}
+	report, err := newImportTestService(repo).Import(1, "owner@example.com", importRows("Netflix", "Gym"), ImportOptions{Atomic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != 2 || len(repo.subscriptions) != 2 || report.Rows[1].SubscriptionID != 2 {
+		t.Errorf("atomic import report %+v", report)
+	}
+}
+
+func TestImportReportsRowsThatFailToSave(t *testing.T) {
+	repo := &unsavableSubscriptionRepository{memorySubscriptionRepository: &memorySubscriptionRepository{}, name: "Gym"}
+	service := NewSubscriptionService(repo, nil, nil, nil, &memoryPreferenceRepository{}, memberOrganizationService{},
+		clock.NewFake(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+
+	report, err := service.Import(1, "owner@example.com", importRows("Netflix", "Gym", "Hosting"), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{ImportRowCreated, ImportRowInvalid, ImportRowCreated}; !reflect.DeepEqual(rowStatuses(report), want) {
+		t.Errorf("statuses = %v, want %v", rowStatuses(report), want)
+	}
+	if report.Created != 2 || report.Invalid != 1 || report.Valid != 0 {
+		t.Errorf("report counts created %d, invalid %d, valid %d", report.Created, report.Invalid, report.Valid)
+	}
+}
+
+func TestImportSkipsDuplicates(t *testing.T) {
+	organizationID := uint(10)
+	repo := &memorySubscriptionRepository{subscriptions: []models.Subscription{
+		{ID: 1, UserID: 1, Name: "Netflix"},
+		{ID: 2, UserID: 1, Name: "Gym", OrganizationID: &organizationID},
+		{ID: 3, UserID: 2, Name: "Hosting"},
+	}}
+	service := newImportTestService(repo)
+
+	// Names repeat across existing personal subscriptions and within the file,
+	// ignoring case and spaces; shared and other users' names do not count
+	rows := importRows(" NETFLIX ", "Gym", "Hosting", "gym")
+	report, err := service.Import(1, "owner@example.com", rows, ImportOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{ImportRowDuplicate, ImportRowValid, ImportRowValid, ImportRowDuplicate}
+	if got := rowStatuses(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("personal import statuses = %v, want %v", got, want)
+	}
+
+	report, err = service.Import(1, "owner@example.com", rows, ImportOptions{OrganizationID: &organizationID, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []string{ImportRowValid, ImportRowDuplicate, ImportRowValid, ImportRowDuplicate}
+	if got := rowStatuses(report); !reflect.DeepEqual(got, want) {
+		t.Errorf("organization import statuses = %v, want %v", got, want)
+	}
+
+	report, err = service.Import(1, "owner@example.com", rows, ImportOptions{AllowDuplicates: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Created != len(rows) || report.Duplicates != 0 {
+		t.Errorf("allowing duplicates created %d and skipped %d", report.Created, report.Duplicates)
+	}
+}
+
+func TestImportLimitsRows(t *testing.T) {
+	names := make([]string, MaxImportRows+1)
+	for i := range names {
+		names[i] = fmt.Sprintf("Subscription %d", i)
+	}
+	repo := &memorySubscriptionRepository{}
+	if _, err := newImportTestService(repo).Import(1, "owner@example.com", importRows(names...), ImportOptions{}); !errors.Is(err, ErrTooManyImportRows) {
+		t.Errorf("error = %v, want %v", err, ErrTooManyImportRows)
+	}
+	if len(repo.subscriptions) != 0 {
+		t.Errorf("oversized import created %d subscriptions", len(repo.subscriptions))
+	}
+}
@@ -2,51 +2,145 @@ package services
 
 import (
 	"errors"
+	"math"
 	"renew-guard/internal/models"
 	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/utils"
+	"sort"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 var (
-	ErrSubscriptionNotFound   = errors.New("subscription not found")
-	ErrUnauthorizedAccess     = errors.New("unauthorized access to subscription")
+	ErrSubscriptionNotFound    = errors.New("subscription not found")
+	ErrUnauthorizedAccess      = errors.New("unauthorized access to subscription")
 	ErrInvalidSubscriptionData = errors.New("invalid subscription data")
+	ErrInvalidBillingCycle     = models.ErrInvalidBillingCycle
+	ErrInvalidPrice            = errors.New("invalid price or currency")
+	ErrInvalidReminders        = errors.New("reminder offsets must be between 0 and 365 days")
+	ErrInvalidSubscriptionZone = errors.New("invalid subscription timezone")
+	ErrInvalidNotifyRoles      = errors.New("notify roles must be organization roles on a shared subscription")
+	ErrInvalidTags             = errors.New("tags must be 1-32 characters, at most 20 per subscription")
+	ErrInvalidFilter           = errors.New("invalid subscription filter")
 )
 
+const (
+	// maxReminderDaysBefore is the furthest ahead a reminder may be scheduled
+	maxReminderDaysBefore = 365
+
+	maxTagsPerSubscription = 20
+	maxTagLength           = 32
+)
+
+// DefaultPageSize applies when a listing does not ask for a page size
+const DefaultPageSize = 20
+
+// SubscriptionInput holds the user-editable fields of a subscription.
+// Either DurationDays or BillingCycle must be set; BillingInterval and
+// BillingUnit are only used by the "custom" cycle.
+type SubscriptionInput struct {
+	Name                string
+	StartDate           time.Time
+	DurationDays        int
+	BillingCycle        string
+	BillingInterval     int
+	BillingUnit         string
+	NotificationEnabled bool
+	AutoRenew           bool
+	PriceMinor          int64
+	Currency            string
+	ReminderDays        []int    // nil leaves existing reminders untouched on update
+	Timezone            string   // IANA zone; empty keeps the current zone, or the owner's preference on create
+	Channels            []string // nil leaves the override untouched, empty inherits the owner's channels
+	OrganizationID      *uint    // Shares the subscription with an organization; only read on create
+	NotifyRoles         []string // Organization roles reminded of a shared subscription; nil leaves them untouched
+	Tags                []string // Labels to filter by; nil leaves them untouched
+}
+
+// CurrencySpend is the normalised recurring spend in a single currency
+type CurrencySpend struct {
+	Currency          string `json:"currency"`
+	MonthlyMinor      int64  `json:"monthly_minor"`
+	YearlyMinor       int64  `json:"yearly_minor"`
+	Monthly           string `json:"monthly"`
+	Yearly            string `json:"yearly"`
+	SubscriptionCount int    `json:"subscription_count"`
+}
+
 type SubscriptionService interface {
-	Create(userID uint, email string, name string, startDate time.Time, durationDays int) (*models.Subscription, error)
+	Create(userID uint, email string, input SubscriptionInput) (*models.Subscription, error)
 	GetByID(id, userID uint) (*models.Subscription, error)
-	GetAllByUserID(userID uint) ([]models.Subscription, error)
-	Update(id, userID uint, name string, startDate time.Time, durationDays int, notificationEnabled bool) (*models.Subscription, error)
+	GetAllByUserID(userID uint, filter repositories.SubscriptionFilter) ([]models.Subscription, int64, error)
+	Update(id, userID uint, input SubscriptionInput) (*models.Subscription, error)
 	Delete(id, userID uint) error
 	ToggleNotification(id, userID uint, enabled bool) (*models.Subscription, error)
+	GetRenewals(id, userID uint) ([]models.SubscriptionRenewal, error)
+	GetSpendSummary(userID uint, organizationID *uint) ([]CurrencySpend, error)
+	GetReminders(id, userID uint) ([]models.SubscriptionReminder, error)
+	SetReminders(id, userID uint, daysBefore []int) ([]models.SubscriptionReminder, error)
+	Import(userID uint, email string, rows []ImportRow, options ImportOptions) (*ImportReport, error)
+	Export(userID uint, organizationID *uint, includeLogs bool, fn func(SubscriptionExport) error) error
+	ExportFilename(format string) string
+	Location(subscription *models.Subscription) *time.Location
 }
 
 type subscriptionService struct {
-	subscriptionRepo repositories.SubscriptionRepository
+	subscriptionRepo    repositories.SubscriptionRepository
+	renewalRepo         repositories.SubscriptionRenewalRepository
+	reminderRepo        repositories.SubscriptionReminderRepository
+	notificationLogRepo repositories.NotificationLogRepository
+	preferenceRepo      repositories.UserPreferenceRepository
+	organizationService OrganizationService
+	clock               clock.Clock
 }
 
-func NewSubscriptionService(subscriptionRepo repositories.SubscriptionRepository) SubscriptionService {
+func NewSubscriptionService(
+	subscriptionRepo repositories.SubscriptionRepository,
+	renewalRepo repositories.SubscriptionRenewalRepository,
+	reminderRepo repositories.SubscriptionReminderRepository,
+	notificationLogRepo repositories.NotificationLogRepository,
+	preferenceRepo repositories.UserPreferenceRepository,
+	organizationService OrganizationService,
+	clk clock.Clock,
+) SubscriptionService {
 	return &subscriptionService{
-		subscriptionRepo: subscriptionRepo,
+		subscriptionRepo:    subscriptionRepo,
+		renewalRepo:         renewalRepo,
+		reminderRepo:        reminderRepo,
+		notificationLogRepo: notificationLogRepo,
+		preferenceRepo:      preferenceRepo,
+		organizationService: organizationService,
+		clock:               clk,
 	}
 }
 
-func (s *subscriptionService) Create(userID uint, email string, name string, startDate time.Time, durationDays int) (*models.Subscription, error) {
-	// Validate input
-	if name == "" || durationDays <= 0 {
-		return nil, ErrInvalidSubscriptionData
+// Location is the zone the subscription's dates are shown to its owner in,
+// the same one reminders and calendar invites use
+func (s *subscriptionService) Location(subscription *models.Subscription) *time.Location {
+	preference, err := s.preferenceRepo.FindByUserID(subscription.UserID)
+	if err != nil {
+		return subscription.Location()
 	}
+	return subscription.LocationFor(preference)
+}
 
-	subscription := &models.Subscription{
-		UserID:              userID,
-		Email:               email,
-		Name:                name,
-		StartDate:           startDate,
-		DurationDays:        durationDays,
-		NotificationEnabled: true,
+func (s *subscriptionService) Create(userID uint, email string, input SubscriptionInput) (*models.Subscription, error) {
+	// Members and above may add subscriptions to an organization
+	if input.OrganizationID != nil {
+		if _, err := s.organizationService.Authorize(*input.OrganizationID, userID, models.OrganizationRoleMember); err != nil {
+			return nil, err
+		}
+	}
+
+	subscription, err := newSubscription(userID, email, s.defaultTimezone(userID), input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkChannelsConfigured(subscription, userID); err != nil {
+		return nil, err
 	}
 
 	if err := s.subscriptionRepo.Create(subscription); err != nil {
@@ -56,55 +150,126 @@ func (s *subscriptionService) Create(userID uint, email string, name string, sta
 	return subscription, nil
 }
 
-func (s *subscriptionService) GetByID(id, userID uint) (*models.Subscription, error) {
-	subscription, err := s.subscriptionRepo.FindByID(id)
+// defaultTimezone is the zone new subscriptions of the user follow unless
+// told otherwise
+func (s *subscriptionService) defaultTimezone(userID uint) string {
+	if preference, err := s.preferenceRepo.FindByUserID(userID); err == nil {
+		return preference.Timezone
+	}
+	return ""
+}
+
+// checkChannelsConfigured makes sure every webhook-based channel a personal
+// subscription overrides to has a URL in the owner's preferences. Shared
+// subscriptions notify each member on their own URLs, so they are not checked.
+func (s *subscriptionService) checkChannelsConfigured(subscription *models.Subscription, ownerID uint) error {
+	if subscription.IsShared() || len(subscription.Channels) == 0 {
+		return nil
+	}
+
+	preference, err := s.preferenceRepo.FindByUserID(ownerID)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, ErrSubscriptionNotFound
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		preference = DefaultPreference(ownerID, 0)
+	}
+	for _, channel := range subscription.Channels {
+		if channel != models.NotificationChannelEmail && preference.WebhookURLFor(channel) == "" {
+			return ErrChannelNotConfigured
+		}
+	}
+	return nil
+}
+
+// GetByID returns a subscription the user may read: one of their own, or one
+// shared with an organization they belong to
+func (s *subscriptionService) GetByID(id, userID uint) (*models.Subscription, error) {
+	return s.getAuthorized(id, userID, models.OrganizationRoleViewer)
+}
+
+// GetAllByUserID returns one page of the subscriptions the user can see that
+// match filter, with the total number of matches. A filter on an organization
+// requires membership of it.
+func (s *subscriptionService) GetAllByUserID(userID uint, filter repositories.SubscriptionFilter) ([]models.Subscription, int64, error) {
+	if filter.OrganizationID != nil {
+		if _, err := s.organizationService.Authorize(*filter.OrganizationID, userID, models.OrganizationRoleViewer); err != nil {
+			return nil, 0, err
 		}
-		return nil, err
 	}
 
-	// Verify ownership
-	if subscription.UserID != userID {
-		return nil, ErrUnauthorizedAccess
+	if filter.MinPriceMinor != nil && filter.MaxPriceMinor != nil && *filter.MinPriceMinor > *filter.MaxPriceMinor {
+		return nil, 0, ErrInvalidFilter
+	}
+	if filter.ExpiresAfter != nil && filter.ExpiresBefore != nil && filter.ExpiresBefore.Before(*filter.ExpiresAfter) {
+		return nil, 0, ErrInvalidFilter
 	}
 
-	return subscription, nil
+	filter.Search = strings.TrimSpace(filter.Search)
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	filter.Currency = utils.NormalizeCurrency(filter.Currency)
+	filter.Now = s.clock.Now()
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+
+	return s.subscriptionRepo.FindPage(userID, filter)
 }
 
-func (s *subscriptionService) GetAllByUserID(userID uint) ([]models.Subscription, error) {
-	return s.subscriptionRepo.FindByUserID(userID)
+// findVisible returns every subscription the user can see, or only those of
+// organizationID when given
+func (s *subscriptionService) findVisible(userID uint, organizationID *uint) ([]models.Subscription, error) {
+	if organizationID == nil {
+		return s.subscriptionRepo.FindByUserID(userID)
+	}
+
+	if _, err := s.organizationService.Authorize(*organizationID, userID, models.OrganizationRoleViewer); err != nil {
+		return nil, err
+	}
+	return s.subscriptionRepo.FindByOrganizationID(*organizationID)
 }
 
-func (s *subscriptionService) Update(id, userID uint, name string, startDate time.Time, durationDays int, notificationEnabled bool) (*models.Subscription, error) {
-	// Get existing subscription and verify ownership
-	subscription, err := s.GetByID(id, userID)
+func (s *subscriptionService) Update(id, userID uint, input SubscriptionInput) (*models.Subscription, error) {
+	// Get existing subscription and verify the user may edit it
+	subscription, err := s.getAuthorized(id, userID, models.OrganizationRoleMember)
 	if err != nil {
 		return nil, err
 	}
 
-	// Validate input
-	if name == "" || durationDays <= 0 {
-		return nil, ErrInvalidSubscriptionData
+	if err := applySubscriptionInput(subscription, input); err != nil {
+		return nil, err
 	}
+	if input.Channels != nil {
+		if err := s.checkChannelsConfigured(subscription, subscription.UserID); err != nil {
+			return nil, err
+		}
+	}
+	subscription.NotificationEnabled = input.NotificationEnabled
 
-	// Update fields
-	subscription.Name = name
-	subscription.StartDate = startDate
-	subscription.DurationDays = durationDays
-	subscription.NotificationEnabled = notificationEnabled
+	reminderDays, err := normalizeReminderDays(input.ReminderDays)
+	if err != nil {
+		return nil, err
+	}
 
 	if err := s.subscriptionRepo.Update(subscription); err != nil {
 		return nil, err
 	}
 
+	if input.ReminderDays != nil {
+		if err := s.reminderRepo.ReplaceForSubscription(subscription.ID, reminderDays); err != nil {
+			return nil, err
+		}
+		if subscription.Reminders, err = s.reminderRepo.FindBySubscriptionID(subscription.ID); err != nil {
+			return nil, err
+		}
+	}
+
 	return subscription, nil
 }
 
 func (s *subscriptionService) Delete(id, userID uint) error {
-	// Verify ownership
-	_, err := s.GetByID(id, userID)
+	// Verify the user may edit the subscription
+	_, err := s.getAuthorized(id, userID, models.OrganizationRoleMember)
 	if err != nil {
 		return err
 	}
@@ -113,8 +278,8 @@ func (s *subscriptionService) Delete(id, userID uint) error {
 }
 
 func (s *subscriptionService) ToggleNotification(id, userID uint, enabled bool) (*models.Subscription, error) {
-	// Get existing subscription and verify ownership
-	subscription, err := s.GetByID(id, userID)
+	// Get existing subscription and verify the user may edit it
+	subscription, err := s.getAuthorized(id, userID, models.OrganizationRoleMember)
 	if err != nil {
 		return nil, err
 	}
@@ -127,3 +292,269 @@ func (s *subscriptionService) ToggleNotification(id, userID uint, enabled bool)
 
 	return subscription, nil
 }
+
+func (s *subscriptionService) GetRenewals(id, userID uint) ([]models.SubscriptionRenewal, error) {
+	// Verify ownership
+	if _, err := s.GetByID(id, userID); err != nil {
+		return nil, err
+	}
+
+	return s.renewalRepo.FindBySubscriptionID(id)
+}
+
+func (s *subscriptionService) GetReminders(id, userID uint) ([]models.SubscriptionReminder, error) {
+	// Verify ownership
+	if _, err := s.GetByID(id, userID); err != nil {
+		return nil, err
+	}
+
+	return s.reminderRepo.FindBySubscriptionID(id)
+}
+
+func (s *subscriptionService) SetReminders(id, userID uint, daysBefore []int) ([]models.SubscriptionReminder, error) {
+	// Verify the user may edit the subscription
+	if _, err := s.getAuthorized(id, userID, models.OrganizationRoleMember); err != nil {
+		return nil, err
+	}
+
+	reminderDays, err := normalizeReminderDays(daysBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.reminderRepo.ReplaceForSubscription(id, reminderDays); err != nil {
+		return nil, err
+	}
+
+	return s.reminderRepo.FindBySubscriptionID(id)
+}
+
+// GetSpendSummary totals the monthly and yearly cost of the user's active
+// personal subscriptions, or of organizationID's when given, grouped by currency
+func (s *subscriptionService) GetSpendSummary(userID uint, organizationID *uint) ([]CurrencySpend, error) {
+	subscriptions, err := s.findVisible(userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	yearlyByCurrency := make(map[string]float64)
+	countByCurrency := make(map[string]int)
+	var currencies []string
+
+	for _, subscription := range subscriptions {
+		// Shared costs are summarised per organization
+		if organizationID == nil && subscription.IsShared() {
+			continue
+		}
+		if subscription.PriceMinor <= 0 || subscription.Currency == "" {
+			continue
+		}
+		// Lapsed subscriptions no longer cost anything
+		if subscription.IsExpired(now) && !subscription.AutoRenew {
+			continue
+		}
+
+		if _, seen := yearlyByCurrency[subscription.Currency]; !seen {
+			currencies = append(currencies, subscription.Currency)
+		}
+		yearlyByCurrency[subscription.Currency] += float64(subscription.PriceMinor) * subscription.CyclesPerYear()
+		countByCurrency[subscription.Currency]++
+	}
+
+	sort.Strings(currencies)
+
+	summary := make([]CurrencySpend, 0, len(currencies))
+	for _, currency := range currencies {
+		yearly := yearlyByCurrency[currency]
+		monthlyMinor := int64(math.Round(yearly / 12))
+		yearlyMinor := int64(math.Round(yearly))
+
+		summary = append(summary, CurrencySpend{
+			Currency:          currency,
+			MonthlyMinor:      monthlyMinor,
+			YearlyMinor:       yearlyMinor,
+			Monthly:           utils.FormatMoney(monthlyMinor, currency),
+			Yearly:            utils.FormatMoney(yearlyMinor, currency),
+			SubscriptionCount: countByCurrency[currency],
+		})
+	}
+
+	return summary, nil
+}
+
+// getAuthorized loads a subscription and checks the user may access it: a
+// personal subscription only by its owner, a shared one by members of its
+// organization holding at least role
+func (s *subscriptionService) getAuthorized(id, userID uint, role string) (*models.Subscription, error) {
+	subscription, err := s.subscriptionRepo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSubscriptionNotFound
+		}
+		return nil, err
+	}
+
+	if !subscription.IsShared() {
+		if subscription.UserID != userID {
+			return nil, ErrUnauthorizedAccess
+		}
+		return subscription, nil
+	}
+
+	if _, err := s.organizationService.Authorize(*subscription.OrganizationID, userID, role); err != nil {
+		if err == ErrOrganizationNotFound || err == ErrInsufficientOrganizationRole {
+			return nil, ErrUnauthorizedAccess
+		}
+		return nil, err
+	}
+
+	return subscription, nil
+}
+
+// newSubscription validates input and builds an unsaved subscription for the
+// user, in timezone unless input names one
+func newSubscription(userID uint, email, timezone string, input SubscriptionInput) (*models.Subscription, error) {
+	subscription := &models.Subscription{
+		UserID:              userID,
+		Email:               email,
+		OrganizationID:      input.OrganizationID,
+		Timezone:            timezone,
+		NotificationEnabled: true,
+	}
+
+	if err := applySubscriptionInput(subscription, input); err != nil {
+		return nil, err
+	}
+
+	reminderDays, err := normalizeReminderDays(input.ReminderDays)
+	if err != nil {
+		return nil, err
+	}
+	for _, days := range reminderDays {
+		subscription.Reminders = append(subscription.Reminders, models.SubscriptionReminder{DaysBefore: days})
+	}
+
+	return subscription, nil
+}
+
+// applySubscriptionInput validates input and copies it onto the subscription
+func applySubscriptionInput(subscription *models.Subscription, input SubscriptionInput) error {
+	if input.Name == "" || utils.HasControlChars(input.Name) {
+		return ErrInvalidSubscriptionData
+	}
+
+	if input.Timezone != "" {
+		if _, err := time.LoadLocation(input.Timezone); err != nil {
+			return ErrInvalidSubscriptionZone
+		}
+		subscription.Timezone = input.Timezone
+	}
+
+	if input.NotifyRoles != nil {
+		for _, role := range input.NotifyRoles {
+			if !subscription.IsShared() || !models.IsValidOrganizationRole(role) {
+				return ErrInvalidNotifyRoles
+			}
+		}
+		subscription.NotifyRoles = input.NotifyRoles
+	}
+
+	if input.Tags != nil {
+		tags, err := normalizeTags(input.Tags)
+		if err != nil {
+			return err
+		}
+		subscription.Tags = tags
+	}
+
+	if input.Channels != nil {
+		for _, channel := range input.Channels {
+			if !isSupportedChannel(channel) {
+				return ErrInvalidChannel
+			}
+		}
+		subscription.Channels = input.Channels
+	}
+
+	subscription.Name = input.Name
+	subscription.StartDate = input.StartDate.UTC()
+	subscription.AutoRenew = input.AutoRenew
+
+	currency := utils.NormalizeCurrency(input.Currency)
+	if input.PriceMinor < 0 || (currency != "" && !utils.IsValidCurrency(currency)) ||
+		(input.PriceMinor > 0 && currency == "") {
+		return ErrInvalidPrice
+	}
+	subscription.PriceMinor = input.PriceMinor
+	subscription.Currency = currency
+
+	if input.BillingCycle == "" {
+		// Legacy fixed-length subscription
+		if input.DurationDays <= 0 {
+			return ErrInvalidSubscriptionData
+		}
+		subscription.DurationDays = input.DurationDays
+		subscription.BillingCycle = ""
+		subscription.BillingInterval = 0
+		subscription.BillingUnit = ""
+		subscription.BillingAnchorDay = 0
+		return nil
+	}
+
+	interval, unit, err := models.ResolveBillingCycle(input.BillingCycle, input.BillingInterval, input.BillingUnit)
+	if err != nil {
+		return ErrInvalidBillingCycle
+	}
+
+	subscription.BillingCycle = input.BillingCycle
+	subscription.BillingInterval = interval
+	subscription.BillingUnit = unit
+	subscription.BillingAnchorDay = input.StartDate.In(subscription.Location()).Day()
+	return nil
+}
+
+// normalizeReminderDays validates reminder offsets and removes duplicates
+func normalizeReminderDays(daysBefore []int) ([]int, error) {
+	seen := make(map[int]bool, len(daysBefore))
+	normalized := make([]int, 0, len(daysBefore))
+
+	for _, days := range daysBefore {
+		if days < 0 || days > maxReminderDaysBefore {
+			return nil, ErrInvalidReminders
+		}
+		if seen[days] {
+			continue
+		}
+		seen[days] = true
+		normalized = append(normalized, days)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(normalized)))
+	return normalized, nil
+}
+
+// normalizeTags lower-cases and trims tags, dropping duplicates
+func normalizeTags(tags []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || len(tag) > maxTagLength {
+			return nil, ErrInvalidTags
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	if len(normalized) > maxTagsPerSubscription {
+		return nil, ErrInvalidTags
+	}
+
+	sort.Strings(normalized)
+	return normalized, nil
+}
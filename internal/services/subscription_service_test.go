@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"renew-guard/internal/models"
+	"renew-guard/pkg/clock"
+)
+
+func (r *memorySubscriptionRepository) Create(subscription *models.Subscription) error {
+	subscription.ID = uint(len(r.subscriptions) + 1)
+	r.subscriptions = append(r.subscriptions, *subscription)
+	return nil
+}
+
+func (r *memorySubscriptionRepository) FindByUserID(userID uint) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.UserID == userID {
+			found = append(found, subscription)
+		}
+	}
+	return found, nil
+}
+
+func (r *memorySubscriptionRepository) FindByOrganizationID(organizationID uint) ([]models.Subscription, error) {
+	var found []models.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.OrganizationID != nil && *subscription.OrganizationID == organizationID {
+			found = append(found, subscription)
+		}
+	}
+	return found, nil
+}
+
+// memberOrganizationService lets every user act as a member of any organization
+type memberOrganizationService struct {
+	OrganizationService
+}
+
+func (memberOrganizationService) Authorize(id, userID uint, role string) (*models.OrganizationMember, error) {
+	return &models.OrganizationMember{OrganizationID: id, UserID: userID, Role: models.OrganizationRoleMember}, nil
+}
+
+func TestChannelOverridesNeedOwnerWebhooks(t *testing.T) {
+	preference := *DefaultPreference(1, 3)
+	preference.SlackWebhookURL = "https://93.184.216.34/slack"
+	service := NewSubscriptionService(
+		&memorySubscriptionRepository{},
+		nil,
+		nil,
+		nil,
+		&memoryPreferenceRepository{preferences: []models.UserPreference{preference}},
+		memberOrganizationService{},
+		clock.NewFake(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)),
+	)
+	organizationID := uint(10)
+
+	tests := []struct {
+		name           string
+		userID         uint
+		channels       []string
+		organizationID *uint
+		want           error
+	}{
+		{name: "configured webhook", userID: 1, channels: []string{"email", "slack"}},
+		{name: "unconfigured webhook", userID: 1, channels: []string{"slack", "discord"}, want: ErrChannelNotConfigured},
+		{name: "no saved preferences", userID: 2, channels: []string{"webhook"}, want: ErrChannelNotConfigured},
+		{name: "email only without preferences", userID: 2, channels: []string{"email"}},
+		// Shared subscriptions post to each member's own webhooks
+		{name: "shared", userID: 2, channels: []string{"discord"}, organizationID: &organizationID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := SubscriptionInput{
+				Name:           "Hosting",
+				StartDate:      time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+				DurationDays:   30,
+				Channels:       tt.channels,
+				OrganizationID: tt.organizationID,
+			}
+			if _, err := service.Create(tt.userID, "owner@example.com", input); !errors.Is(err, tt.want) {
+				t.Errorf("Create error = %v, want %v", err, tt.want)
+			}
+
+			report, err := service.Import(tt.userID, "owner@example.com", []ImportRow{{Row: 2, Input: input}}, ImportOptions{OrganizationID: tt.organizationID, DryRun: true, AllowDuplicates: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wantInvalid := tt.want != nil; (report.Invalid == 1) != wantInvalid {
+				t.Errorf("import report %+v, want the row invalid: %v", report.Rows[0], wantInvalid)
+			}
+		})
+	}
+}
+
+func TestSubscriptionNamesRejectControlCharacters(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want error
+	}{
+		{"Hosting", nil},
+		{"", ErrInvalidSubscriptionData},
+		{"Hosting\r\nBcc: everyone@example.com", ErrInvalidSubscriptionData},
+		{"Hosting\x00", ErrInvalidSubscriptionData},
+	} {
+		input := SubscriptionInput{
+			Name:         tt.name,
+			StartDate:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+			DurationDays: 30,
+		}
+		if err := applySubscriptionInput(&models.Subscription{}, input); !errors.Is(err, tt.want) {
+			t.Errorf("name %q: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"renew-guard/internal/config"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/ratelimit"
+	"renew-guard/pkg/totp"
+	"renew-guard/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled    = errors.New("two-factor enrollment has not been started")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTooManyTwoFactorCodes   = errors.New("too many two-factor attempts, please try again later")
+)
+
+const (
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+	// totpSkew accepts codes from one step either side to absorb clock drift
+	totpSkew = 1
+	// twoFactorAttemptLimit codes may be tried per user per twoFactorAttemptWindow
+	twoFactorAttemptLimit  = 5
+	twoFactorAttemptWindow = 15 * time.Minute
+)
+
+// TOTPEnrollment is what an authenticator app needs to add the account
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// TwoFactorStatus summarises a user's two-factor setup
+type TwoFactorStatus struct {
+	Enabled                bool
+	EnabledAt              *time.Time
+	RecoveryCodesRemaining int64
+}
+
+// TwoFactorService manages TOTP enrollment and checks second-factor codes
+type TwoFactorService interface {
+	Status(userID uint) (*TwoFactorStatus, error)
+	BeginEnrollment(userID uint) (*TOTPEnrollment, error)
+	ConfirmEnrollment(userID uint, code string) ([]string, error)
+	Disable(userID uint, password, code string) error
+	RegenerateRecoveryCodes(userID uint, code string) ([]string, error)
+	VerifyCode(user *models.User, code string) error
+}
+
+type twoFactorService struct {
+	userRepo         repositories.UserRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	config           *config.AuthConfig
+	attemptLimiter   *ratelimit.Limiter // Code attempts per user
+	clock            clock.Clock
+}
+
+func NewTwoFactorService(
+	userRepo repositories.UserRepository,
+	recoveryCodeRepo repositories.RecoveryCodeRepository,
+	cfg *config.AuthConfig,
+	clk clock.Clock,
+) TwoFactorService {
+	return &twoFactorService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		config:           cfg,
+		attemptLimiter:   ratelimit.New(twoFactorAttemptLimit, twoFactorAttemptWindow, clk),
+		clock:            clk,
+	}
+}
+
+func (s *twoFactorService) Status(userID uint) (*TwoFactorStatus, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{
+		Enabled:   user.TwoFactorEnabled(),
+		EnabledAt: user.TOTPEnabledAt,
+	}
+	if status.Enabled {
+		status.RecoveryCodesRemaining, err = s.recoveryCodeRepo.CountUnused(userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// BeginEnrollment generates a new secret for the user to add to an
+// authenticator app. It only takes effect once confirmed with a code.
+func (s *twoFactorService) BeginEnrollment(userID uint) (*TOTPEnrollment, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.SetTOTPSecret(userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.config.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmEnrollment enables two-factor login once the user proves their app
+// produces valid codes, and returns a fresh set of recovery codes
+func (s *twoFactorService) ConfirmEnrollment(userID uint, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	if allowed, _ := s.attemptLimiter.Allow(attemptKey(userID)); !allowed {
+		return nil, ErrTooManyTwoFactorCodes
+	}
+
+	now := s.clock.Now()
+	step, ok := totp.Validate(user.TOTPSecret, code, now, totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, err := s.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.EnableTOTP(userID, step, now); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns two-factor login off. Both the password and a current code
+// (or recovery code) are required.
+func (s *twoFactorService) Disable(userID uint, password, code string) error {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.CheckPassword(password) {
+		return ErrInvalidPassword
+	}
+	if err := s.VerifyCode(user, code); err != nil {
+		return err
+	}
+
+	if err := s.recoveryCodeRepo.DeleteForUser(userID); err != nil {
+		return err
+	}
+	return s.userRepo.DisableTOTP(userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes, invalidating the old ones
+func (s *twoFactorService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := s.findUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.VerifyCode(user, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(userID)
+}
+
+// VerifyCode accepts either a current TOTP code or an unused recovery code.
+// Each TOTP step and each recovery code can only be used once.
+func (s *twoFactorService) VerifyCode(user *models.User, code string) error {
+	if !user.TwoFactorEnabled() {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if allowed, _ := s.attemptLimiter.Allow(attemptKey(user.ID)); !allowed {
+		return ErrTooManyTwoFactorCodes
+	}
+
+	normalized := normalizeCode(code)
+	if len(normalized) != totp.Digits || strings.Trim(normalized, "0123456789") != "" {
+		return s.consumeRecoveryCode(user.ID, normalized)
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, normalized, s.clock.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+	if err := s.userRepo.AcceptTOTPStep(user.ID, step); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *twoFactorService) consumeRecoveryCode(userID uint, normalized string) error {
+	if normalized == "" {
+		return ErrInvalidTwoFactorCode
+	}
+
+	err := s.recoveryCodeRepo.Consume(userID, utils.HashToken(normalized), s.clock.Now())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidTwoFactorCode
+		}
+		return err
+	}
+	return nil
+}
+
+// replaceRecoveryCodes stores a new set of hashed recovery codes and returns
+// them formatted for display
+func (s *twoFactorService) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, formatRecoveryCode(raw))
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: utils.HashToken(raw),
+		})
+	}
+
+	if err := s.recoveryCodeRepo.ReplaceForUser(userID, records); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *twoFactorService) findUser(userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func attemptKey(userID uint) string {
+	return strconv.FormatUint(uint64(userID), 10)
+}
+
+// generateRecoveryCode returns a random upper-case base32 code
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// normalizeCode strips the separators users tend to type and upper-cases
+// recovery codes so they compare equal to the stored form
+func normalizeCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// formatRecoveryCode groups a code into blocks of four for readability
+func formatRecoveryCode(code string) string {
+	var b strings.Builder
+	for i, r := range code {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"renew-guard/internal/config"
+	"renew-guard/internal/models"
+	"renew-guard/internal/repositories"
+	"renew-guard/pkg/clock"
+	"renew-guard/pkg/totp"
+
+	"gorm.io/gorm"
+)
+
+// rfcTOTPSecret is the RFC 6238 Appendix B seed in base32
+const rfcTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// stepUserRepository keeps the last accepted TOTP step in memory, with the
+// same rule as the database: a step is only accepted once and never after a
+// later one
+type stepUserRepository struct {
+	repositories.UserRepository
+	lastStep int64
+}
+
+func (r *stepUserRepository) AcceptTOTPStep(id uint, step int64) error {
+	if step <= r.lastStep {
+		return gorm.ErrRecordNotFound
+	}
+	r.lastStep = step
+	return nil
+}
+
+func newTOTPTestService(clk clock.Clock) (TwoFactorService, *models.User, *stepUserRepository) {
+	enabledAt := clk.Now().Add(-24 * time.Hour)
+	user := &models.User{ID: 1, TOTPSecret: rfcTOTPSecret, TOTPEnabledAt: &enabledAt}
+	repo := &stepUserRepository{}
+	return NewTwoFactorService(repo, nil, &config.AuthConfig{}, clk), user, repo
+}
+
+func totpCodeAt(t *testing.T, at time.Time) string {
+	t.Helper()
+	code, err := totp.Code(rfcTOTPSecret, totp.Step(at))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifyCodeRejectsReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	clk := clock.NewFake(now)
+	service, user, _ := newTOTPTestService(clk)
+
+	code := totpCodeAt(t, now)
+	if err := service.VerifyCode(user, code); err != nil {
+		t.Fatalf("first use of a valid code: %v", err)
+	}
+	if err := service.VerifyCode(user, code); err != ErrInvalidTwoFactorCode {
+		t.Errorf("replayed code: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+
+	// The code is still within the skew window a step later, but its step
+	// has been used
+	clk.Advance(totp.Period)
+	if err := service.VerifyCode(user, code); err != ErrInvalidTwoFactorCode {
+		t.Errorf("replayed code one step later: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+	if err := service.VerifyCode(user, totpCodeAt(t, clk.Now())); err != nil {
+		t.Errorf("code of the next step: %v", err)
+	}
+}
+
+func TestVerifyCodeRejectsEarlierStepAfterLaterOne(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	clk := clock.NewFake(now)
+	service, user, _ := newTOTPTestService(clk)
+
+	// A device running a step fast is accepted, after which the current
+	// step's code is older than the last accepted one
+	if err := service.VerifyCode(user, totpCodeAt(t, now.Add(totp.Period))); err != nil {
+		t.Fatalf("code one step ahead: %v", err)
+	}
+	if err := service.VerifyCode(user, totpCodeAt(t, now)); err != ErrInvalidTwoFactorCode {
+		t.Errorf("earlier step: got %v, want ErrInvalidTwoFactorCode", err)
+	}
+}
+
+func TestVerifyCodeOutsideSkewWindow(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	service, user, repo := newTOTPTestService(clock.NewFake(now))
+
+	for _, at := range []time.Time{now.Add(-2 * totp.Period), now.Add(2 * totp.Period)} {
+		if err := service.VerifyCode(user, totpCodeAt(t, at)); err != ErrInvalidTwoFactorCode {
+			t.Errorf("code for %s: got %v, want ErrInvalidTwoFactorCode", at.UTC().Format(time.RFC3339), err)
+		}
+	}
+	if repo.lastStep != 0 {
+		t.Errorf("rejected codes recorded step %d", repo.lastStep)
+	}
+}
@@ -0,0 +1,5 @@
+-- Remove billing cycle columns from subscriptions table
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_anchor_day;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_unit;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_interval;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS billing_cycle;
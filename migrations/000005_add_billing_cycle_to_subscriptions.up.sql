@@ -0,0 +1,5 @@
+-- Add calendar-aware billing cycle columns to subscriptions table
+ALTER TABLE subscriptions ADD COLUMN billing_cycle VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE subscriptions ADD COLUMN billing_interval INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN billing_unit VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE subscriptions ADD COLUMN billing_anchor_day INTEGER NOT NULL DEFAULT 0;
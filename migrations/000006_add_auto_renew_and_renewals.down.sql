@@ -0,0 +1,4 @@
+-- Drop subscription_renewals table and auto_renew column
+DROP TABLE IF EXISTS subscription_renewals CASCADE;
+DROP INDEX IF EXISTS idx_subscriptions_auto_renew;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS auto_renew;
@@ -0,0 +1,17 @@
+-- Add auto_renew flag to subscriptions table
+ALTER TABLE subscriptions ADD COLUMN auto_renew BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_auto_renew ON subscriptions(auto_renew, end_date);
+
+-- Create subscription_renewals table
+CREATE TABLE IF NOT EXISTS subscription_renewals (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    previous_start_date TIMESTAMP NOT NULL,
+    previous_end_date TIMESTAMP NOT NULL,
+    new_start_date TIMESTAMP NOT NULL,
+    new_end_date TIMESTAMP NOT NULL,
+    renewed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_renewals_subscription_id ON subscription_renewals(subscription_id);
@@ -0,0 +1,3 @@
+-- Remove price and currency columns from subscriptions table
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS currency;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS price_minor;
@@ -0,0 +1,3 @@
+-- Add price (minor units) and ISO 4217 currency to subscriptions table
+ALTER TABLE subscriptions ADD COLUMN price_minor BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT '';
@@ -0,0 +1,3 @@
+-- Drop subscription_reminders table and reminder offset on notification logs
+ALTER TABLE notification_logs DROP COLUMN IF EXISTS days_before;
+DROP TABLE IF EXISTS subscription_reminders CASCADE;
@@ -0,0 +1,14 @@
+-- Create subscription_reminders table
+CREATE TABLE IF NOT EXISTS subscription_reminders (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    days_before INTEGER NOT NULL,
+    last_sent_for_end_date TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Each offset may only be configured once per subscription
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reminder_subscription_days ON subscription_reminders(subscription_id, days_before);
+
+-- Record which reminder offset fired
+ALTER TABLE notification_logs ADD COLUMN days_before INTEGER;
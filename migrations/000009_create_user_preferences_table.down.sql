@@ -0,0 +1,2 @@
+-- Drop user_preferences table
+DROP TABLE IF EXISTS user_preferences CASCADE;
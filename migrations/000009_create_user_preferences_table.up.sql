@@ -0,0 +1,12 @@
+-- Create user_preferences table
+CREATE TABLE IF NOT EXISTS user_preferences (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    default_days_before INTEGER NOT NULL,
+    channels JSONB NOT NULL DEFAULT '["email"]',
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    locale VARCHAR(10) NOT NULL DEFAULT 'en',
+    notification_mode VARCHAR(20) NOT NULL DEFAULT 'daily',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Remove timezone column from subscriptions table
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS timezone;
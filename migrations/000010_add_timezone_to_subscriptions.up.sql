@@ -0,0 +1,2 @@
+-- Add IANA timezone to subscriptions table (empty follows the owner's preference)
+ALTER TABLE subscriptions ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT '';
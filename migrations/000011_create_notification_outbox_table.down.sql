@@ -0,0 +1,5 @@
+-- Drop notification_outbox table and outbox columns on notification logs
+DROP INDEX IF EXISTS idx_notification_logs_outbox_id;
+ALTER TABLE notification_logs DROP COLUMN IF EXISTS attempt;
+ALTER TABLE notification_logs DROP COLUMN IF EXISTS outbox_id;
+DROP TABLE IF EXISTS notification_outbox CASCADE;
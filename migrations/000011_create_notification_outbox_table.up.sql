@@ -0,0 +1,26 @@
+-- Create notification_outbox table for durable delivery with retries
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id SERIAL PRIMARY KEY,
+    subscription_id INTEGER NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    days_before INTEGER,
+    recipient VARCHAR(255) NOT NULL,
+    subject TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    max_attempts INTEGER NOT NULL,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Index for the delivery worker's due-message scan
+CREATE INDEX IF NOT EXISTS idx_outbox_status_next ON notification_outbox(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_notification_outbox_subscription_id ON notification_outbox(subscription_id);
+
+-- Link delivery attempts in notification_logs back to the outbox
+ALTER TABLE notification_logs ADD COLUMN outbox_id INTEGER;
+ALTER TABLE notification_logs ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
+CREATE INDEX IF NOT EXISTS idx_notification_logs_outbox_id ON notification_logs(outbox_id);
@@ -0,0 +1,10 @@
+-- Remove notification channel columns
+ALTER TABLE notification_logs DROP COLUMN IF EXISTS channel;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS data;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS text_body;
+ALTER TABLE notification_outbox ALTER COLUMN recipient TYPE VARCHAR(255);
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS channel;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS channels;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS discord_webhook_url;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS slack_webhook_url;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS webhook_url;
@@ -0,0 +1,16 @@
+-- Webhook targets for the webhook, Slack and Discord channels
+ALTER TABLE user_preferences ADD COLUMN webhook_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_preferences ADD COLUMN slack_webhook_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE user_preferences ADD COLUMN discord_webhook_url TEXT NOT NULL DEFAULT '';
+
+-- Per-subscription channel override; NULL uses the owner's channels
+ALTER TABLE subscriptions ADD COLUMN channels JSONB;
+
+-- Outbox messages carry their channel and the plain-text/JSON variants
+ALTER TABLE notification_outbox ADD COLUMN channel VARCHAR(20) NOT NULL DEFAULT 'email';
+ALTER TABLE notification_outbox ALTER COLUMN recipient TYPE TEXT;
+ALTER TABLE notification_outbox ADD COLUMN text_body TEXT NOT NULL DEFAULT '';
+ALTER TABLE notification_outbox ADD COLUMN data JSONB;
+
+-- Record which channel each delivery attempt used
+ALTER TABLE notification_logs ADD COLUMN channel VARCHAR(20) NOT NULL DEFAULT 'email';
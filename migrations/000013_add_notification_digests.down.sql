@@ -0,0 +1,7 @@
+-- Remove digest columns
+ALTER TABLE notification_logs DROP COLUMN IF EXISTS digest;
+DELETE FROM notification_outbox WHERE subscription_id IS NULL;
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS subscription_ids;
+ALTER TABLE notification_outbox ALTER COLUMN subscription_id SET NOT NULL;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS last_digest_sent_at;
+ALTER TABLE user_preferences DROP COLUMN IF EXISTS digest_frequency;
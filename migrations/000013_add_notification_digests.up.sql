@@ -0,0 +1,10 @@
+-- Digest frequency and the last time a digest went out
+ALTER TABLE user_preferences ADD COLUMN digest_frequency VARCHAR(10) NOT NULL DEFAULT 'daily';
+ALTER TABLE user_preferences ADD COLUMN last_digest_sent_at TIMESTAMP;
+
+-- Digests cover several subscriptions, so the single subscription becomes optional
+ALTER TABLE notification_outbox ALTER COLUMN subscription_id DROP NOT NULL;
+ALTER TABLE notification_outbox ADD COLUMN subscription_ids JSONB;
+
+-- Flag log entries produced by a digest
+ALTER TABLE notification_logs ADD COLUMN digest BOOLEAN NOT NULL DEFAULT false;
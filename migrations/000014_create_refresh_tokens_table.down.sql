@@ -0,0 +1,3 @@
+-- Drop refresh_tokens table and token version
+DROP TABLE IF EXISTS refresh_tokens CASCADE;
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
@@ -0,0 +1,2 @@
+-- Remove email verification column
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
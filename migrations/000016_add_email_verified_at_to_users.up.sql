@@ -0,0 +1,5 @@
+-- Track when the user's current email address was confirmed
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+
+-- Existing accounts predate verification and keep receiving reminders
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
@@ -0,0 +1,6 @@
+-- Remove TOTP two-factor authentication
+DROP TABLE IF EXISTS recovery_codes CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
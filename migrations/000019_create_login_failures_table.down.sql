@@ -0,0 +1,2 @@
+-- Drop login_failures table
+DROP TABLE IF EXISTS login_failures CASCADE;
@@ -0,0 +1,12 @@
+-- Track failed logins per email address for progressive delays and temporary
+-- lockout. Addresses without an account are tracked too, so a lockout does
+-- not reveal which addresses are registered.
+CREATE TABLE IF NOT EXISTS login_failures (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    failed_logins INTEGER NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_failures_email ON login_failures(email);
@@ -0,0 +1,9 @@
+-- Remove organizations and shared subscriptions
+DELETE FROM subscriptions WHERE organization_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_subscriptions_organization_id;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS notify_roles;
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS organization_id;
+
+DROP TABLE IF EXISTS organization_members CASCADE;
+DROP TABLE IF EXISTS organizations CASCADE;
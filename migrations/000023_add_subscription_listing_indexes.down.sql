@@ -0,0 +1,8 @@
+-- Remove subscription listing indexes and tags
+DROP INDEX IF EXISTS idx_subscriptions_name_trgm;
+DROP INDEX IF EXISTS idx_subscriptions_user_price;
+DROP INDEX IF EXISTS idx_subscriptions_organization_end_date;
+DROP INDEX IF EXISTS idx_subscriptions_user_end_date;
+DROP INDEX IF EXISTS idx_subscriptions_tags;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tags;
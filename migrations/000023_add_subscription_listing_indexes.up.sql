@@ -0,0 +1,13 @@
+-- Tags let users label subscriptions and filter the listing by them
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS tags JSONB;
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tags ON subscriptions USING GIN (tags jsonb_path_ops);
+
+-- Listings are scoped to an owner or organization and ordered by expiry by default
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_end_date ON subscriptions(user_id, end_date);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_organization_end_date ON subscriptions(organization_id, end_date);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_price ON subscriptions(user_id, price_minor);
+
+-- Trigram index for case-insensitive name search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_subscriptions_name_trgm ON subscriptions USING GIN (name gin_trgm_ops);
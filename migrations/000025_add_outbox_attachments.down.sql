@@ -0,0 +1,2 @@
+-- Remove outbox attachments
+ALTER TABLE notification_outbox DROP COLUMN IF EXISTS attachments;
@@ -0,0 +1,2 @@
+-- Email notifications may carry attachments such as a calendar invite
+ALTER TABLE notification_outbox ADD COLUMN IF NOT EXISTS attachments JSONB;
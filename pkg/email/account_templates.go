@@ -0,0 +1,223 @@
+package email
+
+import (
+	"fmt"
+	"html"
+)
+
+// accountEmailStyle is shared by the transactional account emails
+const accountEmailStyle = `
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
+            color: white;
+            padding: 30px;
+            border-radius: 10px 10px 0 0;
+            text-align: center;
+        }
+        .content {
+            background: #f9f9f9;
+            padding: 30px;
+            border-radius: 0 0 10px 10px;
+        }
+        .button {
+            display: inline-block;
+            background: #667eea;
+            color: white !important;
+            padding: 12px 24px;
+            border-radius: 5px;
+            text-decoration: none;
+            font-weight: bold;
+        }
+        .link {
+            word-break: break-all;
+            color: #667eea;
+        }
+        .footer {
+            text-align: center;
+            margin-top: 30px;
+            color: #666;
+            font-size: 12px;
+        }`
+
+// GetPasswordResetTemplate generates HTML email template with a one-time password reset link
+func GetPasswordResetTemplate(resetURL string, validMinutes int) string {
+	link := html.EscapeString(resetURL)
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>%s
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>🔑 Reset Your Password</h1>
+    </div>
+    <div class="content">
+        <p>We received a request to reset the password of your RenewGuard account.</p>
+        <p style="text-align: center;">
+            <a class="button" href="%s">Choose a new password</a>
+        </p>
+        <p>Or paste this link into your browser:</p>
+        <p class="link">%s</p>
+        <p>The link can be used once and expires in %d minutes.</p>
+        <p>If you didn't ask to reset your password, you can safely ignore this email. Your password will not change.</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated security email from RenewGuard</p>
+    </div>
+</body>
+</html>
+`, accountEmailStyle, link, link, validMinutes)
+}
+
+// GetPasswordResetSubject generates subject for password reset email
+func GetPasswordResetSubject() string {
+	return "🔑 Reset your RenewGuard password"
+}
+
+// GetEmailVerificationTemplate generates HTML email template asking the user to confirm their address
+func GetEmailVerificationTemplate(verifyURL string, validHours int) string {
+	link := html.EscapeString(verifyURL)
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>%s
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>📬 Confirm Your Email</h1>
+    </div>
+    <div class="content">
+        <p>Please confirm that this is your email address so RenewGuard can send you renewal reminders.</p>
+        <p style="text-align: center;">
+            <a class="button" href="%s">Verify email address</a>
+        </p>
+        <p>Or paste this link into your browser:</p>
+        <p class="link">%s</p>
+        <p>The link expires in %d hours. Until the address is verified, no reminders are emailed to it.</p>
+        <p>If you didn't create a RenewGuard account, you can safely ignore this email.</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email from RenewGuard</p>
+    </div>
+</body>
+</html>
+`, accountEmailStyle, link, link, validHours)
+}
+
+// GetEmailVerificationSubject generates subject for email verification
+func GetEmailVerificationSubject() string {
+	return "📬 Confirm your email for RenewGuard"
+}
+
+// GetEmailChangedTemplate generates HTML email template warning the old address about an email change
+func GetEmailChangedTemplate(newEmail string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>%s
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>✉️ Your Email Was Changed</h1>
+    </div>
+    <div class="content">
+        <p>The email address of your RenewGuard account was changed to <strong>%s</strong>.</p>
+        <p>Renewal reminders will be sent to the new address once it has been verified.</p>
+        <p>If you didn't make this change, reset your password immediately and contact support.</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated security email from RenewGuard</p>
+    </div>
+</body>
+</html>
+`, accountEmailStyle, html.EscapeString(newEmail))
+}
+
+// GetEmailChangedSubject generates subject for the email change notice
+func GetEmailChangedSubject() string {
+	return "✉️ Your RenewGuard email address was changed"
+}
+
+// GetAccountLockedTemplate generates HTML email template telling the owner that repeated failed logins locked their account
+func GetAccountLockedTemplate(failedAttempts, lockedMinutes int) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>%s
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>🔒 Sign-in Temporarily Locked</h1>
+    </div>
+    <div class="content">
+        <p>There were %d failed attempts to sign in to your RenewGuard account, so we have paused sign-ins for %d minutes.</p>
+        <p>Your password has not been changed and no one has signed in. You can try again once the lock expires.</p>
+        <p>If these attempts weren't you, someone may be guessing your password. Consider resetting it with "Forgot password" and turning on two-factor authentication.</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated security email from RenewGuard</p>
+    </div>
+</body>
+</html>
+`, accountEmailStyle, failedAttempts, lockedMinutes)
+}
+
+// GetAccountLockedSubject generates subject for the account lockout notice
+func GetAccountLockedSubject() string {
+	return "🔒 Sign-in to your RenewGuard account was locked"
+}
+
+// GetOrganizationInvitationTemplate generates HTML email template inviting an address to join an organization
+func GetOrganizationInvitationTemplate(organizationName, inviterEmail, role, appURL string, validDays int) string {
+	link := html.EscapeString(appURL)
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>%s
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>🤝 You're Invited</h1>
+    </div>
+    <div class="content">
+        <p><strong>%s</strong> invited you to join <strong>%s</strong> on RenewGuard as a %s.</p>
+        <p>To accept, sign in or create an account with this email address, verify it, and accept the invitation from your organizations.</p>
+        <p style="text-align: center;">
+            <a class="button" href="%s">Open RenewGuard</a>
+        </p>
+        <p>The invitation expires in %d days. You will not be added to the organization unless you accept it.</p>
+        <p>If you weren't expecting this invitation, you can safely ignore this email.</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated email from RenewGuard</p>
+    </div>
+</body>
+</html>
+`, accountEmailStyle, html.EscapeString(inviterEmail), html.EscapeString(organizationName), html.EscapeString(role), link, validDays)
+}
+
+// GetOrganizationInvitationSubject generates subject for an organization invitation
+func GetOrganizationInvitationSubject(organizationName string) string {
+	return fmt.Sprintf("🤝 You're invited to join %s on RenewGuard", organizationName)
+}
@@ -4,6 +4,14 @@ package email
 type EmailService interface {
 	Send(to string, subject string, body string) error
 	SendHTML(to string, subject string, htmlBody string) error
+	SendHTMLWithAttachments(to string, subject string, htmlBody string, attachments []Attachment) error
+}
+
+// Attachment is a file sent along with an email
+type Attachment struct {
+	Filename    string
+	ContentType string // Full media type, e.g. "text/calendar; charset=utf-8; method=PUBLISH"
+	Data        []byte
 }
 
 // EmailConfig holds configuration for email service
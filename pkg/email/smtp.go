@@ -5,6 +5,8 @@ import (
 	"crypto/tls"
 	"encoding/base64"
 	"fmt"
+	"io"
+	"mime"
 	"net"
 	"net/smtp"
 	"net/textproto"
@@ -35,6 +37,12 @@ func (s *SMTPEmailService) SendHTML(to string, subject string, htmlBody string)
 	return s.sendMultipart(to, subject, plainText, htmlBody)
 }
 
+// SendHTMLWithAttachments sends an HTML email with files attached
+func (s *SMTPEmailService) SendHTMLWithAttachments(to string, subject string, htmlBody string, attachments []Attachment) error {
+	plainText := s.htmlToPlainText(htmlBody)
+	return s.sendMultipart(to, subject, plainText, htmlBody, attachments...)
+}
+
 // htmlToPlainText converts HTML to plain text (basic implementation)
 func (s *SMTPEmailService) htmlToPlainText(html string) string {
 	// Remove HTML tags and decode common entities
@@ -44,7 +52,7 @@ func (s *SMTPEmailService) htmlToPlainText(html string) string {
 	text = strings.ReplaceAll(text, "<br />", "\n")
 	text = strings.ReplaceAll(text, "</p>", "\n\n")
 	text = strings.ReplaceAll(text, "</div>", "\n")
-	
+
 	// Simple tag removal
 	for strings.Contains(text, "<") && strings.Contains(text, ">") {
 		start := strings.Index(text, "<")
@@ -55,7 +63,7 @@ func (s *SMTPEmailService) htmlToPlainText(html string) string {
 			break
 		}
 	}
-	
+
 	// Clean up extra whitespace
 	lines := strings.Split(text, "\n")
 	var cleaned []string
@@ -65,7 +73,7 @@ func (s *SMTPEmailService) htmlToPlainText(html string) string {
 			cleaned = append(cleaned, trimmed)
 		}
 	}
-	
+
 	return strings.Join(cleaned, "\n")
 }
 
@@ -76,34 +84,35 @@ func (s *SMTPEmailService) generateMessageID() string {
 	return fmt.Sprintf("<%s@%s>", base64.URLEncoding.EncodeToString(b), s.config.SMTPHost)
 }
 
-// sendMultipart sends email with both plain text and HTML versions
-func (s *SMTPEmailService) sendMultipart(to string, subject string, plainBody string, htmlBody string) error {
+// sendMultipart sends email with both plain text and HTML versions. With
+// attachments the alternatives are nested in a multipart/mixed message.
+func (s *SMTPEmailService) sendMultipart(to string, subject string, plainBody string, htmlBody string, attachments ...Attachment) error {
 	from := s.config.FromEmail
 	fromName := s.config.FromName
-	
+
 	// Connect with timeout
-	addr := fmt.Sprintf("%s:%s", s.config.SMTPHost, s.config.SMTPPort)
+	addr := net.JoinHostPort(s.config.SMTPHost, s.config.SMTPPort)
 	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
 	if err != nil {
 		return fmt.Errorf("failed to connect to SMTP server: %w", err)
 	}
 	defer conn.Close()
-	
+
 	// Set deadline for the entire operation
 	conn.SetDeadline(time.Now().Add(30 * time.Second))
-	
+
 	// Create SMTP client
 	client, err := smtp.NewClient(conn, s.config.SMTPHost)
 	if err != nil {
 		return fmt.Errorf("failed to create SMTP client: %w", err)
 	}
 	defer client.Quit()
-	
+
 	// Say hello
 	if err := client.Hello("localhost"); err != nil {
 		return fmt.Errorf("failed to send EHLO: %w", err)
 	}
-	
+
 	// Start TLS if available
 	if ok, _ := client.Extension("STARTTLS"); ok {
 		config := &tls.Config{ServerName: s.config.SMTPHost}
@@ -111,12 +120,12 @@ func (s *SMTPEmailService) sendMultipart(to string, subject string, plainBody st
 			return fmt.Errorf("failed to start TLS: %w", err)
 		}
 	}
-	
+
 	// Authenticate
 	if err := client.Auth(s.auth); err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
-	
+
 	// Set sender and recipient
 	if err := client.Mail(from); err != nil {
 		return fmt.Errorf("failed to set sender: %w", err)
@@ -124,53 +133,75 @@ func (s *SMTPEmailService) sendMultipart(to string, subject string, plainBody st
 	if err := client.Rcpt(to); err != nil {
 		return fmt.Errorf("failed to set recipient: %w", err)
 	}
-	
+
 	// Get data writer
 	writer, err := client.Data()
 	if err != nil {
 		return fmt.Errorf("failed to get data writer: %w", err)
 	}
-	
+
 	// Build multipart message
 	boundary := s.generateBoundary()
-	
+	contentType := fmt.Sprintf("multipart/alternative; boundary=\"%s\"", boundary)
+	mixedBoundary := ""
+	if len(attachments) > 0 {
+		mixedBoundary = s.generateBoundary()
+		contentType = fmt.Sprintf("multipart/mixed; boundary=\"%s\"", mixedBoundary)
+	}
+
 	// Write headers
 	headers := textproto.MIMEHeader{}
 	headers.Set("From", fmt.Sprintf("%s <%s>", fromName, from))
 	headers.Set("To", to)
-	headers.Set("Subject", subject)
+	// Subjects carry user-chosen names, so encode them rather than trust them
+	headers.Set("Subject", mime.QEncoding.Encode("utf-8", subject))
 	headers.Set("MIME-Version", "1.0")
-	headers.Set("Content-Type", fmt.Sprintf("multipart/alternative; boundary=\"%s\"", boundary))
+	headers.Set("Content-Type", contentType)
 	headers.Set("Date", time.Now().Format(time.RFC1123Z))
 	headers.Set("Message-ID", s.generateMessageID())
 	headers.Set("X-Mailer", "RenewGuard/1.0")
-	
+
 	// Write headers to message
 	for k, v := range headers {
 		fmt.Fprintf(writer, "%s: %s\r\n", k, v[0])
 	}
 	fmt.Fprintf(writer, "\r\n")
-	
+
+	// Open the mixed part holding the alternatives
+	if mixedBoundary != "" {
+		fmt.Fprintf(writer, "--%s\r\n", mixedBoundary)
+		fmt.Fprintf(writer, "Content-Type: multipart/alternative; boundary=\"%s\"\r\n\r\n", boundary)
+	}
+
 	// Write plain text part
 	fmt.Fprintf(writer, "--%s\r\n", boundary)
 	fmt.Fprintf(writer, "Content-Type: text/plain; charset=UTF-8\r\n")
 	fmt.Fprintf(writer, "Content-Transfer-Encoding: 7bit\r\n\r\n")
 	fmt.Fprintf(writer, "%s\r\n\r\n", plainBody)
-	
+
 	// Write HTML part
 	fmt.Fprintf(writer, "--%s\r\n", boundary)
 	fmt.Fprintf(writer, "Content-Type: text/html; charset=UTF-8\r\n")
 	fmt.Fprintf(writer, "Content-Transfer-Encoding: 7bit\r\n\r\n")
 	fmt.Fprintf(writer, "%s\r\n\r\n", htmlBody)
-	
+
 	// Close boundary
 	fmt.Fprintf(writer, "--%s--\r\n", boundary)
-	
+
+	// Write attachments after the alternatives
+	if mixedBoundary != "" {
+		for _, attachment := range attachments {
+			fmt.Fprintf(writer, "\r\n--%s\r\n", mixedBoundary)
+			writeAttachment(writer, attachment)
+		}
+		fmt.Fprintf(writer, "\r\n--%s--\r\n", mixedBoundary)
+	}
+
 	// Close writer
 	if err := writer.Close(); err != nil {
 		return fmt.Errorf("failed to close writer: %w", err)
 	}
-	
+
 	return nil
 }
 
@@ -180,3 +211,27 @@ func (s *SMTPEmailService) generateBoundary() string {
 	rand.Read(b)
 	return fmt.Sprintf("===============%s==", base64.StdEncoding.EncodeToString(b))
 }
+
+// writeAttachment writes one attachment part, base64 encoded in 76 character
+// lines as RFC 2045 requires
+func writeAttachment(w io.Writer, attachment Attachment) {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if mediaType, params, err := mime.ParseMediaType(contentType); err == nil {
+		params["name"] = attachment.Filename
+		contentType = mime.FormatMediaType(mediaType, params)
+	}
+
+	fmt.Fprintf(w, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(w, "Content-Disposition: %s\r\n", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	fmt.Fprintf(w, "Content-Transfer-Encoding: base64\r\n\r\n")
+
+	encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+	for len(encoded) > 76 {
+		fmt.Fprintf(w, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(w, "%s\r\n", encoded)
+}
@@ -2,11 +2,19 @@ package email
 
 import (
 	"fmt"
+	"html"
+	"strings"
 	"time"
 )
 
-// GetExpirationWarningTemplate generates HTML email template for subscription expiration warning
-func GetExpirationWarningTemplate(subscriptionName string, daysLeft int, endDate time.Time) string {
+// GetExpirationWarningTemplate generates HTML email template for subscription expiration warning.
+// amount is the formatted renewal price; pass an empty string to omit it.
+func GetExpirationWarningTemplate(subscriptionName string, daysLeft int, endDate time.Time, amount string) string {
+	amountRow := ""
+	if amount != "" {
+		amountRow = fmt.Sprintf(`<p><strong>Renewal Amount:</strong> <span class="highlight">%s</span></p>`, amount)
+	}
+
 	htmlBody := fmt.Sprintf(`
 <!DOCTYPE html>
 <html>
@@ -74,6 +82,7 @@ func GetExpirationWarningTemplate(subscriptionName string, daysLeft int, endDate
             <p><strong>Service:</strong> %s</p>
             <p><strong>Days Remaining:</strong> <span class="highlight">%d</span></p>
             <p><strong>Expiration Date:</strong> %s</p>
+            %s
         </div>
         
         <p>Don't forget to renew your subscription to continue enjoying uninterrupted service.</p>
@@ -86,11 +95,21 @@ func GetExpirationWarningTemplate(subscriptionName string, daysLeft int, endDate
     </div>
 </body>
 </html>
-`, subscriptionName, daysLeft, endDate.Format("Monday, January 2, 2006"))
+`, subscriptionName, daysLeft, endDate.Format("Monday, January 2, 2006"), amountRow)
 
 	return htmlBody
 }
 
+// GetExpirationWarningText generates the plain-text warning used by chat channels
+func GetExpirationWarningText(subscriptionName string, daysLeft int, endDate time.Time, amount string) string {
+	text := fmt.Sprintf("%s expires on %s (%d day(s) left).",
+		subscriptionName, endDate.Format("Monday, January 2, 2006"), daysLeft)
+	if amount != "" {
+		text += fmt.Sprintf(" Renewal amount: %s.", amount)
+	}
+	return text
+}
+
 // GetExpirationWarningSubject generates email subject for expiration warning
 func GetExpirationWarningSubject(subscriptionName string, daysLeft int) string {
 	if daysLeft == 0 {
@@ -100,3 +119,143 @@ func GetExpirationWarningSubject(subscriptionName string, daysLeft int) string {
 	}
 	return fmt.Sprintf("⚠️ Your %s subscription expires in %d days", subscriptionName, daysLeft)
 }
+
+// DigestItem is one subscription row in a digest email
+type DigestItem struct {
+	Name     string
+	EndDate  time.Time
+	DaysLeft int
+	Amount   string // Formatted renewal price, empty if unknown
+}
+
+// GetDigestTemplate generates the HTML email listing every subscription due for a reminder
+func GetDigestTemplate(items []DigestItem) string {
+	var rows strings.Builder
+	for _, item := range items {
+		amount := html.EscapeString(item.Amount)
+		if amount == "" {
+			amount = "&mdash;"
+		}
+		fmt.Fprintf(&rows, `
+                <tr>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                    <td>%s</td>
+                </tr>`,
+			html.EscapeString(item.Name), item.EndDate.Format("Mon, Jan 2, 2006"),
+			digestDaysLeft(item.DaysLeft), amount)
+	}
+
+	htmlBody := fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <style>
+        body {
+            font-family: Arial, sans-serif;
+            line-height: 1.6;
+            color: #333;
+            max-width: 600px;
+            margin: 0 auto;
+            padding: 20px;
+        }
+        .header {
+            background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%);
+            color: white;
+            padding: 30px;
+            border-radius: 10px 10px 0 0;
+            text-align: center;
+        }
+        .content {
+            background: #f9f9f9;
+            padding: 30px;
+            border-radius: 0 0 10px 10px;
+        }
+        table {
+            width: 100%%;
+            border-collapse: collapse;
+            background: white;
+            box-shadow: 0 2px 4px rgba(0,0,0,0.1);
+        }
+        th, td {
+            padding: 10px;
+            text-align: left;
+            border-bottom: 1px solid #eee;
+        }
+        th {
+            background: #667eea;
+            color: white;
+        }
+        .footer {
+            text-align: center;
+            margin-top: 30px;
+            color: #666;
+            font-size: 12px;
+        }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>🔔 Your Subscription Digest</h1>
+    </div>
+    <div class="content">
+        <p>%d subscription(s) are expiring soon:</p>
+        <table>
+            <thead>
+                <tr>
+                    <th>Service</th>
+                    <th>Expires</th>
+                    <th>Remaining</th>
+                    <th>Amount</th>
+                </tr>
+            </thead>
+            <tbody>%s
+            </tbody>
+        </table>
+        <p>Renew or cancel them in time to avoid interruptions or unwanted charges.</p>
+    </div>
+    <div class="footer">
+        <p>This is an automated digest from RenewGuard</p>
+        <p>You are receiving digests because of your notification preferences.</p>
+    </div>
+</body>
+</html>
+`, len(items), rows.String())
+
+	return htmlBody
+}
+
+// GetDigestText generates the plain-text digest used by chat channels
+func GetDigestText(items []DigestItem) string {
+	var text strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		fmt.Fprintf(&text, "• %s: %s (%s)", item.Name, item.EndDate.Format("Mon, Jan 2, 2006"), digestDaysLeft(item.DaysLeft))
+		if item.Amount != "" {
+			fmt.Fprintf(&text, ", %s", item.Amount)
+		}
+	}
+	return text.String()
+}
+
+// GetDigestSubject generates email subject for a digest
+func GetDigestSubject(count int) string {
+	if count == 1 {
+		return "⚠️ 1 subscription is expiring soon"
+	}
+	return fmt.Sprintf("⚠️ %d subscriptions are expiring soon", count)
+}
+
+// digestDaysLeft renders a days-remaining count for digest rows
+func digestDaysLeft(daysLeft int) string {
+	switch daysLeft {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	}
+	return fmt.Sprintf("in %d days", daysLeft)
+}
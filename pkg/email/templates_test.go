@@ -0,0 +1,25 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestTemplateAmounts(t *testing.T) {
+	end := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	body := GetDigestTemplate([]DigestItem{
+		{Name: "Netflix", EndDate: end, DaysLeft: 3},
+		{Name: "Gym", EndDate: end, DaysLeft: 3, Amount: "<b>€10.00</b>"},
+	})
+
+	if strings.Contains(body, "&amp;mdash;") {
+		t.Error("placeholder for a missing amount is escaped twice")
+	}
+	if !strings.Contains(body, "<td>&mdash;</td>") {
+		t.Error("missing amount not shown as a dash")
+	}
+	if !strings.Contains(body, "<td>&lt;b&gt;€10.00&lt;/b&gt;</td>") {
+		t.Error("amount not escaped")
+	}
+}
@@ -0,0 +1,155 @@
+// Package ical writes RFC 5545 iCalendar documents holding all-day events
+// with display alarms, which is all renewal feeds and invites need.
+package ical
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineOctets is the longest content line RFC 5545 allows before folding
+	maxLineOctets = 75
+
+	dateFormat     = "20060102"
+	dateTimeFormat = "20060102T150405Z"
+)
+
+// Calendar is a VCALENDAR object
+type Calendar struct {
+	ProdID string  // Identifies the product that wrote the calendar
+	Name   string  // Title shown by clients that subscribe to the calendar; optional
+	Method string  // iTIP method such as PUBLISH; optional
+	Events []Event // Written in order
+}
+
+// Event is an all-day VEVENT
+type Event struct {
+	UID          string
+	Stamp        time.Time // When this copy of the event was generated
+	Date         time.Time // Day of the event; only its year, month and day are used
+	Summary      string
+	Description  string    // Optional
+	LastModified time.Time // Optional
+	Alarms       []Alarm
+}
+
+// Alarm is a display VALARM some whole days ahead of its event
+type Alarm struct {
+	DaysBefore  int
+	Description string
+}
+
+// WriteTo writes the calendar to w with CRLF line endings and long lines folded
+func (c *Calendar) WriteTo(w io.Writer) (int64, error) {
+	lw := &lineWriter{w: w}
+
+	lw.line("BEGIN:VCALENDAR")
+	lw.line("VERSION:2.0")
+	lw.line("PRODID:" + escapeText(c.ProdID))
+	lw.line("CALSCALE:GREGORIAN")
+	if c.Method != "" {
+		lw.line("METHOD:" + c.Method)
+	}
+	if c.Name != "" {
+		lw.line("X-WR-CALNAME:" + escapeText(c.Name))
+	}
+
+	for _, event := range c.Events {
+		event.write(lw)
+	}
+
+	lw.line("END:VCALENDAR")
+	return lw.n, lw.err
+}
+
+// Bytes returns the calendar as written by WriteTo
+func (c *Calendar) Bytes() []byte {
+	var buf bytes.Buffer
+	c.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+func (e *Event) write(lw *lineWriter) {
+	day := time.Date(e.Date.Year(), e.Date.Month(), e.Date.Day(), 0, 0, 0, 0, time.UTC)
+
+	lw.line("BEGIN:VEVENT")
+	lw.line("UID:" + escapeText(e.UID))
+	lw.line("DTSTAMP:" + e.Stamp.UTC().Format(dateTimeFormat))
+	lw.line("DTSTART;VALUE=DATE:" + day.Format(dateFormat))
+	lw.line("DTEND;VALUE=DATE:" + day.AddDate(0, 0, 1).Format(dateFormat))
+	lw.line("SUMMARY:" + escapeText(e.Summary))
+	if e.Description != "" {
+		lw.line("DESCRIPTION:" + escapeText(e.Description))
+	}
+	if !e.LastModified.IsZero() {
+		lw.line("LAST-MODIFIED:" + e.LastModified.UTC().Format(dateTimeFormat))
+	}
+	lw.line("TRANSP:TRANSPARENT")
+
+	for _, alarm := range e.Alarms {
+		lw.line("BEGIN:VALARM")
+		lw.line("ACTION:DISPLAY")
+		lw.line("DESCRIPTION:" + escapeText(alarm.Description))
+		lw.line("TRIGGER:" + trigger(alarm.DaysBefore))
+		lw.line("END:VALARM")
+	}
+
+	lw.line("END:VEVENT")
+}
+
+// trigger is the duration from the start of an event to an alarm daysBefore it
+func trigger(daysBefore int) string {
+	if daysBefore <= 0 {
+		return "PT0S"
+	}
+	return fmt.Sprintf("-P%dD", daysBefore)
+}
+
+// escapeText escapes a TEXT value as RFC 5545 section 3.3.11 requires
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", "",
+	).Replace(s)
+}
+
+// lineWriter writes content lines, folding them at 75 octets without
+// splitting UTF-8 sequences, and remembers the first error
+type lineWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (lw *lineWriter) line(s string) {
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		lw.write(s[:cut] + "\r\n ")
+		s = s[cut:]
+		// Continuation lines lose an octet to the leading space
+		limit = maxLineOctets - 1
+	}
+	lw.write(s + "\r\n")
+}
+
+func (lw *lineWriter) write(s string) {
+	if lw.err != nil {
+		return
+	}
+	n, err := io.WriteString(lw.w, s)
+	lw.n += int64(n)
+	lw.err = err
+}
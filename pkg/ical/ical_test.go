@@ -0,0 +1,139 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// unfold joins folded content lines back together
+func unfold(s string) string {
+	return strings.ReplaceAll(s, "\r\n ", "")
+}
+
+func TestLinesFoldAt75OctetsWithoutSplittingRunes(t *testing.T) {
+	summary := strings.Repeat("Ünïcödé renewal ✓ ", 12)
+	calendar := &Calendar{ProdID: "-//Test//EN", Events: []Event{{
+		UID:     "subscription-1@example.com",
+		Date:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Summary: summary,
+	}}}
+	out := string(calendar.Bytes())
+
+	if !strings.HasSuffix(out, "\r\n") || strings.Contains(strings.ReplaceAll(out, "\r\n", ""), "\n") {
+		t.Fatal("lines are not terminated by CRLF")
+	}
+	for i, line := range strings.Split(strings.TrimSuffix(out, "\r\n"), "\r\n") {
+		if len(line) > maxLineOctets {
+			t.Errorf("line %d is %d octets: %q", i, len(line), line)
+		}
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d splits a UTF-8 sequence: %q", i, line)
+		}
+	}
+	if !strings.Contains(unfold(out), "\r\nSUMMARY:"+summary+"\r\n") {
+		t.Errorf("summary does not unfold to the original:\n%s", out)
+	}
+}
+
+func TestFoldingCountsTheContinuationSpace(t *testing.T) {
+	var b strings.Builder
+	lw := &lineWriter{w: &b}
+	lw.line(strings.Repeat("a", 75+74+1))
+
+	lines := strings.Split(b.String(), "\r\n")
+	if len(lines) != 4 || len(lines[0]) != 75 || lines[1] != " "+strings.Repeat("a", 74) || lines[2] != " a" {
+		t.Errorf("folded into %q", lines)
+	}
+	if lw.n != int64(b.Len()) {
+		t.Errorf("counted %d octets, wrote %d", lw.n, b.Len())
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	tests := map[string]string{
+		"Netflix":                 "Netflix",
+		`C:\backup`:               `C:\\backup`,
+		"Gym; monthly, family":    `Gym\; monthly\, family`,
+		"line one\nline two":      `line one\nline two`,
+		"windows\r\nline":         `windows\nline`,
+		"stray\rreturn":           "strayreturn",
+		`\; already escaped? no,`: `\\\; already escaped? no\,`,
+	}
+	for in, want := range tests {
+		if got := escapeText(in); got != want {
+			t.Errorf("escapeText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAllDayEvent(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	calendar := &Calendar{
+		ProdID: "-//Test//EN",
+		Name:   "Renewals",
+		Method: "PUBLISH",
+		Events: []Event{{
+			UID: "subscription-1@example.com",
+			// Late on New Year's Eve in New York is already January in UTC
+			Date:         time.Date(2024, 12, 31, 22, 0, 0, 0, newYork),
+			Stamp:        time.Date(2024, 3, 1, 9, 30, 0, 0, newYork),
+			Summary:      "Hosting renews",
+			LastModified: time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC),
+		}},
+	}
+	out := unfold(string(calendar.Bytes()))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Test//EN\r\nCALSCALE:GREGORIAN\r\nMETHOD:PUBLISH\r\nX-WR-CALNAME:Renewals\r\n",
+		"DTSTAMP:20240301T143000Z\r\n",
+		"DTSTART;VALUE=DATE:20241231\r\n",
+		"DTEND;VALUE=DATE:20250101\r\n",
+		"LAST-MODIFIED:20240201T080000Z\r\n",
+		"TRANSP:TRANSPARENT\r\nEND:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("calendar lacks %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"DESCRIPTION:", "BEGIN:VALARM"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("calendar has %q without one set:\n%s", unwanted, out)
+		}
+	}
+
+	// Optional calendar properties are left out when unset
+	out = string((&Calendar{ProdID: "-//Test//EN"}).Bytes())
+	if strings.Contains(out, "METHOD:") || strings.Contains(out, "X-WR-CALNAME:") {
+		t.Errorf("empty calendar has optional properties:\n%s", out)
+	}
+}
+
+func TestAlarmTriggers(t *testing.T) {
+	calendar := &Calendar{ProdID: "-//Test//EN", Events: []Event{{
+		UID:     "subscription-1@example.com",
+		Date:    time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		Summary: "Gym expires",
+		Alarms: []Alarm{
+			{DaysBefore: 7, Description: "Gym expires"},
+			{DaysBefore: 0, Description: "Gym expires today"},
+		},
+	}}}
+	out := string(calendar.Bytes())
+
+	want := "BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Gym expires\r\nTRIGGER:-P7D\r\nEND:VALARM\r\n" +
+		"BEGIN:VALARM\r\nACTION:DISPLAY\r\nDESCRIPTION:Gym expires today\r\nTRIGGER:PT0S\r\nEND:VALARM\r\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("alarms written as:\n%s", out)
+	}
+
+	for days, want := range map[int]string{30: "-P30D", 1: "-P1D", 0: "PT0S", -2: "PT0S"} {
+		if got := trigger(days); got != want {
+			t.Errorf("trigger(%d) = %q, want %q", days, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package jwt
 import (
 	"errors"
 	"fmt"
+	"renew-guard/pkg/clock"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
@@ -11,51 +12,98 @@ import (
 var (
 	ErrInvalidToken = errors.New("invalid token")
 	ErrExpiredToken = errors.New("token has expired")
+	ErrNoSigningKey = errors.New("the first JWT key must be a private key or secret")
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID       uint   `json:"user_id"`
+	Email        string `json:"email"`
+	Role         string `json:"role,omitempty"`
+	SessionID    string `json:"sid,omitempty"` // Refresh token family the access token was issued for
+	TokenVersion int    `json:"ver"`           // User's token version at issue time; bumped to revoke all tokens
 	jwt.RegisteredClaims
 }
 
+// JWTUtil signs access tokens with one key and verifies them against any of
+// its keys, so a retired key keeps verifying until its tokens have expired
 type JWTUtil struct {
-	secretKey []byte
-	duration  time.Duration
+	signingKey *Key
+	keys       []*Key
+	keysByID   map[string]*Key
+	methods    []string
+	duration   time.Duration
+	clock      clock.Clock
 }
 
-func NewJWTUtil(secretKey string, durationHours int) *JWTUtil {
-	return &JWTUtil{
-		secretKey: []byte(secretKey),
-		duration:  time.Duration(durationHours) * time.Hour,
+// NewJWTUtil creates a JWTUtil. The first key signs new tokens and must hold
+// private material; the others are only used for verification.
+func NewJWTUtil(keys []*Key, duration time.Duration, clk clock.Clock) (*JWTUtil, error) {
+	if len(keys) == 0 || !keys[0].CanSign() {
+		return nil, ErrNoSigningKey
 	}
+
+	j := &JWTUtil{
+		signingKey: keys[0],
+		keys:       keys,
+		keysByID:   make(map[string]*Key, len(keys)),
+		duration:   duration,
+		clock:      clk,
+	}
+	for _, key := range keys {
+		if _, exists := j.keysByID[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate JWT key %q", key.ID)
+		}
+		j.keysByID[key.ID] = key
+		j.methods = append(j.methods, key.Algorithm())
+	}
+
+	return j, nil
+}
+
+// Duration returns how long issued tokens stay valid
+func (j *JWTUtil) Duration() time.Duration {
+	return j.duration
 }
 
-// GenerateToken creates a new JWT token for a user
-func (j *JWTUtil) GenerateToken(userID uint, email string) (string, error) {
+// GenerateToken creates a new access token for a user's session
+func (j *JWTUtil) GenerateToken(userID uint, email, role, sessionID string, tokenVersion int) (string, error) {
+	now := j.clock.Now()
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:       userID,
+		Email:        email,
+		Role:         role,
+		SessionID:    sessionID,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.duration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.duration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.secretKey)
+	token := jwt.NewWithClaims(j.signingKey.method, claims)
+	if j.signingKey.ID != "" {
+		token.Header["kid"] = j.signingKey.ID
+	}
+	return token.SignedString(j.signingKey.signKey)
 }
 
 // ValidateToken validates and parses a JWT token
 func (j *JWTUtil) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Verify signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// Tokens without a kid were signed with the HMAC secret
+		kid, _ := token.Header["kid"].(string)
+		key, ok := j.keysByID[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key: %q", kid)
+		}
+
+		// Verify signing method matches the key, so a public key is never used as an HMAC secret
+		if token.Method.Alg() != key.Algorithm() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return j.secretKey, nil
-	})
+		return key.verifyKey, nil
+	}, jwt.WithTimeFunc(j.clock.Now), jwt.WithValidMethods(j.methods))
 
 	if err != nil {
 		if errors.Is(err, jwt.ErrTokenExpired) {
@@ -72,6 +120,18 @@ func (j *JWTUtil) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// JWKS returns the public keys that verify our tokens, for other services.
+// The HMAC secret is never included.
+func (j *JWTUtil) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range j.keys {
+		if !key.IsSymmetric() {
+			jwks.Keys = append(jwks.Keys, key.JWK())
+		}
+	}
+	return jwks
+}
+
 // ExtractClaims extracts claims from a token without full validation
 func (j *JWTUtil) ExtractClaims(tokenString string) (*Claims, error) {
 	token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
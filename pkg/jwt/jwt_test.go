@@ -0,0 +1,201 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"renew-guard/pkg/clock"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newRSAKey(t *testing.T) *Key {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyPEM(pkcs8PEM(t, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func newEd25519Key(t *testing.T) *Key {
+	t.Helper()
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ParseKeyPEM(pkcs8PEM(t, private))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// publicOnly returns the verification half of a key, as loaded from its public PEM
+func publicOnly(t *testing.T, key *Key) *Key {
+	t.Helper()
+	public, err := ParseKeyPEM(pkixPEM(t, key.verifyKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return public
+}
+
+func newTestUtil(t *testing.T, clk clock.Clock, keys ...*Key) *JWTUtil {
+	t.Helper()
+	util, err := NewJWTUtil(keys, 15*time.Minute, clk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return util
+}
+
+func TestSignAndVerify(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	rsaKey := newRSAKey(t)
+
+	tests := []struct {
+		name string
+		key  *Key
+	}{
+		{"RS256", rsaKey},
+		{"EdDSA", newEd25519Key(t)},
+		{"HS256", NewHMACKey("test-secret")},
+	}
+	for _, tt := range tests {
+		util := newTestUtil(t, clk, tt.key)
+		token, err := util.GenerateToken(7, "owner@example.com", "user", "session", 2)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if kid, _ := parsed.Header["kid"].(string); kid != tt.key.ID || parsed.Method.Alg() != tt.name {
+			t.Errorf("%s: signed with %s, kid %q; want kid %q", tt.name, parsed.Method.Alg(), kid, tt.key.ID)
+		}
+
+		claims, err := util.ValidateToken(token)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if claims.UserID != 7 || claims.Email != "owner@example.com" || claims.Role != "user" || claims.SessionID != "session" || claims.TokenVersion != 2 {
+			t.Errorf("%s: claims = %+v", tt.name, claims)
+		}
+	}
+
+	// A verification-only key cannot be the first one
+	if _, err := NewJWTUtil([]*Key{publicOnly(t, rsaKey), rsaKey}, time.Minute, clk); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("public signing key: error = %v, want %v", err, ErrNoSigningKey)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	oldKey := newRSAKey(t)
+	newKey := newEd25519Key(t)
+
+	before := newTestUtil(t, clk, oldKey, NewHMACKey("test-secret"))
+	oldToken, err := before.GenerateToken(1, "owner@example.com", "user", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hmacToken, err := newTestUtil(t, clk, NewHMACKey("test-secret")).GenerateToken(1, "owner@example.com", "user", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The new key signs; the retired key and the secret only verify
+	after := newTestUtil(t, clk, newKey, publicOnly(t, oldKey), NewHMACKey("test-secret"))
+	for name, token := range map[string]string{"old key": oldToken, "secret": hmacToken} {
+		if _, err := after.ValidateToken(token); err != nil {
+			t.Errorf("token signed with the %s: %v", name, err)
+		}
+	}
+	newToken, err := after.GenerateToken(1, "owner@example.com", "user", "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := before.ValidateToken(newToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token with an unknown kid: error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	// Once the retired key is dropped its tokens stop verifying
+	dropped := newTestUtil(t, clk, newKey)
+	if _, err := dropped.ValidateToken(oldToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("token signed with a dropped key: error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	clk.Advance(15 * time.Minute)
+	if _, err := after.ValidateToken(newToken); !errors.Is(err, ErrExpiredToken) {
+		t.Errorf("expired token: error = %v, want %v", err, ErrExpiredToken)
+	}
+}
+
+func TestRejectsAlgorithmConfusion(t *testing.T) {
+	clk := clock.NewFake(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	rsaKey := newRSAKey(t)
+	util := newTestUtil(t, clk, rsaKey, NewHMACKey("test-secret"))
+
+	claims := &Claims{
+		UserID: 1,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(clk.Now().Add(time.Minute)),
+		},
+	}
+	forge := func(kid string, secret []byte) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	// HS256 tokens naming the RSA key, signed with its public PEM or with
+	// the real secret, must not verify against either
+	tests := map[string]string{
+		"public key as secret": forge(rsaKey.ID, pkixPEM(t, rsaKey.verifyKey)),
+		"secret under RSA kid": forge(rsaKey.ID, []byte("test-secret")),
+	}
+	for name, token := range tests {
+		if _, err := util.ValidateToken(token); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: error = %v, want %v", name, err, ErrInvalidToken)
+		}
+	}
+	if _, err := util.ValidateToken(forge("", []byte("test-secret"))); err != nil {
+		t.Errorf("HS256 token without kid: %v", err)
+	}
+}
+
+func TestJWKSExcludesSecret(t *testing.T) {
+	rsaKey := newRSAKey(t)
+	edKey := newEd25519Key(t)
+	util := newTestUtil(t, clock.New(), edKey, publicOnly(t, rsaKey), NewHMACKey("test-secret"))
+
+	jwks := util.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("JWKS has %d keys, want the 2 asymmetric ones", len(jwks.Keys))
+	}
+	for i, want := range []*Key{edKey, rsaKey} {
+		if jwk := jwks.Keys[i]; jwk.Kid != want.ID || jwk.Alg != want.Algorithm() {
+			t.Errorf("key %d: kid %q, alg %s; want %q, %s", i, jwk.Kid, jwk.Alg, want.ID, want.Algorithm())
+		}
+	}
+
+	// Without key files there is nothing to publish
+	if jwks := newTestUtil(t, clock.New(), NewHMACKey("test-secret")).JWKS(); len(jwks.Keys) != 0 {
+		t.Errorf("HMAC-only JWKS has %d keys, want none", len(jwks.Keys))
+	}
+}
@@ -0,0 +1,105 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"testing"
+)
+
+// rfc7638Modulus is the RSA key from the example in RFC 7638 section 3.1
+const rfc7638Modulus = "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+
+func encodePEM(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+// pkcs8PEM encodes a private key the way openssl genpkey writes it
+func pkcs8PEM(t *testing.T, private interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encodePEM("PRIVATE KEY", der)
+}
+
+func pkixPEM(t *testing.T, public interface{}) []byte {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encodePEM("PUBLIC KEY", der)
+}
+
+func TestThumbprintMatchesRFC7638(t *testing.T) {
+	modulus, err := base64.RawURLEncoding.DecodeString(rfc7638Modulus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := &rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537}
+
+	key, err := ParseKeyPEM(pkixPEM(t, public))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"; key.ID != want {
+		t.Errorf("kid = %q, want %q", key.ID, want)
+	}
+	if jwk := key.JWK(); jwk.N != rfc7638Modulus || jwk.E != "AQAB" {
+		t.Errorf("JWK n = %q, e = %q; want the RFC example key", jwk.N, jwk.E)
+	}
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pem     []byte
+		alg     string
+		canSign bool
+	}{
+		{"PKCS#8 RSA", pkcs8PEM(t, rsaKey), "RS256", true},
+		{"PKCS#1 RSA", encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)), "RS256", true},
+		{"PKIX RSA public", pkixPEM(t, &rsaKey.PublicKey), "RS256", false},
+		{"PKCS#1 RSA public", encodePEM("RSA PUBLIC KEY", x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)), "RS256", false},
+		{"PKCS#8 Ed25519", pkcs8PEM(t, edPrivate), "EdDSA", true},
+		{"PKIX Ed25519 public", pkixPEM(t, edPublic), "EdDSA", false},
+	}
+	ids := make(map[string]string)
+	for _, tt := range tests {
+		key, err := ParseKeyPEM(tt.pem)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if key.Algorithm() != tt.alg || key.CanSign() != tt.canSign {
+			t.Errorf("%s: algorithm %s, can sign %v; want %s, %v", tt.name, key.Algorithm(), key.CanSign(), tt.alg, tt.canSign)
+		}
+
+		// Every encoding of a key pair gets the same kid
+		if id, seen := ids[tt.alg]; seen && id != key.ID {
+			t.Errorf("%s: kid %q, want %q like the other %s encodings", tt.name, key.ID, id, tt.alg)
+		}
+		ids[tt.alg] = key.ID
+	}
+
+	for _, data := range []string{"", "not a key", string(encodePEM("CERTIFICATE", []byte{0}))} {
+		if _, err := ParseKeyPEM([]byte(data)); err == nil {
+			t.Errorf("ParseKeyPEM(%q) accepted", data)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package notifier
+
+import "renew-guard/pkg/email"
+
+// EmailNotifier sends the HTML body through the configured email service
+type EmailNotifier struct {
+	emailService email.EmailService
+}
+
+func NewEmailNotifier(emailService email.EmailService) *EmailNotifier {
+	return &EmailNotifier{emailService: emailService}
+}
+
+func (n *EmailNotifier) Channel() string {
+	return ChannelEmail
+}
+
+func (n *EmailNotifier) Send(msg Message) error {
+	if len(msg.Attachments) > 0 {
+		return n.emailService.SendHTMLWithAttachments(msg.Recipient, msg.Subject, msg.HTMLBody, msg.Attachments)
+	}
+	return n.emailService.SendHTML(msg.Recipient, msg.Subject, msg.HTMLBody)
+}
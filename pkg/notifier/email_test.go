@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"testing"
+
+	"renew-guard/pkg/email"
+)
+
+// recordingEmailService remembers how each email was sent
+type recordingEmailService struct {
+	email.EmailService
+	sent []string
+}
+
+func (s *recordingEmailService) SendHTML(to, subject, htmlBody string) error {
+	s.sent = append(s.sent, "html:"+to+":"+subject)
+	return nil
+}
+
+func (s *recordingEmailService) SendHTMLWithAttachments(to, subject, htmlBody string, attachments []email.Attachment) error {
+	s.sent = append(s.sent, "attachments:"+to+":"+attachments[0].Filename)
+	return nil
+}
+
+func TestEmailNotifierSendsAttachmentsOnlyWhenPresent(t *testing.T) {
+	service := &recordingEmailService{}
+	notifier := NewEmailNotifier(service)
+
+	messages := []Message{
+		{Recipient: "owner@example.com", Subject: "Expiring", HTMLBody: "<p>Soon</p>"},
+		{
+			Recipient:   "owner@example.com",
+			Subject:     "Expiring",
+			HTMLBody:    "<p>Soon</p>",
+			Attachments: []email.Attachment{{Filename: "renewal.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR")}},
+		},
+	}
+	for _, msg := range messages {
+		if err := notifier.Send(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"html:owner@example.com:Expiring", "attachments:owner@example.com:renewal.ics"}
+	if len(service.sent) != len(want) || service.sent[0] != want[0] || service.sent[1] != want[1] {
+		t.Errorf("sent %v, want %v", service.sent, want)
+	}
+}
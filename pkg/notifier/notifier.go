@@ -0,0 +1,58 @@
+package notifier
+
+import (
+	"errors"
+	"fmt"
+	"renew-guard/pkg/email"
+)
+
+// Channel names understood by the registry
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack"
+	ChannelDiscord = "discord"
+)
+
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// Message is a channel-agnostic notification. Recipient is an email address
+// for the email channel and a webhook URL for the others.
+type Message struct {
+	Recipient string
+	Subject   string
+	Text      string
+	HTMLBody  string
+	Data      map[string]interface{}
+	// Attachments are only delivered by the email channel
+	Attachments []email.Attachment
+}
+
+// Notifier delivers messages over one channel
+type Notifier interface {
+	Channel() string
+	Send(msg Message) error
+}
+
+// Registry looks up notifiers by channel name
+type Registry struct {
+	notifiers map[string]Notifier
+}
+
+// NewRegistry creates a registry of the given notifiers
+func NewRegistry(notifiers ...Notifier) *Registry {
+	r := &Registry{notifiers: make(map[string]Notifier, len(notifiers))}
+	for _, n := range notifiers {
+		r.notifiers[n.Channel()] = n
+	}
+	return r
+}
+
+// Send delivers msg over the named channel
+func (r *Registry) Send(channel string, msg Message) error {
+	n, ok := r.notifiers[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+	}
+	return n.Send(msg)
+}
@@ -0,0 +1,136 @@
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// discordContentLimit is the maximum length of a Discord message
+const discordContentLimit = 2000
+
+// NewHTTPClient returns the client used by webhook notifiers. It only
+// connects to public addresses, whatever a webhook host resolves to at send
+// time and wherever it redirects, and ignores proxy settings so the check
+// applies to the real destination.
+func NewHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: refuseInternalAddress,
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+	}
+}
+
+// WebhookNotifier posts a generic JSON document to the recipient URL
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+func NewWebhookNotifier(client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{client: client}
+}
+
+func (n *WebhookNotifier) Channel() string {
+	return ChannelWebhook
+}
+
+func (n *WebhookNotifier) Send(msg Message) error {
+	return postJSON(n.client, msg.Recipient, map[string]interface{}{
+		"subject": msg.Subject,
+		"text":    msg.Text,
+		"data":    msg.Data,
+	})
+}
+
+// SlackNotifier posts to a Slack-compatible incoming webhook
+type SlackNotifier struct {
+	client *http.Client
+}
+
+func NewSlackNotifier(client *http.Client) *SlackNotifier {
+	return &SlackNotifier{client: client}
+}
+
+func (n *SlackNotifier) Channel() string {
+	return ChannelSlack
+}
+
+func (n *SlackNotifier) Send(msg Message) error {
+	return postJSON(n.client, msg.Recipient, map[string]string{
+		"text": fmt.Sprintf("*%s*\n%s", msg.Subject, msg.Text),
+	})
+}
+
+// DiscordNotifier posts to a Discord-compatible webhook
+type DiscordNotifier struct {
+	client *http.Client
+}
+
+func NewDiscordNotifier(client *http.Client) *DiscordNotifier {
+	return &DiscordNotifier{client: client}
+}
+
+func (n *DiscordNotifier) Channel() string {
+	return ChannelDiscord
+}
+
+func (n *DiscordNotifier) Send(msg Message) error {
+	content := fmt.Sprintf("**%s**\n%s", msg.Subject, msg.Text)
+	if runes := []rune(content); len(runes) > discordContentLimit {
+		content = string(runes[:discordContentLimit])
+	}
+	return postJSON(n.client, msg.Recipient, map[string]string{
+		"content": content,
+	})
+}
+
+// postJSON sends payload to webhookURL and treats any non-2xx response as a
+// failure. Errors end up in logs and notification history, so they never
+// include the URL, which may embed a secret token, or the response body.
+func postJSON(client *http.Client, webhookURL string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return ErrInvalidWebhookURL
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "RenewGuard/1.0")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Drain a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// receivedRequest is what a test webhook endpoint was sent
+type receivedRequest struct {
+	method      string
+	contentType string
+	userAgent   string
+	body        map[string]interface{}
+}
+
+// newWebhookServer starts an endpoint that records each request and answers
+// with status and body
+func newWebhookServer(t *testing.T, status int, body string) (*httptest.Server, *[]receivedRequest) {
+	t.Helper()
+	var received []receivedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		request := receivedRequest{
+			method:      r.Method,
+			contentType: r.Header.Get("Content-Type"),
+			userAgent:   r.Header.Get("User-Agent"),
+		}
+		if err := json.Unmarshal(raw, &request.body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		received = append(received, request)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func TestWebhookNotifierPostsJSON(t *testing.T) {
+	server, received := newWebhookServer(t, http.StatusNoContent, "")
+	notifier := NewWebhookNotifier(server.Client())
+
+	err := notifier.Send(Message{
+		Recipient: server.URL + "/hook",
+		Subject:   "Netflix expires in 3 days",
+		Text:      "Renew before Mar 5",
+		HTMLBody:  "<p>ignored</p>",
+		Data:      map[string]interface{}{"event": "subscription.expiring", "days_left": 3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*received) != 1 {
+		t.Fatalf("%d requests, want 1", len(*received))
+	}
+	request := (*received)[0]
+	if request.method != http.MethodPost || request.contentType != "application/json" || request.userAgent != "RenewGuard/1.0" {
+		t.Errorf("got %s with Content-Type %q and User-Agent %q", request.method, request.contentType, request.userAgent)
+	}
+	if request.body["subject"] != "Netflix expires in 3 days" || request.body["text"] != "Renew before Mar 5" {
+		t.Errorf("body = %v", request.body)
+	}
+	data, _ := request.body["data"].(map[string]interface{})
+	if data["event"] != "subscription.expiring" || data["days_left"] != float64(3) {
+		t.Errorf("data = %v", request.body["data"])
+	}
+}
+
+func TestSlackNotifierPostsText(t *testing.T) {
+	server, received := newWebhookServer(t, http.StatusOK, "ok")
+
+	err := NewSlackNotifier(server.Client()).Send(Message{
+		Recipient: server.URL,
+		Subject:   "Netflix expires in 3 days",
+		Text:      "Renew before Mar 5",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]interface{}{"text": "*Netflix expires in 3 days*\nRenew before Mar 5"}
+	if len(*received) != 1 || !reflect.DeepEqual((*received)[0].body, want) {
+		t.Errorf("received %v, want one request with %v", *received, want)
+	}
+}
+
+func TestDiscordNotifierTruncatesContent(t *testing.T) {
+	server, received := newWebhookServer(t, http.StatusNoContent, "")
+
+	err := NewDiscordNotifier(server.Client()).Send(Message{
+		Recipient: server.URL,
+		Subject:   "Digest",
+		Text:      strings.Repeat("é", 3000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(*received) != 1 {
+		t.Fatalf("%d requests, want 1", len(*received))
+	}
+	content, _ := (*received)[0].body["content"].(string)
+	if !strings.HasPrefix(content, "**Digest**\né") {
+		t.Errorf("content starts %q", content[:20])
+	}
+	if n := utf8.RuneCountInString(content); n != discordContentLimit || !utf8.ValidString(content) {
+		t.Errorf("content is %d runes, want %d valid ones", n, discordContentLimit)
+	}
+}
+
+func TestWebhookErrorsCarryOnlyTheStatus(t *testing.T) {
+	server, _ := newWebhookServer(t, http.StatusInternalServerError, "database password is hunter2")
+
+	err := NewWebhookNotifier(server.Client()).Send(Message{Recipient: server.URL + "/hook?token=secret"})
+	if err == nil {
+		t.Fatal("no error for a 500 response")
+	}
+	if got, want := err.Error(), "webhook returned status 500"; got != want {
+		t.Errorf("error = %q, want %q", got, want)
+	}
+}
+
+func TestHTTPClientRefusesInternalAddresses(t *testing.T) {
+	// httptest servers listen on loopback, like services on the host itself
+	server, received := newWebhookServer(t, http.StatusOK, "")
+
+	err := NewWebhookNotifier(NewHTTPClient()).Send(Message{Recipient: server.URL + "/hook?token=secret"})
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("error = %v, want %v", err, ErrForbiddenAddress)
+	}
+	if err != nil && strings.Contains(err.Error(), "secret") {
+		t.Errorf("error %q includes the webhook URL", err)
+	}
+	if len(*received) != 0 {
+		t.Errorf("%d requests reached the loopback server", len(*received))
+	}
+}
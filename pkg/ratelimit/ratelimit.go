@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"renew-guard/pkg/clock"
+)
+
+// Limiter allows at most limit events per key within a sliding window. State
+// is kept in memory, so limits apply per process.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	clock     clock.Clock
+	events    map[string][]time.Time
+	lastSweep time.Time
+}
+
+// New creates a limiter allowing limit events per window for each key. limit
+// must be at least one.
+func New(limit int, window time.Duration, clk clock.Clock) *Limiter {
+	return &Limiter{
+		limit:     limit,
+		window:    window,
+		clock:     clk,
+		events:    make(map[string][]time.Time),
+		lastSweep: clk.Now(),
+	}
+}
+
+// Allow records an event for key and reports whether it is within the limit.
+// When it is not, the event is not recorded and the returned duration is how
+// long until the next event would be allowed.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.sweep(now)
+
+	events := prune(l.events[key], now.Add(-l.window))
+	if len(events) >= l.limit {
+		l.events[key] = events
+		return false, events[0].Add(l.window).Sub(now)
+	}
+
+	l.events[key] = append(events, now)
+	return true, 0
+}
+
+// Check reports whether an event for key would currently be allowed, without
+// recording one. Use it with Allow to count only some outcomes, such as failures.
+func (l *Limiter) Check(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	events := prune(l.events[key], now.Add(-l.window))
+	if len(events) >= l.limit {
+		return false, events[0].Add(l.window).Sub(now)
+	}
+	return true, 0
+}
+
+// Reset forgets all events recorded for key
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.events, key)
+}
+
+// sweep drops idle keys once per window so the map does not grow unbounded
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	cutoff := now.Add(-l.window)
+	for key, events := range l.events {
+		if events = prune(events, cutoff); len(events) == 0 {
+			delete(l.events, key)
+		} else {
+			l.events[key] = events
+		}
+	}
+	l.lastSweep = now
+}
+
+// prune removes events at or before cutoff; events are in chronological order
+func prune(events []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	return events[i:]
+}
@@ -0,0 +1,119 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"renew-guard/pkg/clock"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestAllowSlidingWindow(t *testing.T) {
+	clk := clock.NewFake(start)
+	l := New(2, time.Hour, clk)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := l.Allow("a"); !ok {
+			t.Fatalf("event %d refused within limit", i+1)
+		}
+		clk.Advance(10 * time.Minute)
+	}
+
+	ok, retryAfter := l.Allow("a")
+	if ok {
+		t.Fatal("third event allowed over a limit of 2")
+	}
+	// The first event was at 12:00 and it is now 12:20
+	if retryAfter != 40*time.Minute {
+		t.Errorf("retry after = %v, want 40m", retryAfter)
+	}
+
+	// Other keys have their own budget
+	if ok, _ := l.Allow("b"); !ok {
+		t.Error("event for another key refused")
+	}
+
+	// Once the first event leaves the window one more is allowed, but not two
+	clk.Advance(40 * time.Minute)
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("event refused after the oldest one left the window")
+	}
+	if ok, _ := l.Allow("a"); ok {
+		t.Error("event allowed while the window is full again")
+	}
+}
+
+func TestRefusedEventsAreNotRecorded(t *testing.T) {
+	clk := clock.NewFake(start)
+	l := New(1, time.Hour, clk)
+
+	l.Allow("a")
+	for i := 0; i < 5; i++ {
+		clk.Advance(time.Minute)
+		l.Allow("a")
+	}
+
+	// Only the first event counts, so the key frees up an hour after it
+	clk.Set(start.Add(time.Hour))
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("refused attempts extended the window")
+	}
+}
+
+func TestCheckDoesNotRecord(t *testing.T) {
+	clk := clock.NewFake(start)
+	l := New(1, time.Hour, clk)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.Check("a"); !ok {
+			t.Fatal("check refused with no recorded events")
+		}
+	}
+
+	l.Allow("a")
+	clk.Advance(15 * time.Minute)
+	ok, retryAfter := l.Check("a")
+	if ok {
+		t.Error("check allowed over the limit")
+	}
+	if retryAfter != 45*time.Minute {
+		t.Errorf("retry after = %v, want 45m", retryAfter)
+	}
+}
+
+func TestReset(t *testing.T) {
+	l := New(1, time.Hour, clock.NewFake(start))
+
+	l.Allow("a")
+	l.Reset("a")
+	if ok, _ := l.Allow("a"); !ok {
+		t.Error("event refused after reset")
+	}
+}
+
+func TestSweepDropsIdleKeys(t *testing.T) {
+	clk := clock.NewFake(start)
+	l := New(5, time.Hour, clk)
+
+	l.Allow("idle")
+	clk.Advance(50 * time.Minute)
+	l.Allow("active")
+
+	// Sweeps run at most once per window, so nothing is dropped yet
+	clk.Advance(5 * time.Minute)
+	l.Allow("active")
+	if _, ok := l.events["idle"]; !ok {
+		t.Fatal("key swept before a full window passed")
+	}
+
+	// An hour after the last sweep "idle" has no events left in the window
+	clk.Set(start.Add(time.Hour + time.Minute))
+	l.Allow("other")
+	if _, ok := l.events["idle"]; ok {
+		t.Error("idle key not swept")
+	}
+	if got := len(l.events["active"]); got != 2 {
+		t.Errorf("active key has %d events after sweep, want 2", got)
+	}
+}
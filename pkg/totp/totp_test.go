@@ -0,0 +1,120 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 Appendix B, "12345678901234567890",
+// in base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// rfcVectors are the SHA-1 rows of RFC 6238 Appendix B. The RFC lists 8 digit
+// codes; 6 digit codes are their last six digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeRFC6238Vectors(t *testing.T) {
+	for _, v := range rfcVectors {
+		code, err := Code(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code at %d: %v", v.unix, err)
+		}
+		if code != v.code {
+			t.Errorf("Code at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestCodeAcceptsLowerCaseSecret(t *testing.T) {
+	code, err := Code("gezdgnbvgy3tqojqgezdgnbvgy3tqojq", Step(time.Unix(59, 0)))
+	if err != nil || code != "287082" {
+		t.Errorf("Code = %q, %v; want 287082", code, err)
+	}
+}
+
+func TestCodeRejectsInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted a secret that is not base32")
+	}
+}
+
+func TestValidateRFC6238Vectors(t *testing.T) {
+	for _, v := range rfcVectors {
+		now := time.Unix(v.unix, 0)
+		step, ok := Validate(rfcSecret, v.code, now, 0)
+		if !ok {
+			t.Errorf("Validate rejected %s at %d", v.code, v.unix)
+			continue
+		}
+		if step != Step(now) {
+			t.Errorf("Validate at %d matched step %d, want %d", v.unix, step, Step(now))
+		}
+	}
+}
+
+func TestValidateNormalizesInput(t *testing.T) {
+	if _, ok := Validate(rfcSecret, " 287 082 ", time.Unix(59, 0), 0); !ok {
+		t.Error("Validate rejected a code with spaces")
+	}
+	if _, ok := Validate(rfcSecret, "28708", time.Unix(59, 0), 0); ok {
+		t.Error("Validate accepted a short code")
+	}
+}
+
+func TestValidateSkewWindow(t *testing.T) {
+	// The code for 1111111111 belongs to step 37037037
+	now := time.Unix(1111111111, 0)
+	code := "050471"
+	step := Step(now)
+
+	tests := []struct {
+		name  string
+		shift int64 // Steps the server clock is ahead of the code
+		skew  int
+		ok    bool
+	}{
+		{"same step", 0, 1, true},
+		{"one step behind", 1, 1, true},
+		{"one step ahead", -1, 1, true},
+		{"two steps behind", 2, 1, false},
+		{"two steps ahead", -2, 1, false},
+		{"one step behind without skew", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := now.Add(time.Duration(tt.shift) * Period)
+			matched, ok := Validate(rfcSecret, code, at, tt.skew)
+			if ok != tt.ok {
+				t.Fatalf("Validate = %v, want %v", ok, tt.ok)
+			}
+			if ok && matched != step {
+				t.Errorf("matched step %d, want the code's step %d", matched, step)
+			}
+		})
+	}
+}
+
+func TestGenerateSecretRoundTrips(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	code, err := Code(secret, Step(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := Validate(secret, code, now, 1); !ok {
+		t.Error("generated secret does not validate its own code")
+	}
+}
@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var currencyCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// currencyExponents lists ISO 4217 currencies whose minor unit is not 1/100
+var currencyExponents = map[string]int{
+	"BHD": 3, "BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "IQD": 3, "ISK": 0,
+	"JOD": 3, "JPY": 0, "KMF": 0, "KRW": 0, "KWD": 3, "LYD": 3, "OMR": 3,
+	"PYG": 0, "RWF": 0, "TND": 3, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0,
+	"XAF": 0, "XOF": 0, "XPF": 0,
+}
+
+// NormalizeCurrency upper-cases and trims an ISO 4217 currency code
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsValidCurrency checks that code looks like an ISO 4217 alphabetic code
+func IsValidCurrency(code string) bool {
+	return currencyCodeRegex.MatchString(code)
+}
+
+// CurrencyExponent returns the number of minor-unit digits for a currency
+func CurrencyExponent(code string) int {
+	if exp, ok := currencyExponents[code]; ok {
+		return exp
+	}
+	return 2
+}
+
+// FormatMoney renders an amount in minor units as e.g. "USD 12.99"
+func FormatMoney(amountMinor int64, currency string) string {
+	exp := CurrencyExponent(currency)
+	if exp == 0 {
+		return fmt.Sprintf("%s %d", currency, amountMinor)
+	}
+
+	sign := ""
+	if amountMinor < 0 {
+		sign = "-"
+		amountMinor = -amountMinor
+	}
+
+	divisor := int64(1)
+	for i := 0; i < exp; i++ {
+		divisor *= 10
+	}
+
+	return fmt.Sprintf("%s %s%d.%0*d", currency, sign, amountMinor/divisor, exp, amountMinor%divisor)
+}
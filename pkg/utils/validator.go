@@ -3,6 +3,7 @@ package utils
 import (
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 // IsValidEmail validates email format
@@ -22,3 +23,9 @@ func IsValidPassword(password string) bool {
 	// Minimum 6 characters
 	return len(password) >= 6
 }
+
+// HasControlChars reports whether s contains control characters such as
+// newlines, which have no place in names shown in emails and headers
+func HasControlChars(s string) bool {
+	return strings.IndexFunc(s, unicode.IsControl) >= 0
+}